@@ -3,15 +3,19 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port      string
-	DBPath    string
-	JWTSecret string
-	GinMode   string
+	Port          string
+	DBPath        string
+	JWTSecret     string
+	GinMode       string
+	AdminUsername string // 首次安装时创建的超级管理员用户名
+	AdminPassword string // 首次安装时的超级管理员密码，留空则随机生成
+	PowDifficulty int    // PoW 挑战要求的哈希前导零（十六进制位）个数
 }
 
 var AppConfig *Config
@@ -22,10 +26,13 @@ func LoadConfig() {
 	_ = godotenv.Load()
 
 	AppConfig = &Config{
-		Port:      getEnv("SERVER_PORT", "8080"),
-		DBPath:    getEnv("DATABASE_PATH", "./invite.db"),
-		JWTSecret: getEnv("JWT_SECRET", "default_jwt_secret_key_change_me"),
-		GinMode:   getEnv("GIN_MODE", "debug"),
+		Port:          getEnv("SERVER_PORT", "8080"),
+		DBPath:        getEnv("DATABASE_PATH", "./invite.db"),
+		JWTSecret:     getEnv("JWT_SECRET", "default_jwt_secret_key_change_me"),
+		GinMode:       getEnv("GIN_MODE", "debug"),
+		AdminUsername: getEnv("ADMIN_USERNAME", "admin"),
+		AdminPassword: os.Getenv("ADMIN_PASSWORD"),
+		PowDifficulty: getEnvInt("DEFAULT_POW_DIFFICULTY", 4),
 	}
 
 	log.Printf("Config loaded: Port=%s, DBPath=%s, Mode=%s\n", AppConfig.Port, AppConfig.DBPath, AppConfig.GinMode)
@@ -38,3 +45,11 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -1,10 +1,18 @@
 package database
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"invite-backend/config"
+	"invite-backend/utils"
+
 	_ "modernc.org/sqlite"
 )
 
@@ -13,7 +21,14 @@ var DB *sql.DB
 // InitDB 初始化数据库
 func InitDB(dbPath string) error {
 	var err error
-	DB, err = sql.Open("sqlite", dbPath)
+	// 设置忙等待超时，避免后台任务与请求并发读写时直接返回 SQLITE_BUSY
+	dsn := dbPath
+	if strings.Contains(dsn, "?") {
+		dsn += "&_pragma=busy_timeout(5000)"
+	} else {
+		dsn += "?_pragma=busy_timeout(5000)"
+	}
+	DB, err = sql.Open("sqlite", dsn)
 	if err != nil {
 		return err
 	}
@@ -33,6 +48,11 @@ func InitDB(dbPath string) error {
 		return err
 	}
 
+	// 初始化内置角色
+	if err = initDefaultRoles(); err != nil {
+		return err
+	}
+
 	// 迁移管理员数据并初始化默认管理员
 	if err = migrateAdmins(); err != nil {
 		return err
@@ -52,33 +72,83 @@ func migrateAdmins() error {
 
 	if count == 0 {
 		// 尝试从 settings 表获取旧的管理员信息
+		// 旧版 settings 中的 SHA256 哈希会在首次登录时自动升级为 argon2id
 		var username, passwordHash string
+		mustChange := false
 		err = DB.QueryRow("SELECT value FROM settings WHERE key = 'admin_username'").Scan(&username)
 		if err == nil {
 			err = DB.QueryRow("SELECT value FROM settings WHERE key = 'admin_password_hash'").Scan(&passwordHash)
 		}
 
-		// 如果获取失败（新安装），使用默认值
+		// 如果获取失败（新安装），使用环境变量中的账号，未配置密码时随机生成一次性密码
+		// 无论哪种方式，初始密码都要求首次登录后修改
 		if err != nil {
-			username = "admin"
-			passwordHash = "8c6976e5b5410415bde908bd4dee15dfb167a9c873fc4bb8a81f6f2ab448a918" // admin
+			username = config.AppConfig.AdminUsername
+			password := config.AppConfig.AdminPassword
+			mustChange = true
+			if password == "" {
+				password = generateRandomPassword()
+				log.Println("==================================================")
+				log.Printf("Initial super admin: %s / %s\n", username, password)
+				log.Println("This password is shown only once, please change it after first login")
+				log.Println("==================================================")
+			}
+			passwordHash = utils.HashPassword(password)
 		}
 
 		// 插入第一个超级管理员
 		_, err = DB.Exec(`
-			INSERT INTO admins (username, password_hash, role, created_at, updated_at)
-			VALUES (?, ?, 'super', ?, ?)
-		`, username, passwordHash, time.Now().Unix(), time.Now().Unix())
+			INSERT INTO admins (username, password_hash, role, must_change_password, created_at, updated_at)
+			VALUES (?, ?, 'super', ?, ?, ?)
+		`, username, passwordHash, mustChange, time.Now().Unix(), time.Now().Unix())
 		if err != nil {
 			return err
 		}
 
 		log.Printf("Default super admin '%s' created\n", username)
+		return nil
+	}
+
+	// 旧版本默认创建的 admin/admin 账号，强制其在下次登录时修改密码
+	// 旧默认密码只可能以无盐 SHA256 存储，直接比对哈希，避免每次启动都对 argon2id 哈希做完整派生
+	defaultHash := fmt.Sprintf("%x", sha256.Sum256([]byte("admin")))
+	rows, err := DB.Query("SELECT id, username, password_hash FROM admins WHERE must_change_password = 0 AND password_hash IS NOT NULL AND password_hash != ''")
+	if err != nil {
+		return err
+	}
+	var weakIDs []int
+	for rows.Next() {
+		var id int
+		var username, passwordHash string
+		if err := rows.Scan(&id, &username, &passwordHash); err != nil {
+			continue
+		}
+		if strings.HasPrefix(passwordHash, "$argon2id$") {
+			continue
+		}
+		if strings.ToLower(passwordHash) == defaultHash {
+			weakIDs = append(weakIDs, id)
+			log.Printf("Admin '%s' still uses the default password, password change will be required\n", username)
+		}
+	}
+	rows.Close()
+
+	for _, id := range weakIDs {
+		if _, err := DB.Exec("UPDATE admins SET must_change_password = 1 WHERE id = ?", id); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// generateRandomPassword 生成一次性随机密码
+func generateRandomPassword() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
 // ToUnixTimestamp 将数据库返回的时间值转换为 Unix 时间戳
 func ToUnixTimestamp(v interface{}) int64 {
 	switch t := v.(type) {
@@ -109,13 +179,24 @@ func createTables() error {
 		updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
 		admin_note TEXT,
 		review_opinion TEXT,
-		processed_by INTEGER REFERENCES admins(id)
+		processed_by INTEGER REFERENCES admins(id),
+		locked_by INTEGER REFERENCES admins(id), -- 认领人
+		locked_until INTEGER, -- 认领锁过期时间
+		assigned_to INTEGER REFERENCES admins(id), -- 自动分配的审核员
+		assigned_at INTEGER,
+		first_approved_by INTEGER REFERENCES admins(id), -- 双人审核时的初审人
+		decided_at INTEGER, -- 首次作出批准或拒绝决定的时间，申诉改判不会修改
+		decided_by INTEGER REFERENCES admins(id), -- 首次作出决定的审核员
+		risk_score INTEGER NOT NULL DEFAULT 0, -- 提交时的风险分
+		risk_reasons TEXT, -- 命中的风控规则（JSON）
+		risk_flagged INTEGER NOT NULL DEFAULT 0 -- 风险分达到标记阈值，需人工复核
 	);
 
 	CREATE TABLE IF NOT EXISTS verification_codes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		email TEXT NOT NULL,
 		code TEXT NOT NULL,
+		ip TEXT, -- 请求发送验证码的 IP
 		expires_at INTEGER NOT NULL,
 		created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
 	);
@@ -124,7 +205,12 @@ func createTables() error {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		code TEXT NOT NULL UNIQUE,
 		is_used INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'available', -- available, assigned, redeemed, revoked
 		application_id INTEGER REFERENCES applications(id),
+		assigned_at INTEGER,
+		redeemed_at INTEGER,
+		revoked_at INTEGER,
+		expires_at INTEGER,
 		created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
 	);
 
@@ -150,12 +236,215 @@ func createTables() error {
 		password_hash TEXT, -- 对于 Linux DO 用户，该字段可以为空
 		role TEXT NOT NULL DEFAULT 'reviewer', -- super, reviewer
 		linuxdo_id TEXT UNIQUE, -- Linux DO 的用户 ID
+		email TEXT, -- 接收系统通知的邮箱
+		must_change_password INTEGER NOT NULL DEFAULT 0, -- 是否需要在下次登录后修改密码
+		totp_secret TEXT, -- TOTP 密钥（启用前为待确认状态）
+		totp_enabled INTEGER NOT NULL DEFAULT 0,
+		totp_last_counter INTEGER NOT NULL DEFAULT 0, -- 最近一次成功验证的时间计数器，防止重放
+		token_version INTEGER NOT NULL DEFAULT 0, -- 递增后该管理员已签发的令牌全部失效
+		available INTEGER NOT NULL DEFAULT 1, -- 是否接收自动分配
+		last_assigned_at INTEGER, -- 最近一次被自动分配的时间，用于轮询
+		created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+		updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+	);
+
+	CREATE TABLE IF NOT EXISTS admin_recovery_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		admin_id INTEGER NOT NULL REFERENCES admins(id),
+		code_hash TEXT NOT NULL,
+		used_at INTEGER,
+		created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+	);
+
+	CREATE TABLE IF NOT EXISTS admin_sessions (
+		jti TEXT PRIMARY KEY, -- 令牌 ID
+		admin_id INTEGER NOT NULL REFERENCES admins(id),
+		ip TEXT,
+		user_agent TEXT,
+		created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+		last_seen_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+		expires_at INTEGER NOT NULL,
+		revoked_at INTEGER -- 不为空表示已吊销
+	);
+
+	CREATE TABLE IF NOT EXISTS application_comments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		application_id INTEGER NOT NULL REFERENCES applications(id),
+		admin_id INTEGER NOT NULL,
+		admin_username TEXT NOT NULL, -- 冗余保存，管理员删除后仍可显示
+		content TEXT NOT NULL,
+		created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+	);
+
+	CREATE TABLE IF NOT EXISTS application_comment_mentions (
+		comment_id INTEGER NOT NULL REFERENCES application_comments(id),
+		admin_id INTEGER NOT NULL REFERENCES admins(id),
+		PRIMARY KEY (comment_id, admin_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS roles (
+		name TEXT PRIMARY KEY,
+		description TEXT,
+		builtin INTEGER NOT NULL DEFAULT 0, -- 内置角色不可删除
 		created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
 		updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
 	);
 
+	CREATE TABLE IF NOT EXISTS role_permissions (
+		role_name TEXT NOT NULL REFERENCES roles(name),
+		permission TEXT NOT NULL,
+		PRIMARY KEY (role_name, permission)
+	);
+
+	CREATE TABLE IF NOT EXISTS role_permission_grants (
+		role_name TEXT NOT NULL REFERENCES roles(name),
+		permission TEXT NOT NULL, -- 升级时已为内置角色补充过的权限，管理员移除后不再补充
+		PRIMARY KEY (role_name, permission)
+	);
+
+	CREATE TABLE IF NOT EXISTS admin_refresh_tokens (
+		token_hash TEXT PRIMARY KEY, -- 刷新令牌的 SHA256 哈希
+		session_jti TEXT NOT NULL REFERENCES admin_sessions(jti), -- 所属令牌族（即登录会话）
+		admin_id INTEGER NOT NULL REFERENCES admins(id),
+		created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+		expires_at INTEGER NOT NULL,
+		used_at INTEGER -- 已轮换时间，再次出现即视为重放
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_applications_email ON applications(email);
 	CREATE INDEX IF NOT EXISTS idx_applications_device ON applications(device_id);
+	CREATE TABLE IF NOT EXISTS review_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL, -- 支持 {{email}}、{{site_name}} 占位符
+		kind TEXT NOT NULL, -- approval, rejection
+		created_by TEXT,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS review_template_usage (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		template_id INTEGER NOT NULL,
+		application_id INTEGER NOT NULL,
+		admin_id INTEGER NOT NULL,
+		used_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_review_template_usage_template ON review_template_usage(template_id);
+
+	CREATE TABLE IF NOT EXISTS appeals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		application_id INTEGER NOT NULL UNIQUE REFERENCES applications(id), -- 每个申请仅可申诉一次
+		email TEXT NOT NULL,
+		content TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending', -- pending, overturned, upheld
+		reviewer_id INTEGER REFERENCES admins(id),
+		reviewer_username TEXT,
+		decision_opinion TEXT,
+		created_at INTEGER NOT NULL,
+		decided_at INTEGER
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_appeals_status ON appeals(status);
+
+	CREATE TABLE IF NOT EXISTS pow_challenges (
+		salt TEXT PRIMARY KEY,
+		fingerprint TEXT NOT NULL, -- 挑战绑定的设备指纹
+		ip TEXT,
+		difficulty INTEGER NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL,
+		used_at INTEGER -- 每个挑战只能使用一次
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_pow_challenges_created_at ON pow_challenges(created_at);
+
+	CREATE TABLE IF NOT EXISTS security_failures (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL, -- pow, captcha, code
+		ip TEXT,
+		fingerprint TEXT,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_security_failures_created_at ON security_failures(created_at);
+
+	CREATE TABLE IF NOT EXISTS email_domain_blocklist (
+		domain TEXT PRIMARY KEY, -- 以 *. 开头表示匹配任意子域名
+		source TEXT NOT NULL DEFAULT 'custom', -- bundled（内置）, custom（管理员添加）
+		created_by INTEGER REFERENCES admins(id),
+		created_at INTEGER NOT NULL,
+		removed_at INTEGER -- 被移除的内置条目保留记录，避免重启后重新导入
+	);
+
+	CREATE TABLE IF NOT EXISTS email_domain_rejections (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT NOT NULL,
+		domain TEXT NOT NULL,
+		reason TEXT NOT NULL, -- blocklist, no_mx
+		matched TEXT, -- 命中的黑名单条目
+		ip TEXT,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_email_domain_rejections_matched ON email_domain_rejections(matched);
+
+	CREATE TABLE IF NOT EXISTS reason_lsh_index (
+		application_id INTEGER NOT NULL REFERENCES applications(id),
+		band INTEGER NOT NULL, -- MinHash 签名分段序号
+		bucket INTEGER NOT NULL -- 该分段的哈希值，相同即为候选相似申请
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_reason_lsh_index_bucket ON reason_lsh_index(band, bucket);
+	CREATE INDEX IF NOT EXISTS idx_reason_lsh_index_app ON reason_lsh_index(application_id);
+
+	CREATE TABLE IF NOT EXISTS application_similarities (
+		application_id INTEGER NOT NULL REFERENCES applications(id),
+		similar_id INTEGER NOT NULL REFERENCES applications(id), -- 更早提交的相似申请
+		similarity REAL NOT NULL, -- 理由 3-gram 的 Jaccard 相似度
+		PRIMARY KEY (application_id, similar_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_application_similarities_similar ON application_similarities(similar_id);
+
+	CREATE TABLE IF NOT EXISTS application_manage_tokens (
+		token_hash TEXT PRIMARY KEY, -- 仅存储令牌哈希
+		application_id INTEGER NOT NULL REFERENCES applications(id),
+		email TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL,
+		used_at INTEGER -- 一次性链接，使用后失效
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_application_manage_tokens_app ON application_manage_tokens(application_id);
+
+	CREATE TABLE IF NOT EXISTS application_edits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		application_id INTEGER NOT NULL REFERENCES applications(id),
+		old_reason TEXT NOT NULL,
+		new_reason TEXT NOT NULL,
+		ip TEXT,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_application_edits_app ON application_edits(application_id);
+
+	CREATE TABLE IF NOT EXISTS email_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL, -- approval, rejection
+		recipient TEXT NOT NULL,
+		payload TEXT NOT NULL, -- JSON 格式的邮件参数
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		created_at INTEGER NOT NULL,
+		next_attempt_at INTEGER NOT NULL,
+		sent_at INTEGER,
+		failed_at INTEGER -- 超过重试次数后放弃
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_email_queue_pending ON email_queue(sent_at, failed_at, next_attempt_at);
+
 	CREATE TABLE IF NOT EXISTS audit_logs (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		admin_id INTEGER REFERENCES admins(id),
@@ -187,9 +476,122 @@ func createTables() error {
 	// 检查并添加 processed_by 字段
 	_, _ = DB.Exec("ALTER TABLE applications ADD COLUMN processed_by INTEGER")
 
+	// 检查并添加邀请码分配时间、过期时间字段
+	_, _ = DB.Exec("ALTER TABLE invitation_codes ADD COLUMN assigned_at INTEGER")
+	_, _ = DB.Exec("ALTER TABLE invitation_codes ADD COLUMN expires_at INTEGER")
+	// 检查并添加邀请码生命周期字段，旧数据按是否已分配推导状态
+	if _, err := DB.Exec("ALTER TABLE invitation_codes ADD COLUMN status TEXT NOT NULL DEFAULT 'available'"); err == nil {
+		_, _ = DB.Exec("UPDATE invitation_codes SET status = 'assigned' WHERE application_id IS NOT NULL")
+		_, _ = DB.Exec("UPDATE invitation_codes SET status = 'redeemed' WHERE is_used = 1")
+	}
+	_, _ = DB.Exec("ALTER TABLE invitation_codes ADD COLUMN redeemed_at INTEGER")
+	_, _ = DB.Exec("ALTER TABLE invitation_codes ADD COLUMN revoked_at INTEGER")
+	// 永不过期统一存储为 NULL，旧数据中的 0 一并归一
+	_, _ = DB.Exec("UPDATE invitation_codes SET expires_at = NULL WHERE expires_at = 0")
+	// 检查并添加管理员通知邮箱字段
+	_, _ = DB.Exec("ALTER TABLE admins ADD COLUMN email TEXT")
+	// 检查并添加强制修改密码字段
+	_, _ = DB.Exec("ALTER TABLE admins ADD COLUMN must_change_password INTEGER NOT NULL DEFAULT 0")
+	// 检查并添加两步验证字段
+	_, _ = DB.Exec("ALTER TABLE admins ADD COLUMN totp_secret TEXT")
+	_, _ = DB.Exec("ALTER TABLE admins ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0")
+	_, _ = DB.Exec("ALTER TABLE admins ADD COLUMN totp_last_counter INTEGER NOT NULL DEFAULT 0")
+	_, _ = DB.Exec("CREATE INDEX IF NOT EXISTS idx_admin_recovery_codes_admin ON admin_recovery_codes(admin_id)")
+	// 检查并添加令牌版本字段
+	_, _ = DB.Exec("ALTER TABLE admins ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0")
+	_, _ = DB.Exec("CREATE INDEX IF NOT EXISTS idx_admin_sessions_admin ON admin_sessions(admin_id)")
+	_, _ = DB.Exec("CREATE INDEX IF NOT EXISTS idx_admin_refresh_tokens_session ON admin_refresh_tokens(session_jti)")
+	// 检查并添加申请认领锁字段
+	_, _ = DB.Exec("ALTER TABLE applications ADD COLUMN locked_by INTEGER")
+	_, _ = DB.Exec("ALTER TABLE applications ADD COLUMN locked_until INTEGER")
+	// 检查并添加自动分配字段
+	_, _ = DB.Exec("ALTER TABLE applications ADD COLUMN assigned_to INTEGER")
+	_, _ = DB.Exec("ALTER TABLE applications ADD COLUMN assigned_at INTEGER")
+	_, _ = DB.Exec("ALTER TABLE admins ADD COLUMN available INTEGER NOT NULL DEFAULT 1")
+	_, _ = DB.Exec("ALTER TABLE admins ADD COLUMN last_assigned_at INTEGER")
+	_, _ = DB.Exec("CREATE INDEX IF NOT EXISTS idx_applications_assigned_to ON applications(assigned_to)")
+	_, _ = DB.Exec("CREATE INDEX IF NOT EXISTS idx_application_comments_app ON application_comments(application_id)")
+	// 检查并添加双人审核初审人字段
+	_, _ = DB.Exec("ALTER TABLE applications ADD COLUMN first_approved_by INTEGER")
+	// 检查并添加首次决定字段，旧数据取审计日志中最早的批准/拒绝记录，没有记录时取最后处理时间
+	if _, err := DB.Exec("ALTER TABLE applications ADD COLUMN decided_at INTEGER"); err == nil {
+		_, _ = DB.Exec("ALTER TABLE applications ADD COLUMN decided_by INTEGER")
+		_, _ = DB.Exec(`
+			UPDATE applications SET
+				decided_at = COALESCE((
+					SELECT l.created_at FROM audit_logs l
+					WHERE l.application_id = applications.id AND l.action IN ('approved', 'rejected') AND typeof(l.created_at) = 'integer'
+					ORDER BY l.created_at ASC, l.id ASC LIMIT 1
+				), updated_at),
+				decided_by = COALESCE((
+					SELECT l.admin_id FROM audit_logs l
+					WHERE l.application_id = applications.id AND l.action IN ('approved', 'rejected') AND typeof(l.created_at) = 'integer'
+					ORDER BY l.created_at ASC, l.id ASC LIMIT 1
+				), processed_by)
+			WHERE status IN ('approved', 'rejected')
+		`)
+	}
+	// 检查并添加验证码发送 IP 字段，用于申请详情的风控排查
+	_, _ = DB.Exec("ALTER TABLE verification_codes ADD COLUMN ip TEXT")
+	// 检查并添加风险评分字段
+	_, _ = DB.Exec("ALTER TABLE applications ADD COLUMN risk_score INTEGER NOT NULL DEFAULT 0")
+	_, _ = DB.Exec("ALTER TABLE applications ADD COLUMN risk_reasons TEXT")
+	_, _ = DB.Exec("ALTER TABLE applications ADD COLUMN risk_flagged INTEGER NOT NULL DEFAULT 0")
+
 	// 添加性能索引
 	_, _ = DB.Exec("CREATE INDEX IF NOT EXISTS idx_applications_ip ON applications(ip)")
 	_, _ = DB.Exec("CREATE INDEX IF NOT EXISTS idx_applications_processed_by ON applications(processed_by)")
+	_, _ = DB.Exec("CREATE INDEX IF NOT EXISTS idx_invitation_codes_app ON invitation_codes(application_id)")
+	_, _ = DB.Exec("CREATE INDEX IF NOT EXISTS idx_invitation_codes_status ON invitation_codes(status)")
+
+	return nil
+}
+
+// initDefaultRoles 初始化内置角色，已存在的角色保留管理员调整过的权限
+// 后续版本为内置角色增加的权限写在 added 中，升级时为已存在的角色补充一次
+func initDefaultRoles() error {
+	defaultRoles := []struct {
+		name        string
+		description string
+		permissions []string
+		added       []string
+	}{
+		{"super", "超级管理员，拥有全部权限", nil, nil},
+		{"reviewer", "审核员，负责审核申请", []string{"applications.review"}, []string{"appeals.review"}},
+		{"moderator", "版主，负责审核申请与管理公告", []string{"applications.review", "announcements.manage"}, []string{"appeals.review"}},
+	}
+
+	now := time.Now().Unix()
+	for _, role := range defaultRoles {
+		res, err := DB.Exec(`
+			INSERT INTO roles (name, description, builtin, created_at, updated_at)
+			VALUES (?, ?, 1, ?, ?)
+			ON CONFLICT(name) DO NOTHING
+		`, role.name, role.description, now, now)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			for _, p := range role.permissions {
+				if _, err := DB.Exec("INSERT OR IGNORE INTO role_permissions (role_name, permission) VALUES (?, ?)", role.name, p); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, p := range role.added {
+			res, err := DB.Exec("INSERT OR IGNORE INTO role_permission_grants (role_name, permission) VALUES (?, ?)", role.name, p)
+			if err != nil {
+				return err
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				continue
+			}
+			if _, err := DB.Exec("INSERT OR IGNORE INTO role_permissions (role_name, permission) VALUES (?, ?)", role.name, p); err != nil {
+				return err
+			}
+		}
+	}
 
 	return nil
 }
@@ -212,6 +614,24 @@ func initDefaultSettings() error {
 		"linuxdo_client_secret":       "",
 		"linuxdo_min_trust_level":     "3",
 		"allow_auto_admin_reg":        "true",
+		"invite_pool_low_threshold":   "10",
+		"require_2fa_for_super":       "false",
+		"review_lock_minutes":         "15",
+		"assignment_mode":             "off",
+		"assignment_max_queue":        "20",
+		"assignment_timeout_minutes":  "60",
+		"require_dual_approval":       "false",
+		"site_url":                    "",
+		"reapply_cooldown_days":       "0",
+		"max_rejections_per_email":    "0",
+		"review_sla_hours":            "48",
+		"pow_difficulty_override":     "",
+		"pow_surge_volume":            "30",
+		"risk_flag_score":             "40",
+		"risk_reject_score":           "100",
+		"email_blocklist_enabled":     "false",
+		"email_mx_check_enabled":      "false",
+		"reason_similarity_threshold": "80",
 	}
 
 	for key, value := range defaultSettings {
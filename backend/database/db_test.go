@@ -0,0 +1,73 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"invite-backend/config"
+)
+
+func rolePermissions(t *testing.T, role string) map[string]bool {
+	t.Helper()
+	rows, err := DB.Query("SELECT permission FROM role_permissions WHERE role_name = ?", role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	perms := make(map[string]bool)
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			t.Fatal(err)
+		}
+		perms[p] = true
+	}
+	return perms
+}
+
+func TestInitDefaultRolesGrantsAddedPermissionsOnce(t *testing.T) {
+	config.AppConfig = &config.Config{AdminUsername: "admin", AdminPassword: "test-password", JWTSecret: "test"}
+	if err := InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.Close() })
+
+	if perms := rolePermissions(t, "reviewer"); !perms["applications.review"] || !perms["appeals.review"] {
+		t.Errorf("fresh reviewer permissions = %v", perms)
+	}
+	if perms := rolePermissions(t, "moderator"); !perms["appeals.review"] || perms["templates.manage"] {
+		t.Errorf("fresh moderator permissions = %v", perms)
+	}
+
+	// 模拟升级前的数据库：角色已存在，但没有补充记录与新权限
+	if _, err := DB.Exec("DELETE FROM role_permission_grants"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec("DELETE FROM role_permissions WHERE permission = 'appeals.review'"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec("DELETE FROM role_permissions WHERE role_name = 'moderator' AND permission = 'announcements.manage'"); err != nil {
+		t.Fatal(err)
+	}
+	if err := initDefaultRoles(); err != nil {
+		t.Fatal(err)
+	}
+	if perms := rolePermissions(t, "reviewer"); !perms["appeals.review"] {
+		t.Errorf("upgraded reviewer permissions = %v", perms)
+	}
+	// 管理员调整过的原有权限保持不变
+	if perms := rolePermissions(t, "moderator"); !perms["appeals.review"] || perms["announcements.manage"] {
+		t.Errorf("upgraded moderator permissions = %v", perms)
+	}
+
+	// 补充过之后被管理员移除的权限不再补充
+	if _, err := DB.Exec("DELETE FROM role_permissions WHERE role_name = 'reviewer' AND permission = 'appeals.review'"); err != nil {
+		t.Fatal(err)
+	}
+	if err := initDefaultRoles(); err != nil {
+		t.Fatal(err)
+	}
+	if perms := rolePermissions(t, "reviewer"); perms["appeals.review"] {
+		t.Errorf("removed permission was granted again: %v", perms)
+	}
+}
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"invite-backend/database"
+	"invite-backend/middleware"
 	"invite-backend/models"
 	"invite-backend/services"
 	"invite-backend/utils"
@@ -29,10 +33,7 @@ func GetApplications(c *gin.Context) {
 	}
 
 	// 基础查询
-	baseQuery := `
-		FROM applications a 
-		LEFT JOIN admins ad ON a.processed_by = ad.id 
-		WHERE 1=1`
+	baseQuery := applicationFromClause + " WHERE 1=1"
 	var args []interface{}
 
 	if status != "" {
@@ -45,6 +46,25 @@ func GetApplications(c *gin.Context) {
 		args = append(args, "%"+search+"%", "%"+search+"%")
 	}
 
+	// 超过审核时效仍未处理的申请
+	sla := services.ReviewSLA()
+	if c.Query("overdue") == "true" && sla > 0 {
+		baseQuery += " AND a.status IN ('pending', 'pending_second_review') AND a.created_at <= ?"
+		args = append(args, time.Now().Add(-sla).Unix())
+	}
+
+	// 风险分达到标记阈值的申请
+	if c.Query("flagged") == "true" {
+		baseQuery += " AND a.risk_flagged = 1"
+	}
+
+	// 我的队列：分配给当前管理员的申请
+	if c.Query("queue") == "mine" {
+		adminID, _ := c.Get("admin_id")
+		baseQuery += " AND a.assigned_to = ?"
+		args = append(args, adminID)
+	}
+
 	// 获取总数
 	var total int
 	err := database.DB.QueryRow("SELECT COUNT(*) "+baseQuery, args...).Scan(&total)
@@ -54,11 +74,7 @@ func GetApplications(c *gin.Context) {
 	}
 
 	// 获取分页数据
-	query := `
-		SELECT 
-			a.id, a.email, a.reason, a.status, a.device_id, a.ip, 
-			a.created_at, a.updated_at, a.admin_note, a.review_opinion, 
-			a.processed_by, ad.username as admin_username ` + baseQuery + `
+	query := applicationSelectColumns + baseQuery + `
 		ORDER BY a.created_at DESC 
 		LIMIT ? OFFSET ?`
 
@@ -71,57 +87,127 @@ func GetApplications(c *gin.Context) {
 	defer rows.Close()
 
 	var apps []models.Application
+	now := time.Now()
 	for rows.Next() {
-		var app models.Application
-		var createdAtVal, updatedAtVal interface{}
-		var adminNote, reviewOpinion, adminUsername sql.NullString
-		var processedBy sql.NullInt64
-
-		err := rows.Scan(
-			&app.ID, &app.Email, &app.Reason, &app.Status,
-			&app.DeviceID, &app.IP, &createdAtVal, &updatedAtVal, &adminNote, &reviewOpinion,
-			&processedBy, &adminUsername,
-		)
+		app, err := scanApplication(rows)
 		if err != nil {
 			continue
 		}
-
-		app.CreatedAt = time.Unix(database.ToUnixTimestamp(createdAtVal), 0)
-		app.UpdatedAt = time.Unix(database.ToUnixTimestamp(updatedAtVal), 0)
-		if adminNote.Valid {
-			app.AdminNote = adminNote.String
-		}
-		if reviewOpinion.Valid {
-			app.ReviewOpinion = reviewOpinion.String
-		}
-		if processedBy.Valid {
-			id := int(processedBy.Int64)
-			app.ProcessedBy = &id
-		}
-		if adminUsername.Valid {
-			app.AdminUsername = adminUsername.String
+		if services.IsAwaitingReview(app.Status) {
+			age := now.Sub(app.CreatedAt)
+			app.AgeSeconds = int64(age.Seconds())
+			app.Overdue = sla > 0 && age >= sla
+		} else {
+			app.AgeSeconds = int64(app.UpdatedAt.Sub(app.CreatedAt).Seconds())
 		}
-
 		apps = append(apps, app)
 	}
 
+	// 附带理由最相似的更早申请
+	ids := make([]int, len(apps))
+	for i := range apps {
+		ids[i] = apps[i].ID
+	}
+	if similar, err := services.GetSimilarApplications(ids, 3); err == nil {
+		for i := range apps {
+			if items, ok := similar[apps[i].ID]; ok {
+				apps[i].Similar = items
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"total":    total,
 		"page":     page,
 		"pageSize": pageSize,
+		"slaHours": int(sla.Hours()),
 		"items":    apps,
 	})
 }
 
+// 申请列表与详情共用的查询列及关联
+const applicationSelectColumns = `
+		SELECT 
+			a.id, a.email, a.reason, a.status, a.device_id, a.ip, 
+			a.created_at, a.updated_at, a.admin_note, a.review_opinion, 
+			a.processed_by, ad.username as admin_username,
+			a.locked_by, lk.username as locked_by_username, a.locked_until,
+			a.assigned_to, asg.username as assigned_to_username, a.assigned_at,
+			a.risk_score, a.risk_reasons, a.risk_flagged `
+
+const applicationFromClause = `
+		FROM applications a 
+		LEFT JOIN admins ad ON a.processed_by = ad.id 
+		LEFT JOIN admins lk ON a.locked_by = lk.id 
+		LEFT JOIN admins asg ON a.assigned_to = asg.id`
+
+// scanApplication 按 applicationSelectColumns 的列顺序读取一条申请
+func scanApplication(row interface{ Scan(...interface{}) error }) (models.Application, error) {
+	var app models.Application
+	var createdAtVal, updatedAtVal interface{}
+	var adminNote, reviewOpinion, adminUsername, lockedByUsername, assignedToUsername, riskReasons sql.NullString
+	var processedBy, lockedBy, lockedUntil, assignedTo, assignedAt sql.NullInt64
+
+	err := row.Scan(
+		&app.ID, &app.Email, &app.Reason, &app.Status,
+		&app.DeviceID, &app.IP, &createdAtVal, &updatedAtVal, &adminNote, &reviewOpinion,
+		&processedBy, &adminUsername,
+		&lockedBy, &lockedByUsername, &lockedUntil,
+		&assignedTo, &assignedToUsername, &assignedAt,
+		&app.RiskScore, &riskReasons, &app.RiskFlagged,
+	)
+	if err != nil {
+		return app, err
+	}
+
+	app.CreatedAt = time.Unix(database.ToUnixTimestamp(createdAtVal), 0)
+	app.UpdatedAt = time.Unix(database.ToUnixTimestamp(updatedAtVal), 0)
+	if adminNote.Valid {
+		app.AdminNote = adminNote.String
+	}
+	if reviewOpinion.Valid {
+		app.ReviewOpinion = reviewOpinion.String
+	}
+	if processedBy.Valid {
+		id := int(processedBy.Int64)
+		app.ProcessedBy = &id
+	}
+	if adminUsername.Valid {
+		app.AdminUsername = adminUsername.String
+	}
+	if lockedBy.Valid && lockedUntil.Int64 > time.Now().Unix() {
+		id := int(lockedBy.Int64)
+		until := time.Unix(lockedUntil.Int64, 0)
+		app.LockedBy = &id
+		app.LockedByUsername = lockedByUsername.String
+		app.LockedUntil = &until
+	}
+	if assignedTo.Valid {
+		id := int(assignedTo.Int64)
+		at := time.Unix(assignedAt.Int64, 0)
+		app.AssignedTo = &id
+		app.AssignedToUsername = assignedToUsername.String
+		app.AssignedAt = &at
+	}
+	app.RiskReasons = make([]models.RiskHit, 0)
+	app.Similar = make([]models.SimilarApplication, 0)
+	if riskReasons.Valid {
+		_ = json.Unmarshal([]byte(riskReasons.String), &app.RiskReasons)
+	}
+
+	return app, nil
+}
+
 // ReviewApplication 审核申请
 func ReviewApplication(c *gin.Context) {
 	var req struct {
 		AppID  int    `json:"appId" binding:"required"`
 		Status string `json:"status" binding:"required"`
 		Data   struct {
-			Code    string `json:"code"`
-			Note    string `json:"note"`
-			Opinion string `json:"opinion"`
+			Code       string `json:"code"`
+			Note       string `json:"note"`
+			Opinion    string `json:"opinion"`
+			TemplateID int    `json:"templateId"` // 使用审核模板生成审核意见
 		} `json:"data"`
 	}
 
@@ -135,134 +221,401 @@ func ReviewApplication(c *gin.Context) {
 		return
 	}
 
-	// 获取申请信息
-	var email string
-	err := database.DB.QueryRow("SELECT email FROM applications WHERE id = ?", req.AppID).Scan(&email)
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	newStatus, err := applyReview(adminID.(int), adminUsername.(string), req.AppID, reviewInput{
+		Status:     req.Status,
+		Code:       req.Data.Code,
+		Note:       req.Data.Note,
+		Opinion:    req.Data.Opinion,
+		TemplateID: req.Data.TemplateID,
+	})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "申请不存在"})
+		status, body := reviewErrorResponse(req.AppID, err)
+		c.JSON(status, body)
 		return
 	}
 
+	if newStatus == "pending_second_review" {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已提交，等待另一位管理员复核", "status": newStatus})
+		return
+	}
+	if newStatus == "approved" {
+		go services.CheckInvitePoolLevel()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "处理成功"})
+}
+
+// 审核错误
+var (
+	errSecondReviewerRequired = errors.New("second reviewer required")
+	errReviewConflict         = errors.New("review conflict")
+	errApplicationWithdrawn   = errors.New("application withdrawn")
+	errCodeAtFirstApproval    = errors.New("invitation code not allowed at first approval")
+)
+
+// reviewInput 单个申请的审核参数
+type reviewInput struct {
+	Status     string // approved, rejected
+	Code       string // 人工指定的邀请码
+	Note       string
+	Opinion    string
+	TemplateID int // 非零时使用模板渲染审核意见，Opinion 作为补充说明附在其后
+}
+
+// applyReview 审核单个申请：更新状态、分配邀请码、写入审计日志并将通知邮件加入队列，返回申请的新状态
+func applyReview(adminID int, adminUsername string, appID int, in reviewInput) (string, error) {
+	status, code, note, opinion := in.Status, in.Code, in.Note, in.Opinion
+
+	// 获取申请信息
+	var email, currentStatus string
+	var storedOpinion sql.NullString
+	var firstApprovedBy sql.NullInt64
+	err := database.DB.QueryRow(
+		"SELECT email, status, review_opinion, first_approved_by FROM applications WHERE id = ?", appID,
+	).Scan(&email, &currentStatus, &storedOpinion, &firstApprovedBy)
+	if err != nil {
+		return "", services.ErrApplicationNotFound
+	}
+	if currentStatus == "withdrawn" {
+		return "", errApplicationWithdrawn
+	}
+
+	if in.TemplateID != 0 {
+		rendered, err := services.RenderReviewTemplate(in.TemplateID, status, email)
+		if err != nil {
+			return "", err
+		}
+		if opinion != "" {
+			rendered += "\n\n" + opinion
+		}
+		opinion = rendered
+	}
+
+	// 双人审核：开启后首次批准仅进入待复核，由另一位管理员确认后才发放邀请码
+	settings, _ := services.GetSystemSettings()
+	newStatus := status
+	secondApproval := false
+	if status == "approved" {
+		if currentStatus == "pending_second_review" {
+			if firstApprovedBy.Valid && int(firstApprovedBy.Int64) == adminID {
+				return "", errSecondReviewerRequired
+			}
+			secondApproval = true
+			if opinion == "" {
+				opinion = storedOpinion.String
+			}
+		} else if settings["require_dual_approval"] == "true" {
+			// 首审不发放邀请码，人工指定的邀请码只能由复核人填写，避免被静默丢弃
+			if code != "" {
+				return "", errCodeAtFirstApproval
+			}
+			newStatus = "pending_second_review"
+		}
+	}
+
 	// 开始事务
 	tx, err := database.DB.Begin()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "系统错误"})
-		return
+		return "", err
 	}
 	defer tx.Rollback()
 
-	// 获取管理员 ID
-	adminID, _ := c.Get("admin_id")
-
-	// 更新申请状态
-	_, err = tx.Exec(
-		"UPDATE applications SET status = ?, admin_note = ?, review_opinion = ?, processed_by = ?, updated_at = ? WHERE id = ?",
-		req.Status, req.Data.Note, req.Data.Opinion, adminID, time.Now().Unix(), req.AppID,
+	// 比较并更新：状态必须仍是读取时的状态，且为待审核/待复核并未被他人认领；处理后释放认领锁
+	// 已批准或已拒绝的申请不能再次审核，避免重复分配邀请码或拒绝已发放邀请码的申请
+	now := time.Now().Unix()
+	res, err := tx.Exec(`
+		UPDATE applications
+		SET status = ?, admin_note = ?, review_opinion = ?, processed_by = ?, updated_at = ?,
+			first_approved_by = CASE WHEN ? = 'pending_second_review' THEN ? ELSE first_approved_by END,
+			decided_at = CASE WHEN ? IN ('approved', 'rejected') THEN COALESCE(decided_at, ?) ELSE decided_at END,
+			decided_by = CASE WHEN ? IN ('approved', 'rejected') THEN COALESCE(decided_by, ?) ELSE decided_by END,
+			locked_by = NULL, locked_until = NULL
+		WHERE id = ? AND status = ? AND status IN ('pending', 'pending_second_review')
+			AND (locked_by IS NULL OR locked_until <= ? OR locked_by = ?)`,
+		newStatus, note, opinion, adminID, now,
+		newStatus, adminID,
+		newStatus, now,
+		newStatus, adminID,
+		appID, currentStatus, now, adminID,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "更新失败"})
-		return
+		return "", err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", errReviewConflict
 	}
 
-	// 如果批准，保存邀请码
-	if req.Status == "approved" && req.Data.Code != "" {
-		_, err = tx.Exec(
-			"INSERT INTO invitation_codes (code, application_id, created_at) VALUES (?, ?, ?)",
-			req.Data.Code, req.AppID, time.Now().Unix(),
+	if newStatus == "pending_second_review" {
+		if err := tx.Commit(); err != nil {
+			return "", err
+		}
+		if in.TemplateID != 0 {
+			services.RecordTemplateUsage(in.TemplateID, appID, adminID)
+		}
+
+		_, _ = database.DB.Exec(
+			"INSERT INTO audit_logs (admin_id, admin_username, action, application_id, target_email, details) VALUES (?, ?, ?, ?, ?, ?)",
+			adminID, adminUsername, "first_approve", appID, email, reviewAuditDetails(note, opinion),
 		)
+		return newStatus, nil
+	}
+
+	// 如果批准，从库存领取邀请码（或使用人工指定的邀请码）
+	inviteCode := ""
+	if status == "approved" {
+		inviteCode, err = services.AssignInvitationCode(tx, appID, code)
 		if err != nil {
-			// 如果已存在（可能是重复点击），则更新
-			_, err = tx.Exec(
-				"UPDATE invitation_codes SET code = ? WHERE application_id = ?",
-				req.Data.Code, req.AppID,
-			)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "保存邀请码失败"})
-				return
-			}
+			return "", err
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "提交事务失败"})
-		return
+		return "", err
+	}
+	if in.TemplateID != 0 {
+		services.RecordTemplateUsage(in.TemplateID, appID, adminID)
 	}
 
-	// 异步发送邮件，避免阻塞审核响应
-	go func(status, targetEmail, code, opinion string) {
-		emailService, emailErr := services.GetEmailService()
-		if emailErr == nil {
-			if status == "approved" {
-				emailService.SendApprovalEmail(targetEmail, code, opinion)
-			} else {
-				emailService.SendRejectionEmail(targetEmail, opinion)
-			}
-		}
-	}(req.Status, email, req.Data.Code, req.Data.Opinion)
+	// 通知邮件加入发送队列，避免阻塞审核响应
+	if status == "approved" {
+		services.EnqueueApprovalEmail(email, inviteCode, opinion)
+	} else {
+		services.EnqueueRejectionEmail(email, opinion, services.AppealURL(appID, email), services.ReapplyNotice(email))
+	}
 
 	// 记录审计日志
-	adminUsername, _ := c.Get("admin_username")
-	auditDetails := req.Data.Note
-	if req.Data.Opinion != "" {
+	auditDetails := reviewAuditDetails(note, opinion)
+	if secondApproval {
 		if auditDetails != "" {
-			auditDetails += " | 意见: " + req.Data.Opinion
+			auditDetails = "复核通过 | " + auditDetails
 		} else {
-			auditDetails = "意见: " + req.Data.Opinion
+			auditDetails = "复核通过"
 		}
 	}
 	_, _ = database.DB.Exec(
 		"INSERT INTO audit_logs (admin_id, admin_username, action, application_id, target_email, details) VALUES (?, ?, ?, ?, ?, ?)",
-		adminID, adminUsername, req.Status, req.AppID, email, auditDetails,
+		adminID, adminUsername, status, appID, email, auditDetails,
 	)
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "处理成功"})
+	return newStatus, nil
 }
 
-// DeleteApplication 删除申请
-func DeleteApplication(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
+// reviewErrorResponse 将审核错误转换为 HTTP 状态码与响应内容
+func reviewErrorResponse(appID int, err error) (int, gin.H) {
+	switch err {
+	case services.ErrApplicationNotFound:
+		return http.StatusNotFound, gin.H{"success": false, "message": "申请不存在"}
+	case errSecondReviewerRequired:
+		return http.StatusConflict, gin.H{"success": false, "message": "需要由另一位管理员进行复核"}
+	case errReviewConflict:
+		return http.StatusConflict, reviewConflict(appID)
+	case errApplicationWithdrawn:
+		return http.StatusConflict, gin.H{"success": false, "message": "申请人已撤回该申请"}
+	case errCodeAtFirstApproval:
+		return http.StatusBadRequest, gin.H{"success": false, "message": "已开启双人审核，邀请码需由复核人填写"}
+	case services.ErrInvitePoolExhausted:
+		return http.StatusConflict, gin.H{"success": false, "message": "邀请码库存已耗尽，请先导入邀请码或手动填写"}
+	case services.ErrInviteCodeTaken:
+		return http.StatusConflict, gin.H{"success": false, "message": "该邀请码已分配给其他申请"}
+	case services.ErrInviteCodeUnavailable:
+		return http.StatusConflict, gin.H{"success": false, "message": "该邀请码已使用、已作废或已过期"}
+	case services.ErrTemplateNotFound:
+		return http.StatusBadRequest, gin.H{"success": false, "message": "审核模板不存在"}
+	case services.ErrTemplateKindMismatch:
+		return http.StatusBadRequest, gin.H{"success": false, "message": "审核模板类型与审核结果不符"}
+	default:
+		return http.StatusInternalServerError, gin.H{"success": false, "message": "审核失败"}
+	}
+}
+
+// reviewAuditDetails 拼接审核备注与意见作为审计详情
+func reviewAuditDetails(note, opinion string) string {
+	details := note
+	if opinion != "" {
+		if details != "" {
+			details += " | 意见: " + opinion
+		} else {
+			details = "意见: " + opinion
+		}
+	}
+	return details
+}
+
+// reviewConflict 生成审核冲突的响应内容
+func reviewConflict(appID int) gin.H {
+	if lock, held, err := services.GetApplicationLock(appID); err == nil && held {
+		return gin.H{"success": false, "message": "该申请已被 " + lock.Username + " 认领", "lock": lock}
+	}
+	return gin.H{"success": false, "message": "该申请已被处理"}
+}
+
+// SetAvailability 设置本人是否接收自动分配
+func SetAvailability(c *gin.Context) {
+	var req struct {
+		Available *bool `json:"available" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	adminID, _ := c.Get("admin_id")
+	if err := services.SetAdminAvailability(adminID.(int), *req.Available); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "更新失败"})
+		return
+	}
+
+	message := "已暂停接收分配"
+	if *req.Available {
+		message = "已开始接收分配"
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": message})
+}
+
+// ClaimApplication 认领申请，锁定期内其他管理员无法审核
+func ClaimApplication(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的申请ID"})
 		return
 	}
 
-	// 开始事务
-	tx, err := database.DB.Begin()
+	adminID, _ := c.Get("admin_id")
+	lock, err := services.ClaimApplication(id, adminID.(int))
+	if err == services.ErrApplicationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "申请不存在"})
+		return
+	}
+	if err == services.ErrApplicationLocked {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "该申请已被 " + lock.Username + " 认领", "lock": lock})
+		return
+	}
+	if err == services.ErrApplicationDecided {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "该申请已处理，无法认领"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "系统错误"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "认领失败"})
 		return
 	}
-	defer tx.Rollback()
 
-	// 1. 删除关联的邀请码（如果有）
-	_, err = tx.Exec("DELETE FROM invitation_codes WHERE application_id = ?", id)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "认领成功", "lock": lock})
+}
+
+// ReleaseApplication 释放本人对申请的认领
+func ReleaseApplication(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "删除关联邀请码失败"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的申请ID"})
 		return
 	}
 
-	// 2. 删除申请记录
-	res, err := tx.Exec("DELETE FROM applications WHERE id = ?", id)
+	adminID, _ := c.Get("admin_id")
+	released, err := services.ReleaseApplication(id, adminID.(int))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "删除申请失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "释放失败"})
+		return
+	}
+	if !released {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "您没有认领该申请"})
 		return
 	}
 
-	rows, _ := res.RowsAffected()
-	if rows == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "申请不存在"})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "已释放认领"})
+}
+
+// DeleteApplication 删除申请
+func DeleteApplication(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的申请ID"})
 		return
 	}
 
-	if err := tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "提交事务失败"})
+	email, err := removeApplication(id)
+	if err != nil {
+		if err == services.ErrApplicationNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "申请不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "删除申请失败"})
 		return
 	}
 
+	// 记录审计日志
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, application_id, target_email, details) VALUES (?, ?, ?, ?, ?, ?)",
+		adminID, adminUsername, "delete", id, email, "删除申请",
+	)
+
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "删除成功"})
 }
 
+// removeApplication 删除申请及其内部评论与申诉，并解除邀请码关联，返回申请邮箱
+func removeApplication(id int) (string, error) {
+	var email string
+	if err := database.DB.QueryRow("SELECT email FROM applications WHERE id = ?", id).Scan(&email); err != nil {
+		return "", services.ErrApplicationNotFound
+	}
+
+	// 开始事务
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// 1. 解除关联的邀请码：已发出的作废，从未发出的归还库存
+	if err := services.ReleaseApplicationCodes(tx, id); err != nil {
+		return "", err
+	}
+
+	// 2. 删除内部评论
+	if _, err := tx.Exec("DELETE FROM application_comment_mentions WHERE comment_id IN (SELECT id FROM application_comments WHERE application_id = ?)", id); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec("DELETE FROM application_comments WHERE application_id = ?", id); err != nil {
+		return "", err
+	}
+
+	// 3. 删除申诉
+	if _, err := tx.Exec("DELETE FROM appeals WHERE application_id = ?", id); err != nil {
+		return "", err
+	}
+
+	// 4. 删除申请人自助管理令牌与修改记录
+	if _, err := tx.Exec("DELETE FROM application_manage_tokens WHERE application_id = ?", id); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec("DELETE FROM application_edits WHERE application_id = ?", id); err != nil {
+		return "", err
+	}
+
+	// 5. 删除理由相似度索引
+	if _, err := tx.Exec("DELETE FROM reason_lsh_index WHERE application_id = ?", id); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec("DELETE FROM application_similarities WHERE application_id = ? OR similar_id = ?", id, id); err != nil {
+		return "", err
+	}
+
+	// 6. 删除申请记录
+	res, err := tx.Exec("DELETE FROM applications WHERE id = ?", id)
+	if err != nil {
+		return "", err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return "", services.ErrApplicationNotFound
+	}
+
+	return email, tx.Commit()
+}
+
 // GetAuditLogs 获取审计日志
 func GetAuditLogs(c *gin.Context) {
 	rows, err := database.DB.Query(`
@@ -279,8 +632,10 @@ func GetAuditLogs(c *gin.Context) {
 
 	var logs []map[string]interface{}
 	for rows.Next() {
-		var id, adminID, appID int
-		var adminUsername, action, targetEmail, details string
+		var id int
+		var adminID, appID sql.NullInt64
+		var adminUsername, targetEmail, details sql.NullString
+		var action string
 		var createdAtVal interface{}
 
 		err := rows.Scan(&id, &adminID, &adminUsername, &action, &appID, &targetEmail, &details, &createdAtVal)
@@ -290,12 +645,12 @@ func GetAuditLogs(c *gin.Context) {
 
 		logs = append(logs, map[string]interface{}{
 			"id":             id,
-			"admin_id":       adminID,
-			"admin_username": adminUsername,
+			"admin_id":       adminID.Int64,
+			"admin_username": adminUsername.String,
 			"action":         action,
-			"application_id": appID,
-			"target_email":   targetEmail,
-			"details":        details,
+			"application_id": appID.Int64,
+			"target_email":   targetEmail.String,
+			"details":        details.String,
 			"created_at":     time.Unix(database.ToUnixTimestamp(createdAtVal), 0),
 		})
 	}
@@ -325,6 +680,8 @@ func UpdateSettings(c *gin.Context) {
 	// 不允许通过此接口修改密码和用户名
 	delete(settings, "admin_password_hash")
 	delete(settings, "admin_username")
+	// PoW 难度固定值通过专用接口修改并记录审计日志
+	delete(settings, "pow_difficulty_override")
 
 	err := services.UpdateSettings(settings)
 	if err != nil {
@@ -332,6 +689,9 @@ func UpdateSettings(c *gin.Context) {
 		return
 	}
 
+	// 分配设置可能已变更，立即执行一轮分配
+	go services.RunAssignment()
+
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "设置已更新"})
 }
 
@@ -438,7 +798,11 @@ func ToggleAnnouncement(c *gin.Context) {
 
 // GetAdmins 获取所有管理员
 func GetAdmins(c *gin.Context) {
-	rows, err := database.DB.Query("SELECT id, username, role, linuxdo_id, created_at, updated_at FROM admins ORDER BY created_at DESC")
+	rows, err := database.DB.Query(`
+		SELECT id, username, role, linuxdo_id, email, must_change_password, totp_enabled, available,
+			(SELECT COUNT(*) FROM applications ap WHERE ap.assigned_to = admins.id AND ap.status = 'pending'),
+			created_at, updated_at
+		FROM admins ORDER BY created_at DESC`)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
@@ -449,13 +813,14 @@ func GetAdmins(c *gin.Context) {
 	for rows.Next() {
 		var admin models.Admin
 		var createdAtVal, updatedAtVal interface{}
-		var linuxdoID sql.NullString
-		if err := rows.Scan(&admin.ID, &admin.Username, &admin.Role, &linuxdoID, &createdAtVal, &updatedAtVal); err != nil {
+		var linuxdoID, email sql.NullString
+		if err := rows.Scan(&admin.ID, &admin.Username, &admin.Role, &linuxdoID, &email, &admin.MustChangePassword, &admin.TOTPEnabled, &admin.Available, &admin.OpenQueue, &createdAtVal, &updatedAtVal); err != nil {
 			continue
 		}
 		if linuxdoID.Valid {
 			admin.LinuxDoID = linuxdoID.String
 		}
+		admin.Email = email.String
 		admin.CreatedAt = time.Unix(database.ToUnixTimestamp(createdAtVal), 0)
 		admin.UpdatedAt = time.Unix(database.ToUnixTimestamp(updatedAtVal), 0)
 		admins = append(admins, admin)
@@ -470,6 +835,7 @@ func AddAdmin(c *gin.Context) {
 		Username string `json:"username" binding:"required"`
 		Password string `json:"password" binding:"required"`
 		Role     string `json:"role" binding:"required"`
+		Email    string `json:"email"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -477,13 +843,17 @@ func AddAdmin(c *gin.Context) {
 		return
 	}
 
-	if req.Role != "super" && req.Role != "reviewer" {
+	if !services.RoleExists(req.Role) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "角色无效"})
 		return
 	}
+	if !canManageRole(c, req.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "只有超级管理员可以添加超级管理员"})
+		return
+	}
 
 	// 检查是否允许新增审核员
-	if req.Role == "reviewer" {
+	if req.Role != services.RoleSuper {
 		settings, _ := services.GetSystemSettings()
 		if settings["allow_auto_admin_reg"] == "false" {
 			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "系统已关闭新增审核员功能"})
@@ -495,8 +865,8 @@ func AddAdmin(c *gin.Context) {
 	now := time.Now().Unix()
 
 	_, err := database.DB.Exec(
-		"INSERT INTO admins (username, password_hash, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
-		req.Username, passwordHash, req.Role, now, now,
+		"INSERT INTO admins (username, password_hash, role, email, must_change_password, created_at, updated_at) VALUES (?, ?, ?, ?, 1, ?, ?)",
+		req.Username, passwordHash, req.Role, strings.TrimSpace(req.Email), now, now,
 	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "用户名已存在或添加失败"})
@@ -512,7 +882,7 @@ func DeleteAdmin(c *gin.Context) {
 	currentAdminID, _ := c.Get("admin_id")
 
 	// 不能删除自己
-	if id == string(currentAdminID.(int)) {
+	if id == strconv.Itoa(currentAdminID.(int)) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "不能删除自己"})
 		return
 	}
@@ -524,6 +894,10 @@ func DeleteAdmin(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "管理员不存在"})
 		return
 	}
+	if !canManageRole(c, role) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "只有超级管理员可以删除超级管理员"})
+		return
+	}
 
 	if role == "super" {
 		var superCount int
@@ -534,6 +908,19 @@ func DeleteAdmin(c *gin.Context) {
 		}
 	}
 
+	// 先吊销该管理员的全部会话，已签发的令牌立即失效
+	adminID, _ := strconv.Atoi(id)
+	if err := services.RevokeAdminSessions(adminID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "删除失败"})
+		return
+	}
+
+	// 其名下的待审核分配退回分配池
+	if err := services.SetAdminAvailability(adminID, false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "删除失败"})
+		return
+	}
+
 	_, err = database.DB.Exec("DELETE FROM admins WHERE id = ?", id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "删除失败"})
@@ -543,12 +930,36 @@ func DeleteAdmin(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "管理员已删除"})
 }
 
+// canManageRole 拥有人员管理权限的非超级管理员不能授予或操作超级管理员角色，
+// 也不能授予或操作权限超出自己的角色
+func canManageRole(c *gin.Context, role string) bool {
+	currentRole, _ := c.Get("admin_role")
+	if currentRole == services.RoleSuper {
+		return true
+	}
+	if role == services.RoleSuper {
+		return false
+	}
+	perms, err := services.GetRolePermissions(role)
+	if err != nil {
+		return false
+	}
+	for p := range perms {
+		if !middleware.HasPermission(c, p) {
+			return false
+		}
+	}
+	return true
+}
+
 // UpdateAdmin 更新管理员角色或密码
 func UpdateAdmin(c *gin.Context) {
 	id := c.Param("id")
 	var req struct {
-		Password string `json:"password"`
-		Role     string `json:"role"`
+		Password  string  `json:"password"`
+		Role      string  `json:"role"`
+		Email     *string `json:"email"`
+		Available *bool   `json:"available"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -556,24 +967,50 @@ func UpdateAdmin(c *gin.Context) {
 		return
 	}
 
+	var currentRole string
+	if err := database.DB.QueryRow("SELECT role FROM admins WHERE id = ?", id).Scan(&currentRole); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "管理员不存在"})
+		return
+	}
+
 	query := "UPDATE admins SET updated_at = ?"
 	args := []interface{}{time.Now().Unix()}
 
+	if !canManageRole(c, currentRole) || (req.Role != "" && !canManageRole(c, req.Role)) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "只有超级管理员可以修改超级管理员"})
+		return
+	}
+
 	if req.Role != "" {
-		if req.Role != "super" && req.Role != "reviewer" {
+		if !services.RoleExists(req.Role) {
 			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "角色无效"})
 			return
 		}
+		// 不能将最后一个超级管理员降级
+		if currentRole == services.RoleSuper && req.Role != services.RoleSuper {
+			var superCount int
+			database.DB.QueryRow("SELECT COUNT(*) FROM admins WHERE role = 'super'").Scan(&superCount)
+			if superCount <= 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "必须保留至少一个超级管理员"})
+				return
+			}
+		}
 		query += ", role = ?"
 		args = append(args, req.Role)
 	}
 
+	if req.Email != nil {
+		query += ", email = ?"
+		args = append(args, strings.TrimSpace(*req.Email))
+	}
+
 	if req.Password != "" {
 		if len(req.Password) < 6 {
 			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "密码至少需要6个字符"})
 			return
 		}
-		query += ", password_hash = ?"
+		// 由他人重置的密码，要求本人下次登录后修改
+		query += ", password_hash = ?, must_change_password = 1"
 		args = append(args, utils.HashPassword(req.Password))
 	}
 
@@ -586,5 +1023,22 @@ func UpdateAdmin(c *gin.Context) {
 		return
 	}
 
+	if req.Available != nil {
+		adminID, _ := strconv.Atoi(id)
+		if err := services.SetAdminAvailability(adminID, *req.Available); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "更新失败"})
+			return
+		}
+	}
+
+	// 角色变更或密码重置后，该管理员需要重新登录
+	if (req.Role != "" && req.Role != currentRole) || req.Password != "" {
+		adminID, _ := strconv.Atoi(id)
+		if err := services.RevokeAdminSessions(adminID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "吊销登录会话失败"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "管理员信息已更新"})
 }
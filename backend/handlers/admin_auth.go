@@ -1,10 +1,10 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net"
 	"net/http"
 	"net/url"
@@ -190,21 +190,28 @@ func LinuxDoCallback(c *gin.Context) {
 		dbUsername = userInfo.Username
 	}
 
-	// 5. 生成 JWT Token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":       id,
-		"username": dbUsername,
-		"role":     role,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
-	})
+	// 5. 已启用两步验证的账号需要先完成动态码验证
+	var totpEnabled bool
+	database.DB.QueryRow("SELECT totp_enabled FROM admins WHERE id = ?", id).Scan(&totpEnabled)
+	if totpEnabled {
+		challenge, err := generate2FAChallenge(id)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "生成 Token 失败")
+			return
+		}
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.String(http.StatusOK, fmt.Sprintf(`<script>localStorage.setItem('admin_2fa_challenge', '%s'); window.location.href='/admin/login?twofa=1';</script>`, challenge))
+		return
+	}
 
-	tokenString, err := token.SignedString([]byte(config.AppConfig.JWTSecret))
+	// 6. 生成 JWT Token
+	tokenString, err := generateAdminToken(c, id, dbUsername, role)
 	if err != nil {
 		c.String(http.StatusInternalServerError, "生成 Token 失败")
 		return
 	}
 
-	// 6. 成功登录后重定向回前端，并带上 Token
+	// 7. 成功登录后重定向回前端，并带上 Token
 	// 注意：在实际生产中，更好的方式是通过 Cookie 或特定的前端回调页处理
 	c.Header("Content-Type", "text/html; charset=utf-8")
 	c.String(http.StatusOK, fmt.Sprintf(`<script>localStorage.setItem('admin_token', '%s'); window.location.href='/admin/dashboard';</script>`, tokenString))
@@ -247,39 +254,164 @@ func AdminLogin(c *gin.Context) {
 	// 3. 验证用户名和密码
 	var id int
 	var storedPasswordHash, role string
-	err = database.DB.QueryRow("SELECT id, password_hash, role FROM admins WHERE username = ?", username).Scan(&id, &storedPasswordHash, &role)
+	var mustChangePassword bool
+	err = database.DB.QueryRow(
+		"SELECT id, password_hash, role, must_change_password FROM admins WHERE username = ?", username,
+	).Scan(&id, &storedPasswordHash, &role, &mustChangePassword)
 
-	if err != nil || storedPasswordHash != utils.HashPassword(password) {
+	if err != nil {
+		// 用户不存在时同样执行一次完整的哈希校验，避免通过响应时间判断用户名是否存在
+		utils.VerifyPassword(password, dummyPasswordHash)
 		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "用户名或密码错误"})
 		return
 	}
+	ok, needsRehash := utils.VerifyPassword(password, storedPasswordHash)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "用户名或密码错误"})
+		return
+	}
+
+	// 旧版哈希校验通过后透明升级为 argon2id
+	if needsRehash {
+		rehashPassword(id, password)
+	}
+
+	// 已启用两步验证时，返回挑战令牌，由 /admin/login/2fa 完成第二步
+	var totpEnabled bool
+	database.DB.QueryRow("SELECT totp_enabled FROM admins WHERE id = ?", id).Scan(&totpEnabled)
+	if totpEnabled {
+		challenge, err := generate2FAChallenge(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "生成 Token 失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success":        true,
+			"requires2FA":    true,
+			"challengeToken": challenge,
+		})
+		return
+	}
 
 	// 生成 JWT Token
+	tokenString, err := generateAdminToken(c, id, username, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "生成 Token 失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":            true,
+		"token":              tokenString,
+		"mustChangePassword": mustChangePassword,
+	})
+}
+
+// dummyPasswordHash 用户不存在时用于校验的固定 argon2id 哈希，参数与 utils.HashPassword 一致
+const dummyPasswordHash = "$argon2id$v=19$m=65536,t=3,p=2$Ukx1zb7PVQpIYwzNvRQSmw$4motuVPM7IM9zun96H9rHlQL+5l30dreszBuWXUseoE"
+
+// 访问令牌与刷新令牌有效期
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// 刷新令牌 Cookie 名称及路径（仅随刷新与登出请求发送）
+const (
+	refreshCookieName = "admin_refresh"
+	refreshCookiePath = "/api/admin"
+)
+
+// generateAdminToken 登记服务端会话并签发访问令牌，刷新令牌通过 HttpOnly Cookie 下发
+func generateAdminToken(c *gin.Context, id int, username, role string) (string, error) {
+	expiresAt := time.Now().Add(refreshTokenTTL)
+	jti, tokenVersion, err := services.CreateAdminSession(id, c.ClientIP(), c.Request.UserAgent(), expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	refreshToken, err := services.IssueRefreshToken(jti, id, expiresAt)
+	if err != nil {
+		return "", err
+	}
+	setRefreshCookie(c, refreshToken, int(refreshTokenTTL.Seconds()))
+
+	return signAccessToken(id, username, role, jti, tokenVersion)
+}
+
+// signAccessToken 签发短期访问令牌
+func signAccessToken(id int, username, role, jti string, tokenVersion int) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"id":       id,
 		"username": username,
 		"role":     role,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
+		"jti":      jti,
+		"ver":      tokenVersion,
+		"exp":      time.Now().Add(accessTokenTTL).Unix(),
 	})
 
-	tokenString, err := token.SignedString([]byte(config.AppConfig.JWTSecret))
+	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+}
+
+// setRefreshCookie 写入刷新令牌 Cookie，maxAge 为负数时删除
+func setRefreshCookie(c *gin.Context, token string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(refreshCookieName, token, maxAge, refreshCookiePath, "", secure, true)
+}
+
+// RefreshAdminToken 使用刷新令牌换取新的访问令牌，并轮换刷新令牌
+func RefreshAdminToken(c *gin.Context) {
+	refreshToken, err := c.Cookie(refreshCookieName)
+	if err != nil || refreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "登录已失效，请重新登录"})
+		return
+	}
+
+	adminID, jti, tokenVersion, newRefreshToken, err := services.RotateRefreshToken(refreshToken)
+	if err == services.ErrRefreshTokenReused {
+		log.Printf("Refresh token reuse detected for admin %d, session %s revoked", adminID, jti)
+	}
+	if err != nil {
+		setRefreshCookie(c, "", -1)
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "登录已失效，请重新登录"})
+		return
+	}
+
+	var username, role string
+	if err := database.DB.QueryRow("SELECT username, role FROM admins WHERE id = ?", adminID).Scan(&username, &role); err != nil {
+		setRefreshCookie(c, "", -1)
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "登录已失效，请重新登录"})
+		return
+	}
+
+	tokenString, err := signAccessToken(adminID, username, role, jti, tokenVersion)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "生成 Token 失败"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"token":   tokenString,
-	})
+	setRefreshCookie(c, newRefreshToken, int(refreshTokenTTL.Seconds()))
+	c.JSON(http.StatusOK, gin.H{"success": true, "token": tokenString})
 }
 
-// AdminLogout 管理员登出
+// AdminLogout 管理员登出，吊销当前会话（访问令牌已过期时依据刷新令牌定位会话）
 func AdminLogout(c *gin.Context) {
-	sessionID, _ := c.Cookie("admin_session")
-	delete(middleware.SessionStore, sessionID)
+	jti := ""
+	if _, tokenJTI, ok := middleware.ParseAdminToken(c.GetHeader("Authorization")); ok {
+		jti = tokenJTI
+	} else if refreshToken, err := c.Cookie(refreshCookieName); err == nil {
+		jti, _ = services.RefreshTokenSession(refreshToken)
+	}
 
-	c.SetCookie("admin_session", "", -1, "/", "", false, true)
+	if jti != "" {
+		if err := services.RevokeSession(jti); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "登出失败"})
+			return
+		}
+	}
+
+	setRefreshCookie(c, "", -1)
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
@@ -287,12 +419,28 @@ func AdminLogout(c *gin.Context) {
 func GetMe(c *gin.Context) {
 	username, _ := c.Get("admin_username")
 	role, _ := c.Get("admin_role")
+	mustChangePassword, _ := c.Get("must_change_password")
+
+	adminID, _ := c.Get("admin_id")
+	var available bool
+	database.DB.QueryRow("SELECT available FROM admins WHERE id = ?", adminID).Scan(&available)
+
+	permissions := make([]string, 0)
+	for _, p := range services.AllPermissions {
+		if middleware.HasPermission(c, p.Name) {
+			permissions = append(permissions, p.Name)
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"username": username,
-			"role":     role,
+			"username":           username,
+			"role":               role,
+			"permissions":        permissions,
+			"mustChangePassword": mustChangePassword,
+			"available":          available,
+			"openQueue":          services.OpenQueueSize(adminID.(int)),
 		},
 	})
 }
@@ -315,20 +463,30 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
+	if mustChange, _ := c.Get("must_change_password"); mustChange == true && req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "请先设置新密码"})
+		return
+	}
+
 	adminID, _ := c.Get("admin_id")
-	var currentStoredHash string
+	var currentStoredHash sql.NullString
 	err := database.DB.QueryRow("SELECT password_hash FROM admins WHERE id = ?", adminID).Scan(&currentStoredHash)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "数据库错误"})
 		return
 	}
 
-	currentHash := utils.HashPassword(req.CurrentPassword)
-	if currentHash != currentStoredHash {
+	ok, needsRehash := utils.VerifyPassword(req.CurrentPassword, currentStoredHash.String)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "当前密码错误"})
 		return
 	}
 
+	// 仅修改用户名时，也顺带把旧版哈希升级为 argon2id
+	if needsRehash && req.NewPassword == "" {
+		rehashPassword(adminID.(int), req.CurrentPassword)
+	}
+
 	if req.NewUsername != "" {
 		_, err = database.DB.Exec("UPDATE admins SET username = ?, updated_at = ? WHERE id = ?", req.NewUsername, time.Now().Unix(), adminID)
 		if err != nil {
@@ -342,12 +500,21 @@ func ChangePassword(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "新密码至少需要6个字符"})
 			return
 		}
+		if req.NewPassword == req.CurrentPassword {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "新密码不能与当前密码相同"})
+			return
+		}
 		newHash := utils.HashPassword(req.NewPassword)
-		_, err = database.DB.Exec("UPDATE admins SET password_hash = ?, updated_at = ? WHERE id = ?", newHash, time.Now().Unix(), adminID)
+		_, err = database.DB.Exec("UPDATE admins SET password_hash = ?, must_change_password = 0, updated_at = ? WHERE id = ?", newHash, time.Now().Unix(), adminID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "修改密码失败"})
 			return
 		}
+
+		// 修改密码后所有已登录会话（包括当前会话）全部失效
+		if err := services.RevokeAdminSessions(adminID.(int)); err != nil {
+			log.Printf("Failed to revoke sessions for admin %v: %v", adminID, err)
+		}
 	}
 
 	// 如果修改了密码，通知前端重新登录
@@ -358,8 +525,10 @@ func ChangePassword(c *gin.Context) {
 	}
 }
 
-func generateSessionID() string {
-	b := make([]byte, 32)
-	rand.Read(b)
-	return hex.EncodeToString(b)
+// rehashPassword 使用当前算法重新生成并保存密码哈希
+func rehashPassword(adminID int, password string) {
+	_, err := database.DB.Exec("UPDATE admins SET password_hash = ? WHERE id = ?", utils.HashPassword(password), adminID)
+	if err != nil {
+		log.Printf("Failed to upgrade password hash for admin %d: %v", adminID, err)
+	}
 }
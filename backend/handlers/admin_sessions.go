@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAdminSessions 获取指定管理员当前有效的登录会话
+func GetAdminSessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的管理员ID"})
+		return
+	}
+
+	sessions, err := services.ListAdminSessions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+
+	currentJTI, _ := c.Get("admin_jti")
+	items := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		items = append(items, gin.H{
+			"jti":        s.JTI,
+			"ip":         s.IP,
+			"userAgent":  s.UserAgent,
+			"createdAt":  s.CreatedAt,
+			"lastSeenAt": s.LastSeenAt,
+			"expiresAt":  s.ExpiresAt,
+			"current":    s.JTI == currentJTI,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": items})
+}
+
+// RevokeAdminSessions 强制下线指定管理员的全部会话
+func RevokeAdminSessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的管理员ID"})
+		return
+	}
+
+	var username, role string
+	if err := database.DB.QueryRow("SELECT username, role FROM admins WHERE id = ?", id).Scan(&username, &role); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "管理员不存在"})
+		return
+	}
+	if !canManageRole(c, role) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "只有超级管理员可以下线超级管理员"})
+		return
+	}
+
+	if err := services.RevokeAdminSessions(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "操作失败"})
+		return
+	}
+
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, details) VALUES (?, ?, ?, ?)",
+		adminID, adminUsername, "revoke_sessions", "强制下线管理员 "+username+" 的全部会话",
+	)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "已强制下线全部会话"})
+}
+
+// RevokeAdminSession 强制下线指定管理员的单个会话
+func RevokeAdminSession(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的管理员ID"})
+		return
+	}
+	jti := c.Param("jti")
+
+	var username, role string
+	var revokedAt sql.NullInt64
+	err = database.DB.QueryRow(`
+		SELECT a.username, a.role, s.revoked_at
+		FROM admin_sessions s
+		JOIN admins a ON a.id = s.admin_id
+		WHERE s.jti = ? AND s.admin_id = ?
+	`, jti, id).Scan(&username, &role, &revokedAt)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "会话不存在"})
+		return
+	}
+	if !canManageRole(c, role) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "只有超级管理员可以下线超级管理员"})
+		return
+	}
+	if revokedAt.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "该会话已下线"})
+		return
+	}
+
+	if err := services.RevokeSession(jti); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "操作失败"})
+		return
+	}
+
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, details) VALUES (?, ?, ?, ?)",
+		adminID, adminUsername, "revoke_session", "强制下线管理员 "+username+" 的会话 "+jti,
+	)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "会话已下线"})
+}
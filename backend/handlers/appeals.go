@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAppeal 申请人通过申诉链接查看申请状态与申诉进度
+func GetAppeal(c *gin.Context) {
+	appID, _ := strconv.Atoi(c.Query("id"))
+	status, appeal, err := services.GetApplicantAppeal(appID, c.Query("token"))
+	if err != nil {
+		c.JSON(appealErrorStatus(err), gin.H{"success": false, "message": appealErrorMessage(err, "查询失败")})
+		return
+	}
+
+	data := gin.H{
+		"status":    status,
+		"canAppeal": status == "rejected" && appeal == nil,
+	}
+	if appeal != nil {
+		data["appeal"] = gin.H{
+			"content":         appeal.Content,
+			"status":          appeal.Status,
+			"decisionOpinion": appeal.DecisionOpinion,
+			"createdAt":       appeal.CreatedAt,
+			"decidedAt":       appeal.DecidedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// SubmitAppeal 申请人提交申诉
+func SubmitAppeal(c *gin.Context) {
+	var req struct {
+		ID      int    `json:"id" binding:"required"`
+		Token   string `json:"token" binding:"required"`
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	content := strings.TrimSpace(req.Content)
+	if n := utf8.RuneCountInString(content); n < 10 || n > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "申诉内容需为 10-1000 字"})
+		return
+	}
+
+	email, err := services.SubmitAppeal(req.ID, req.Token, content)
+	if err != nil {
+		c.JSON(appealErrorStatus(err), gin.H{"success": false, "message": appealErrorMessage(err, "提交失败")})
+		return
+	}
+
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (action, application_id, target_email, details) VALUES (?, ?, ?, ?)",
+		"appeal_submit", req.ID, email, "申请人提交申诉",
+	)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "申诉已提交，审核结果将通过邮件通知您"})
+}
+
+// GetAppeals 获取申诉队列，默认只返回待处理的申诉
+func GetAppeals(c *gin.Context) {
+	status := c.DefaultQuery("status", services.AppealPending)
+	if status == "all" {
+		status = ""
+	}
+
+	appeals, err := services.ListAppeals(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": appeals})
+}
+
+// DecideAppeal 处理申诉：overturn 改判通过，uphold 维持拒绝
+func DecideAppeal(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的申诉ID"})
+		return
+	}
+
+	var req struct {
+		Decision string `json:"decision" binding:"required"` // overturn, uphold
+		Opinion  string `json:"opinion"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+	if req.Decision != "overturn" && req.Decision != "uphold" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "处理结果错误"})
+		return
+	}
+
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	opinion := strings.TrimSpace(req.Opinion)
+	appeal, newStatus, err := decideAppeal(adminID.(int), adminUsername.(string), id, req.Decision == "overturn", opinion)
+	if err != nil {
+		if status := appealErrorStatus(err); status != http.StatusInternalServerError {
+			c.JSON(status, gin.H{"success": false, "message": appealErrorMessage(err, "处理失败")})
+			return
+		}
+		// 改判后批准失败，改判已撤销
+		status, body := reviewErrorResponse(appeal.ApplicationID, err)
+		body["message"] = "申诉改判失败：" + body["message"].(string)
+		c.JSON(status, body)
+		return
+	}
+
+	if newStatus == "pending_second_review" {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "申诉已改判，等待另一位管理员复核", "data": appeal, "status": newStatus})
+		return
+	}
+	if newStatus == "approved" {
+		go services.CheckInvitePoolLevel()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "申诉已处理", "data": appeal})
+}
+
+// decideAppeal 处理申诉并写入审计日志，返回改判后申请的新状态（维持拒绝时为空）
+// 改判后的申请按正常审核流程批准：开启双人审核时进入待复核，否则分配邀请码并发送通过邮件；
+// 批准失败时撤销改判，申诉恢复为待处理，申请恢复为拒绝
+func decideAppeal(adminID int, adminUsername string, appealID int, overturn bool, opinion string) (services.Appeal, string, error) {
+	appeal, err := services.DecideAppeal(appealID, adminID, adminUsername, overturn, opinion)
+	if err != nil {
+		return appeal, "", err
+	}
+
+	newStatus := ""
+	if overturn {
+		newStatus, err = applyReview(adminID, adminUsername, appeal.ApplicationID, reviewInput{
+			Status:  "approved",
+			Opinion: services.AppealOverturnOpinion(opinion),
+		})
+		if err != nil {
+			if _, revertErr := services.RevertAppealOverturn(appeal); revertErr != nil {
+				log.Printf("Failed to revert overturned appeal %d: %v", appeal.ID, revertErr)
+			}
+			return appeal, "", err
+		}
+	}
+
+	action, details := "appeal_uphold", "申诉维持拒绝"
+	if overturn {
+		action, details = "appeal_overturn", "申诉改判通过"
+	}
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, application_id, target_email, details) VALUES (?, ?, ?, ?, ?, ?)",
+		adminID, adminUsername, action, appeal.ApplicationID, appeal.Email, reviewAuditDetails(details, opinion),
+	)
+
+	return appeal, newStatus, nil
+}
+
+func appealErrorStatus(err error) int {
+	switch err {
+	case services.ErrAppealInvalidLink, services.ErrAppealNotFound:
+		return http.StatusNotFound
+	case services.ErrAppealNotAllowed, services.ErrAppealExists, services.ErrAppealDecided, services.ErrApplicationLocked:
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func appealErrorMessage(err error, fallback string) string {
+	switch err {
+	case services.ErrAppealInvalidLink:
+		return "申诉链接无效"
+	case services.ErrAppealNotFound:
+		return "申诉不存在"
+	case services.ErrAppealNotAllowed:
+		return "该申请当前不是拒绝状态，无法申诉"
+	case services.ErrAppealExists:
+		return "该申请已提交过申诉"
+	case services.ErrAppealDecided:
+		return "该申诉已处理"
+	case services.ErrApplicationLocked:
+		return "该申请正被其他管理员认领，请稍后再处理申诉"
+	}
+	return fallback
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+
+	"invite-backend/database"
+	"invite-backend/services"
+)
+
+// submitTestAppeal 将申请设为已拒绝并提交申诉，返回申诉 ID
+func submitTestAppeal(t *testing.T, appID int, email string) int {
+	t.Helper()
+	if _, err := database.DB.Exec("UPDATE applications SET status = 'rejected' WHERE id = ?", appID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.SubmitAppeal(appID, services.AppealToken(appID, email), "please reconsider"); err != nil {
+		t.Fatal(err)
+	}
+	var id int
+	database.DB.QueryRow("SELECT id FROM appeals WHERE application_id = ?", appID).Scan(&id)
+	return id
+}
+
+func TestDecideAppealOverturn(t *testing.T) {
+	setupTestDB(t)
+	appID := insertTestApplication(t, "a@example.com")
+	appealID := submitTestAppeal(t, appID, "a@example.com")
+
+	// 库存为空时批准失败，改判撤销：申请恢复为拒绝，申诉恢复为待处理
+	if _, _, err := decideAppeal(1, "admin", appealID, true, ""); err != services.ErrInvitePoolExhausted {
+		t.Fatalf("overturn with empty pool: err = %v, want %v", err, services.ErrInvitePoolExhausted)
+	}
+	var appealStatus string
+	database.DB.QueryRow("SELECT status FROM appeals WHERE id = ?", appealID).Scan(&appealStatus)
+	if status, _ := applicationState(t, appID); status != "rejected" || appealStatus != services.AppealPending {
+		t.Errorf("after failed overturn: application %s, appeal %s", status, appealStatus)
+	}
+
+	// 补充库存后改判通过并发放邀请码
+	importTestCodes(t, "CODE-1")
+	if _, newStatus, err := decideAppeal(1, "admin", appealID, true, ""); err != nil || newStatus != "approved" {
+		t.Fatalf("overturn: %q, %v", newStatus, err)
+	}
+	database.DB.QueryRow("SELECT status FROM appeals WHERE id = ?", appealID).Scan(&appealStatus)
+	if status, code := applicationState(t, appID); status != "approved" || code != "CODE-1" || appealStatus != services.AppealOverturned {
+		t.Errorf("after overturn: application %s, code %q, appeal %s", status, code, appealStatus)
+	}
+}
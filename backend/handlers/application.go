@@ -2,13 +2,15 @@ package handlers
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
 	"invite-backend/database"
+	"invite-backend/risk"
 	"invite-backend/services"
 	"invite-backend/utils"
 
@@ -21,6 +23,8 @@ func SubmitApplication(c *gin.Context) {
 		Encrypted   string `json:"encrypted" binding:"required"`
 		Fingerprint string `json:"fingerprint" binding:"required"`
 		Nonce       int    `json:"nonce" binding:"required"`
+		PowSalt     string `json:"powSalt" binding:"required"`
+		PowNonce    int64  `json:"powNonce"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -28,6 +32,11 @@ func SubmitApplication(c *gin.Context) {
 		return
 	}
 
+	// 0. 校验 PoW 挑战
+	if !checkPowSolution(c, req.Fingerprint, req.PowSalt, req.PowNonce) {
+		return
+	}
+
 	// 1. 解密数据
 	security := &utils.StarMoonSecurity{}
 	data, err := security.DecryptData(req.Encrypted, req.Fingerprint, req.Nonce)
@@ -77,23 +86,30 @@ func SubmitApplication(c *gin.Context) {
 	if err == sql.ErrNoRows || storedCode != code || time.Now().Unix() > expiresAt {
 		fmt.Printf("Verification failed for %s: input=%s, stored=%s, expiresAt=%d, now=%d, err=%v\n",
 			email, code, storedCode, expiresAt, time.Now().Unix(), err)
+		services.RecordSecurityFailure(services.SecurityFailureCode, ip, req.Fingerprint)
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "验证码无效或已过期"})
 		return
 	}
 
+	// 2.5 被拒绝后的重新申请限制
+	if msg, blocked := reapplyBlocked(email); blocked {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": msg})
+		return
+	}
+
 	// 3. 风控检查
 	if settings["risk_control_enabled"] == "true" {
 		// 检查是否有未拒绝的申请
 		var count int
 		database.DB.QueryRow(
-			"SELECT COUNT(*) FROM applications WHERE (email = ? OR device_id = ?) AND status IN ('pending', 'approved')",
+			"SELECT COUNT(*) FROM applications WHERE (email = ? OR device_id = ?) AND status IN ('pending', 'pending_second_review', 'approved')",
 			email, req.Fingerprint,
 		).Scan(&count)
 
 		if count > 0 {
 			var status string
 			database.DB.QueryRow(
-				"SELECT status FROM applications WHERE (email = ? OR device_id = ?) AND status IN ('pending', 'approved') LIMIT 1",
+				"SELECT status FROM applications WHERE (email = ? OR device_id = ?) AND status IN ('pending', 'pending_second_review', 'approved') LIMIT 1",
 				email, req.Fingerprint,
 			).Scan(&status)
 
@@ -104,69 +120,31 @@ func SubmitApplication(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": msg})
 			return
 		}
+	}
 
-		// 邮箱限制（仅统计已通过）
-		maxEmail, _ := strconv.Atoi(settings["max_applications_per_email"])
-		if maxEmail == 0 {
-			maxEmail = 1
-		}
-		var approvedEmailCount int
-		database.DB.QueryRow(
-			"SELECT COUNT(*) FROM applications WHERE email = ? AND status = 'approved'",
-			email,
-		).Scan(&approvedEmailCount)
-		if approvedEmailCount >= maxEmail {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "该邮箱已成功申请过邀请码"})
-			return
-		}
-
-		// 设备限制（仅统计已通过）
-		maxDevice, _ := strconv.Atoi(settings["max_applications_per_device"])
-		if maxDevice == 0 {
-			maxDevice = 1
-		}
-		var approvedDeviceCount int
-		database.DB.QueryRow(
-			"SELECT COUNT(*) FROM applications WHERE device_id = ? AND status = 'approved'",
-			req.Fingerprint,
-		).Scan(&approvedDeviceCount)
-		if approvedDeviceCount >= maxDevice {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "该设备已成功申请过邀请码"})
-			return
-		}
-
-		// IP 提交次数限制（统计所有状态）
-		maxIP, _ := strconv.Atoi(settings["max_applications_per_ip"])
-		if maxIP == 0 {
-			maxIP = 3 // 默认 3 次
-		}
-		var totalIPCount int
-		database.DB.QueryRow(
-			"SELECT COUNT(*) FROM applications WHERE ip = ?",
-			ip,
-		).Scan(&totalIPCount)
-		if totalIPCount >= maxIP {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "该 IP 提交次数过多，请联系管理员"})
-			return
-		}
-
-		// 设备提交总数限制（修复同一设备多次提交的问题，统计所有状态）
-		var totalDeviceCount int
-		database.DB.QueryRow(
-			"SELECT COUNT(*) FROM applications WHERE device_id = ?",
-			req.Fingerprint,
-		).Scan(&totalDeviceCount)
-		// 限制每个设备最多提交 3 次（防止恶意重复提交）
-		if totalDeviceCount >= 3 {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "该设备提交次数过多，请勿重复操作"})
-			return
-		}
+	// 4. 风险评分：超过拒绝阈值时直接拒绝，超过标记阈值时标记人工复核
+	assessment := risk.Evaluate(risk.Submission{
+		Email:    email,
+		Reason:   reason,
+		DeviceID: req.Fingerprint,
+		IP:       ip,
+		Settings: settings,
+	})
+	if assessment.Decision == risk.DecisionReject {
+		_, _ = database.DB.Exec(
+			"INSERT INTO audit_logs (action, target_email, details) VALUES (?, ?, ?)",
+			"risk_reject", email, fmt.Sprintf("风险分 %d，自动拒绝：%s", assessment.Score, assessment.TopReason()),
+		)
+		// 命中原因可能包含其他申请的信息，也会暴露规则细节，只记录在审计日志中
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "您的申请未通过自动审核，请检查申请信息后再试"})
+		return
 	}
+	riskReasons, _ := json.Marshal(assessment.Hits)
 
-	// 4. 插入申请
-	_, err = database.DB.Exec(
-		"INSERT INTO applications (email, reason, device_id, ip, status) VALUES (?, ?, ?, ?, 'pending')",
-		email, reason, req.Fingerprint, ip,
+	// 5. 插入申请
+	res, err := database.DB.Exec(
+		"INSERT INTO applications (email, reason, device_id, ip, status, risk_score, risk_reasons, risk_flagged) VALUES (?, ?, ?, ?, 'pending', ?, ?, ?)",
+		email, reason, req.Fingerprint, ip, assessment.Score, string(riskReasons), assessment.Decision == risk.DecisionFlag,
 	)
 
 	if err != nil {
@@ -174,5 +152,15 @@ func SubmitApplication(c *gin.Context) {
 		return
 	}
 
+	// 写入理由相似度索引
+	if appID, err := res.LastInsertId(); err == nil {
+		if err := services.IndexApplicationReason(int(appID)); err != nil {
+			log.Printf("Failed to index reason of application %d: %v", appID, err)
+		}
+	}
+
+	// 立即尝试分配给审核员
+	go services.RunAssignment()
+
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "申请提交成功，请耐心等待审核"})
 }
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"invite-backend/database"
+	"invite-backend/models"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 详情中各类关联记录的返回上限
+const detailHistoryLimit = 50
+
+// relatedApplication 与当前申请共用邮箱、设备或 IP 的其他申请
+type relatedApplication struct {
+	models.Application
+	MatchedBy []string `json:"matchedBy"` // email, device, ip
+}
+
+// GetApplicationDetail 获取单个申请及其风控相关历史
+func GetApplicationDetail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的申请ID"})
+		return
+	}
+
+	app, err := scanApplication(database.DB.QueryRow(applicationSelectColumns+applicationFromClause+" WHERE a.id = ?", id))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "申请不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+
+	if similar, err := services.GetSimilarApplications([]int{app.ID}, 5); err == nil && len(similar[app.ID]) > 0 {
+		app.Similar = similar[app.ID]
+	}
+
+	related, err := relatedApplications(app)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询关联申请失败"})
+		return
+	}
+
+	// 历史决定：当前申请与关联申请的审核记录，以及同邮箱已删除申请留下的记录
+	appIDs := []interface{}{app.ID}
+	for _, r := range related {
+		appIDs = append(appIDs, r.ID)
+	}
+	decisions, err := queryAuditLogs(
+		"action IN ('approved', 'rejected', 'first_approve') AND (application_id IN ("+placeholders(len(appIDs))+") OR target_email = ?)",
+		append(appIDs, app.Email)...,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询审核记录失败"})
+		return
+	}
+
+	auditTrail, err := queryAuditLogs("application_id = ?", app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询审计日志失败"})
+		return
+	}
+
+	verifications, err := verificationHistory(app.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询验证码记录失败"})
+		return
+	}
+
+	comments, err := services.ListApplicationComments(app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询评论失败"})
+		return
+	}
+
+	edits, err := services.ListApplicationEdits(app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询修改记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"application":   app,
+			"related":       related,
+			"decisions":     decisions,
+			"verifications": verifications,
+			"auditTrail":    auditTrail,
+			"comments":      comments,
+			"edits":         edits,
+		},
+	})
+}
+
+// relatedApplications 查询与申请共用邮箱、设备 ID 或 IP 的其他申请，并标注匹配项
+func relatedApplications(app models.Application) ([]relatedApplication, error) {
+	rows, err := database.DB.Query(
+		applicationSelectColumns+applicationFromClause+`
+		WHERE a.id != ? AND (a.email = ? OR a.device_id = ? OR a.ip = ?)
+		ORDER BY a.created_at DESC
+		LIMIT ?`,
+		app.ID, app.Email, app.DeviceID, app.IP, detailHistoryLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	related := make([]relatedApplication, 0)
+	for rows.Next() {
+		other, err := scanApplication(rows)
+		if err != nil {
+			continue
+		}
+		r := relatedApplication{Application: other, MatchedBy: make([]string, 0, 3)}
+		if other.Email == app.Email {
+			r.MatchedBy = append(r.MatchedBy, "email")
+		}
+		if other.DeviceID == app.DeviceID {
+			r.MatchedBy = append(r.MatchedBy, "device")
+		}
+		if other.IP == app.IP {
+			r.MatchedBy = append(r.MatchedBy, "ip")
+		}
+		related = append(related, r)
+	}
+
+	return related, nil
+}
+
+// queryAuditLogs 按条件查询审计日志（按时间倒序）
+func queryAuditLogs(where string, args ...interface{}) ([]gin.H, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, admin_id, admin_username, action, application_id, target_email, details, created_at
+		FROM audit_logs
+		WHERE `+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`, append(args, detailHistoryLimit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := make([]gin.H, 0)
+	for rows.Next() {
+		var id int
+		var adminID, appID sql.NullInt64
+		var adminUsername, targetEmail, details sql.NullString
+		var action string
+		var createdAtVal interface{}
+		if err := rows.Scan(&id, &adminID, &adminUsername, &action, &appID, &targetEmail, &details, &createdAtVal); err != nil {
+			continue
+		}
+
+		logs = append(logs, gin.H{
+			"id":             id,
+			"admin_id":       adminID.Int64,
+			"admin_username": adminUsername.String,
+			"action":         action,
+			"application_id": appID.Int64,
+			"target_email":   targetEmail.String,
+			"details":        details.String,
+			"created_at":     time.Unix(database.ToUnixTimestamp(createdAtVal), 0),
+		})
+	}
+
+	return logs, nil
+}
+
+// verificationHistory 查询邮箱的验证码发送记录（不含验证码本身）
+func verificationHistory(email string) ([]gin.H, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, ip, created_at, expires_at
+		FROM verification_codes
+		WHERE email = ?
+		ORDER BY id DESC
+		LIMIT ?`, email, detailHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]gin.H, 0)
+	for rows.Next() {
+		var id int
+		var ip sql.NullString
+		var createdAtVal interface{}
+		var expiresAt int64
+		if err := rows.Scan(&id, &ip, &createdAtVal, &expiresAt); err != nil {
+			continue
+		}
+
+		history = append(history, gin.H{
+			"id":        id,
+			"ip":        ip.String,
+			"createdAt": time.Unix(database.ToUnixTimestamp(createdAtVal), 0),
+			"expiresAt": time.Unix(expiresAt, 0),
+		})
+	}
+
+	return history, nil
+}
+
+// placeholders 生成 n 个以逗号分隔的 SQL 占位符
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
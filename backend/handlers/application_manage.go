@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"invite-backend/database"
+	"invite-backend/risk"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestManageLink 申请人请求自助管理链接，链接通过邮件发送以验证邮箱所有权
+func RequestManageLink(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "邮箱格式错误"})
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	_, err := services.SendManageLink(email)
+	if err == services.ErrSiteURLNotConfigured {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "自助管理功能暂未开放，请联系管理员"})
+		return
+	}
+	if err != nil && err != services.ErrManageLinkTooFrequent {
+		log.Printf("Failed to send manage link to %s: %v", email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "发送失败"})
+		return
+	}
+
+	// 无论是否存在可管理的申请、是否触发频率限制都返回相同的提示，避免泄露申请信息
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "如果该邮箱有待审核的申请，管理链接已发送至邮箱"})
+}
+
+// GetManagedApplication 申请人通过管理链接查看申请
+func GetManagedApplication(c *gin.Context) {
+	app, err := services.GetManagedApplication(c.Query("token"))
+	if err != nil {
+		c.JSON(manageErrorStatus(err), gin.H{"success": false, "message": manageErrorMessage(err, "查询失败")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"email":     app.Email,
+			"status":    app.Status,
+			"reason":    app.Reason,
+			"createdAt": app.CreatedAt,
+			"expiresAt": app.ExpiresAt,
+			"canManage": app.CanManage,
+		},
+	})
+}
+
+// WithdrawApplication 申请人撤回待审核的申请
+func WithdrawApplication(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	appID, email, err := services.WithdrawApplication(req.Token)
+	if err != nil {
+		c.JSON(manageErrorStatus(err), gin.H{"success": false, "message": manageErrorMessage(err, "撤回失败")})
+		return
+	}
+
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (action, application_id, target_email, details) VALUES (?, ?, ?, ?)",
+		"withdraw", appID, email, "申请人撤回申请",
+	)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "申请已撤回"})
+}
+
+// EditApplication 申请人修改待审核申请的理由
+func EditApplication(c *gin.Context) {
+	var req struct {
+		Token  string `json:"token" binding:"required"`
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if len([]rune(reason)) < 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "申请理由不能少于 50 个字，请认真填写"})
+		return
+	}
+
+	appID, email, err := services.EditApplicationReason(req.Token, reason, c.ClientIP())
+	if err != nil {
+		c.JSON(manageErrorStatus(err), gin.H{"success": false, "message": manageErrorMessage(err, "修改失败")})
+		return
+	}
+
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (action, application_id, target_email, details) VALUES (?, ?, ?, ?)",
+		"edit_reason", appID, email, "申请人修改申请理由",
+	)
+
+	// 理由已变更，重新评分并重建相似度索引
+	if err := rescoreApplicationReason(appID, email, reason); err != nil {
+		log.Printf("Failed to rescore application %d: %v", appID, err)
+	}
+	if err := services.IndexApplicationReason(appID); err != nil {
+		log.Printf("Failed to index reason of application %d: %v", appID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "申请理由已更新"})
+}
+
+// rescoreApplicationReason 按修改后的理由重新计算风险分与标记
+func rescoreApplicationReason(appID int, email, reason string) error {
+	var deviceID, ip string
+	var previousJSON sql.NullString
+	if err := database.DB.QueryRow(
+		"SELECT device_id, ip, risk_reasons FROM applications WHERE id = ?", appID,
+	).Scan(&deviceID, &ip, &previousJSON); err != nil {
+		return err
+	}
+	var previous []risk.Hit
+	if previousJSON.String != "" {
+		_ = json.Unmarshal([]byte(previousJSON.String), &previous)
+	}
+
+	settings, _ := services.GetSystemSettings()
+	assessment := risk.ReevaluateReason(risk.Submission{
+		Email:    email,
+		Reason:   reason,
+		DeviceID: deviceID,
+		IP:       ip,
+		Settings: settings,
+	}, previous)
+	riskReasons, _ := json.Marshal(assessment.Hits)
+	_, err := database.DB.Exec(
+		"UPDATE applications SET risk_score = ?, risk_reasons = ?, risk_flagged = ? WHERE id = ?",
+		assessment.Score, string(riskReasons), assessment.Decision != risk.DecisionAccept, appID,
+	)
+	return err
+}
+
+func manageErrorStatus(err error) int {
+	switch err {
+	case services.ErrManageLinkInvalid, services.ErrApplicationNotFound:
+		return http.StatusNotFound
+	case services.ErrApplicationNotPending, services.ErrApplicationLocked:
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func manageErrorMessage(err error, fallback string) string {
+	switch err {
+	case services.ErrManageLinkInvalid:
+		return "管理链接无效或已过期，请重新获取"
+	case services.ErrApplicationNotFound:
+		return "申请不存在"
+	case services.ErrApplicationNotPending:
+		return "申请已进入审核流程，无法撤回或修改"
+	case services.ErrApplicationLocked:
+		return "审核员正在处理该申请，暂时无法修改，请稍后再试"
+	}
+	return fallback
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invite-backend/database"
+	"invite-backend/middleware"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 单次批量操作的申请数量上限
+const maxBulkReviewItems = 200
+
+// bulkReviewResult 批量操作中单个申请的处理结果
+type bulkReviewResult struct {
+	AppID   int    `json:"appId"`
+	Success bool   `json:"success"`
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message"`
+}
+
+// BulkReviewApplications 批量批准、拒绝或删除申请，逐条处理并返回每条的结果
+func BulkReviewApplications(c *gin.Context) {
+	var req struct {
+		AppIDs     []int  `json:"appIds" binding:"required"`
+		Action     string `json:"action" binding:"required"` // approve, reject, delete
+		Opinion    string `json:"opinion"`
+		Note       string `json:"note"`
+		TemplateID int    `json:"templateId"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	if len(req.AppIDs) == 0 || len(req.AppIDs) > maxBulkReviewItems {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "每次最多处理 200 条申请"})
+		return
+	}
+
+	var status string
+	switch req.Action {
+	case "approve":
+		status = "approved"
+	case "reject":
+		status = "rejected"
+	case "delete":
+		if !middleware.HasPermission(c, services.PermApplicationsDelete) {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "权限不足"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "操作类型错误"})
+		return
+	}
+
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+
+	results := make([]bulkReviewResult, 0, len(req.AppIDs))
+	seen := make(map[int]bool)
+	succeeded := 0
+	approved := false
+	for _, appID := range req.AppIDs {
+		if seen[appID] {
+			continue
+		}
+		seen[appID] = true
+
+		result := bulkReviewResult{AppID: appID}
+		if req.Action == "delete" {
+			email, err := removeApplication(appID)
+			if err == services.ErrApplicationNotFound {
+				result.Message = "申请不存在"
+			} else if err != nil {
+				result.Message = "删除失败"
+			} else {
+				result.Success = true
+				result.Message = "删除成功"
+				_, _ = database.DB.Exec(
+					"INSERT INTO audit_logs (admin_id, admin_username, action, application_id, target_email, details) VALUES (?, ?, ?, ?, ?, ?)",
+					adminID, adminUsername, "delete", appID, email, "批量删除",
+				)
+			}
+		} else {
+			newStatus, err := applyReview(adminID.(int), adminUsername.(string), appID, reviewInput{
+				Status:     status,
+				Note:       req.Note,
+				Opinion:    req.Opinion,
+				TemplateID: req.TemplateID,
+			})
+			if err != nil {
+				_, body := reviewErrorResponse(appID, err)
+				result.Message = body["message"].(string)
+			} else {
+				result.Success = true
+				result.Status = newStatus
+				result.Message = "处理成功"
+				if newStatus == "approved" {
+					approved = true
+				}
+			}
+		}
+
+		if result.Success {
+			succeeded++
+		}
+		results = append(results, result)
+	}
+
+	if approved {
+		go services.CheckInvitePoolLevel()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"message":   "批量处理完成",
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
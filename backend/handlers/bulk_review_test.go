@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"invite-backend/database"
+	"invite-backend/services"
+)
+
+type bulkReviewResponse struct {
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []bulkReviewResult `json:"results"`
+}
+
+func bulkReview(t *testing.T, adminID int, username, role, body string) (int, bulkReviewResponse) {
+	t.Helper()
+	w := performAsAdmin(t, BulkReviewApplications, adminID, username, role, http.MethodPost, body)
+	var resp bulkReviewResponse
+	if w.Code == http.StatusOK {
+		decodeResponse(t, w, &resp)
+	}
+	return w.Code, resp
+}
+
+func TestBulkReviewPartialFailure(t *testing.T) {
+	setupTestDB(t)
+	importTestCodes(t, "CODE-1")
+	first := insertTestApplication(t, "a@example.com")
+	second := insertTestApplication(t, "b@example.com")
+
+	// 重复的 ID 只处理一次；库存只够第一条，第二条失败，不存在的申请单独报错
+	code, resp := bulkReview(t, 1, "admin", services.RoleSuper,
+		fmt.Sprintf(`{"appIds": [%d, %d, %d, 9999], "action": "approve"}`, first, first, second))
+	if code != http.StatusOK || resp.Succeeded != 1 || resp.Failed != 2 || len(resp.Results) != 3 {
+		t.Fatalf("bulk approve: %d, %+v", code, resp)
+	}
+	if r := resp.Results[0]; !r.Success || r.AppID != first || r.Status != "approved" {
+		t.Errorf("first result: %+v", r)
+	}
+	if r := resp.Results[1]; r.Success || r.AppID != second || r.Message != "邀请码库存已耗尽，请先导入邀请码或手动填写" {
+		t.Errorf("second result: %+v", r)
+	}
+	if r := resp.Results[2]; r.Success || r.Message != "申请不存在" {
+		t.Errorf("missing result: %+v", r)
+	}
+
+	// 失败的申请不受影响，已成功的保持批准
+	if status, code := applicationState(t, first); status != "approved" || code != "CODE-1" {
+		t.Errorf("first application: %s, %q", status, code)
+	}
+	if status, _ := applicationState(t, second); status != "pending" {
+		t.Errorf("second application: %s, want pending", status)
+	}
+}
+
+func TestBulkReviewConflicts(t *testing.T) {
+	setupTestDB(t)
+	other := insertTestAdmin(t, "reviewer2")
+	locked := insertTestApplication(t, "a@example.com")
+	decided := insertTestApplication(t, "b@example.com")
+	free := insertTestApplication(t, "c@example.com")
+	if _, err := database.DB.Exec("UPDATE applications SET locked_by = ?, locked_until = ? WHERE id = ?", other, time.Now().Add(time.Hour).Unix(), locked); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.DB.Exec("UPDATE applications SET status = 'approved' WHERE id = ?", decided); err != nil {
+		t.Fatal(err)
+	}
+
+	// 他人认领中与已处理的申请逐条报冲突，其余照常处理
+	code, resp := bulkReview(t, 1, "admin", services.RoleSuper,
+		fmt.Sprintf(`{"appIds": [%d, %d, %d], "action": "reject"}`, locked, decided, free))
+	if code != http.StatusOK || resp.Succeeded != 1 || resp.Failed != 2 {
+		t.Fatalf("bulk reject: %d, %+v", code, resp)
+	}
+	for _, r := range resp.Results[:2] {
+		if r.Success || r.Message == "" {
+			t.Errorf("conflicting result: %+v", r)
+		}
+	}
+	if status, _ := applicationState(t, locked); status != "pending" {
+		t.Errorf("locked application: %s, want pending", status)
+	}
+	if status, _ := applicationState(t, decided); status != "approved" {
+		t.Errorf("decided application: %s, want approved", status)
+	}
+	if status, _ := applicationState(t, free); status != "rejected" {
+		t.Errorf("free application: %s, want rejected", status)
+	}
+}
+
+func TestBulkDelete(t *testing.T) {
+	setupTestDB(t)
+	reviewer := insertTestAdmin(t, "reviewer2")
+	importTestCodes(t, "CODE-1")
+	approved := insertTestApplication(t, "a@example.com")
+	if _, err := applyReview(1, "admin", approved, reviewInput{Status: "approved"}); err != nil {
+		t.Fatal(err)
+	}
+	pending := insertTestApplication(t, "b@example.com")
+	body := fmt.Sprintf(`{"appIds": [%d, %d, 9999], "action": "delete"}`, approved, pending)
+
+	// 没有删除权限的审核员不能批量删除
+	if code, _ := bulkReview(t, reviewer, "reviewer2", "reviewer", body); code != http.StatusForbidden {
+		t.Fatalf("bulk delete by reviewer: %d, want %d", code, http.StatusForbidden)
+	}
+
+	code, resp := bulkReview(t, 1, "admin", services.RoleSuper, body)
+	if code != http.StatusOK || resp.Succeeded != 2 || resp.Failed != 1 {
+		t.Fatalf("bulk delete: %d, %+v", code, resp)
+	}
+	var remaining, audits int
+	var codeStatus string
+	database.DB.QueryRow("SELECT COUNT(*) FROM applications").Scan(&remaining)
+	database.DB.QueryRow("SELECT COUNT(*) FROM audit_logs WHERE action = 'delete'").Scan(&audits)
+	database.DB.QueryRow("SELECT status FROM invitation_codes WHERE code = 'CODE-1'").Scan(&codeStatus)
+	if remaining != 0 || audits != 2 || codeStatus != services.InviteCodeRevoked {
+		t.Errorf("after bulk delete: %d applications, %d audit entries, code %s", remaining, audits, codeStatus)
+	}
+}
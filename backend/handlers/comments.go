@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 单条评论最大长度
+const maxCommentLength = 2000
+
+// GetApplicationComments 获取申请的内部评论
+func GetApplicationComments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的申请ID"})
+		return
+	}
+
+	comments, err := services.ListApplicationComments(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": comments})
+}
+
+// AddApplicationComment 添加内部评论，支持 @用户名 提及其他管理员
+func AddApplicationComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的申请ID"})
+		return
+	}
+
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" || utf8.RuneCountInString(content) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "评论内容不能为空且不超过 2000 字"})
+		return
+	}
+
+	var exists int
+	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE id = ?", id).Scan(&exists)
+	if exists == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "申请不存在"})
+		return
+	}
+
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	comment, err := services.AddApplicationComment(id, adminID.(int), adminUsername.(string), content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "评论失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "评论已添加", "data": comment})
+}
+
+// DeleteApplicationComment 删除本人的内部评论
+func DeleteApplicationComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的申请ID"})
+		return
+	}
+	commentID, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的评论ID"})
+		return
+	}
+
+	adminID, _ := c.Get("admin_id")
+	err = services.DeleteApplicationComment(id, commentID, adminID.(int))
+	if err == services.ErrCommentNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "评论不存在"})
+		return
+	}
+	if err == services.ErrCommentNotAuthor {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "只能删除自己发布的评论"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "评论已删除"})
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeleteApplicationCommentHandler(t *testing.T) {
+	setupTestDB(t)
+	reviewer := insertTestAdmin(t, "reviewer2")
+	appID := insertTestApplication(t, "a@example.com")
+	comment, err := services.AddApplicationComment(appID, 1, "admin", "internal note")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := gin.Params{{Key: "id", Value: strconv.Itoa(appID)}, {Key: "commentId", Value: strconv.Itoa(comment.ID)}}
+
+	if w := performAsAdmin(t, DeleteApplicationComment, reviewer, "reviewer2", "reviewer", http.MethodDelete, "", params...); w.Code != http.StatusForbidden {
+		t.Errorf("delete by non-author: status %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := performAsAdmin(t, DeleteApplicationComment, 1, "admin", services.RoleSuper, http.MethodDelete, "", params...); w.Code != http.StatusOK {
+		t.Errorf("delete by author: status %d (%s)", w.Code, w.Body.String())
+	}
+	if w := performAsAdmin(t, DeleteApplicationComment, 1, "admin", services.RoleSuper, http.MethodDelete, "", params...); w.Code != http.StatusNotFound {
+		t.Errorf("delete twice: status %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetEmailBlocklist 获取一次性邮箱域名黑名单及拒绝次数统计
+func GetEmailBlocklist(c *gin.Context) {
+	items, err := services.ListBlockedDomains(c.Query("search"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"items":      items,
+			"rejections": services.GetEmailRejectionStats(),
+		},
+	})
+}
+
+// AddEmailBlocklistDomain 添加黑名单域名
+func AddEmailBlocklistDomain(c *gin.Context) {
+	var req struct {
+		Domain string `json:"domain" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	domain, err := services.NormalizeBlockedDomain(req.Domain)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "域名格式错误，示例：example.com 或 *.example.com"})
+		return
+	}
+
+	adminID := c.GetInt("admin_id")
+	if err := services.AddBlockedDomain(domain, adminID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "添加失败"})
+		return
+	}
+
+	logBlocklistChange(c, "blocklist_add", "添加邮箱域名黑名单 "+domain)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "已添加 " + domain})
+}
+
+// RemoveEmailBlocklistDomain 移除黑名单域名
+func RemoveEmailBlocklistDomain(c *gin.Context) {
+	domain, err := services.NormalizeBlockedDomain(c.Param("domain"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "域名格式错误"})
+		return
+	}
+
+	if err := services.RemoveBlockedDomain(domain); err != nil {
+		if err == services.ErrBlockedDomainNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "黑名单中没有该域名"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "移除失败"})
+		return
+	}
+
+	logBlocklistChange(c, "blocklist_remove", "移除邮箱域名黑名单 "+domain)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "已移除 " + domain})
+}
+
+// logBlocklistChange 记录黑名单变更审计日志
+func logBlocklistChange(c *gin.Context, action, details string) {
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, details) VALUES (?, ?, ?, ?)",
+		adminID, adminUsername, action, details,
+	)
+}
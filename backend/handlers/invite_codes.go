@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 单次导入文件大小上限
+const maxInviteImportSize = 2 << 20
+
+// ImportInviteCodes 批量导入邀请码到库存（支持粘贴文本、CSV 或文本文件上传）
+func ImportInviteCodes(c *gin.Context) {
+	var content string
+	var expiresAt int64
+	isCSV := false
+
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		expiresAt, _ = strconv.ParseInt(c.PostForm("expiresAt"), 10, 64)
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			// 表单中未携带文件时，兼容以表单字段提交的粘贴文本
+			content = c.PostForm("codes")
+		} else {
+			if fileHeader.Size > maxInviteImportSize {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "文件过大，最多 2MB"})
+				return
+			}
+			f, err := fileHeader.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "读取文件失败"})
+				return
+			}
+			defer f.Close()
+
+			data, err := io.ReadAll(io.LimitReader(f, maxInviteImportSize))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "读取文件失败"})
+				return
+			}
+			content = string(data)
+			isCSV = strings.EqualFold(filepath.Ext(fileHeader.Filename), ".csv")
+		}
+	} else {
+		var req struct {
+			Codes     string `json:"codes" binding:"required"`
+			CSV       bool   `json:"csv"`
+			ExpiresAt int64  `json:"expiresAt"` // 过期时间（Unix 秒），0 表示永不过期
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+			return
+		}
+		content = req.Codes
+		isCSV = req.CSV
+		expiresAt = req.ExpiresAt
+	}
+
+	if expiresAt != 0 && expiresAt <= time.Now().Unix() {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "过期时间必须晚于当前时间"})
+		return
+	}
+
+	// 去除 UTF-8 BOM（Excel 导出的 CSV 常带）
+	content = strings.TrimPrefix(content, "\ufeff")
+	codes := services.ParseInviteCodes(content, isCSV)
+	if len(codes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "未解析到有效的邀请码"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	imported, duplicates, err := services.ImportInviteCodes(tx, codes, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "导入失败"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "提交事务失败"})
+		return
+	}
+
+	// 记录审计日志
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, details) VALUES (?, ?, ?, ?)",
+		adminID, adminUsername, "import_codes", fmt.Sprintf("导入 %d 个，重复 %d 个", imported, duplicates),
+	)
+
+	// 导入后重新检查库存水位，恢复到阈值以上时重置告警
+	go services.CheckInvitePoolLevel()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"message":    fmt.Sprintf("成功导入 %d 个邀请码，跳过重复 %d 个", imported, duplicates),
+		"imported":   imported,
+		"duplicates": duplicates,
+	})
+}
+
+// GetInviteCodes 获取邀请码库存列表
+func GetInviteCodes(c *gin.Context) {
+	status := c.Query("status")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	baseQuery := `
+		FROM invitation_codes ic
+		LEFT JOIN applications a ON ic.application_id = a.id
+		WHERE 1=1`
+	var args []interface{}
+
+	now := time.Now().Unix()
+	switch status {
+	case services.InviteCodeAvailable, services.InviteCodeAssigned:
+		baseQuery += " AND ic.status = ? AND (ic.expires_at IS NULL OR ic.expires_at > ?)"
+		args = append(args, status, now)
+	case services.InviteCodeRedeemed, services.InviteCodeRevoked:
+		baseQuery += " AND ic.status = ?"
+		args = append(args, status)
+	case services.InviteCodeExpired:
+		baseQuery += " AND ic.status IN ('available', 'assigned') AND ic.expires_at IS NOT NULL AND ic.expires_at <= ?"
+		args = append(args, now)
+	}
+
+	if search := c.Query("search"); search != "" {
+		baseQuery += " AND (ic.code LIKE ? OR a.email LIKE ?)"
+		args = append(args, "%"+search+"%", "%"+search+"%")
+	}
+
+	var total int
+	if err := database.DB.QueryRow("SELECT COUNT(*) "+baseQuery, args...).Scan(&total); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询总数失败"})
+		return
+	}
+
+	query := `
+		SELECT ic.id, ic.code, ic.status, ic.application_id, a.email,
+			ic.assigned_at, ic.redeemed_at, ic.revoked_at, ic.expires_at, ic.created_at ` + baseQuery + `
+		ORDER BY ic.created_at DESC, ic.id DESC
+		LIMIT ? OFFSET ?`
+
+	dataArgs := append(args, pageSize, (page-1)*pageSize)
+	rows, err := database.DB.Query(query, dataArgs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	items := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		var id int
+		var code, codeStatus string
+		var appID, assignedAt, redeemedAt, revokedAt, expiresAt sql.NullInt64
+		var email sql.NullString
+		var createdAtVal interface{}
+		if err := rows.Scan(&id, &code, &codeStatus, &appID, &email, &assignedAt, &redeemedAt, &revokedAt, &expiresAt, &createdAtVal); err != nil {
+			continue
+		}
+
+		item := map[string]interface{}{
+			"id":            id,
+			"code":          code,
+			"status":        services.EffectiveInviteCodeStatus(codeStatus, expiresAt.Int64),
+			"applicationId": nil,
+			"email":         email.String,
+			"assignedAt":    nullableTime(assignedAt),
+			"redeemedAt":    nullableTime(redeemedAt),
+			"revokedAt":     nullableTime(revokedAt),
+			"expiresAt":     nullableTime(expiresAt),
+			"createdAt":     time.Unix(database.ToUnixTimestamp(createdAtVal), 0),
+		}
+		if appID.Valid {
+			item["applicationId"] = appID.Int64
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+		"items":    items,
+	})
+}
+
+// GetInviteCodeStats 获取邀请码库存统计（总数、未使用、已使用、已过期及每日消耗速度）
+func GetInviteCodeStats(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if days < 1 || days > 90 {
+		days = 7
+	}
+
+	stats, err := services.GetInvitePoolStats(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// RevokeInviteCode 作废邀请码（例如邀请码泄露或被转卖）
+func RevokeInviteCode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的邀请码ID"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	appID, code, err := services.RevokeInviteCode(id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "邀请码不存在"})
+		return
+	}
+	if err == services.ErrInviteCodeUnavailable {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "该邀请码已作废"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "作废失败"})
+		return
+	}
+
+	// 记录审计日志
+	var targetEmail sql.NullString
+	if appID.Valid {
+		database.DB.QueryRow("SELECT email FROM applications WHERE id = ?", appID.Int64).Scan(&targetEmail)
+	}
+	details := "作废邀请码 " + code
+	if req.Reason != "" {
+		details += " | 原因: " + req.Reason
+	}
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, application_id, target_email, details) VALUES (?, ?, ?, ?, ?, ?)",
+		adminID, adminUsername, "revoke_code", appID, targetEmail, details,
+	)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "邀请码已作废"})
+}
+
+// RedeemInviteCodes 批量将已分配的邀请码标记为已使用
+func RedeemInviteCodes(c *gin.Context) {
+	var req struct {
+		Codes []string `json:"codes" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Codes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	codes := services.ParseInviteCodes(strings.Join(req.Codes, "\n"), false)
+	redeemed, skipped, err := services.MarkInviteCodesRedeemed(codes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "标记失败"})
+		return
+	}
+
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, details) VALUES (?, ?, ?, ?)",
+		adminID, adminUsername, "redeem_codes", fmt.Sprintf("标记已使用 %d 个，跳过 %d 个", len(redeemed), len(skipped)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  fmt.Sprintf("已标记 %d 个邀请码为已使用", len(redeemed)),
+		"redeemed": redeemed,
+		"skipped":  skipped,
+	})
+}
+
+// nullableTime 将可空的 Unix 时间戳转换为 JSON 时间
+func nullableTime(v sql.NullInt64) interface{} {
+	if !v.Valid || v.Int64 == 0 {
+		return nil
+	}
+	return time.Unix(v.Int64, 0)
+}
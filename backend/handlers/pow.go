@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPowStatus 获取 PoW 难度状态与最近 24 小时的难度曲线
+func GetPowStatus(c *gin.Context) {
+	status, err := services.GetPowStatus(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": status})
+}
+
+// UpdatePowOverride 固定 PoW 难度，difficulty 为 null 时恢复自动调整
+func UpdatePowOverride(c *gin.Context) {
+	var req struct {
+		Difficulty *int `json:"difficulty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+	if req.Difficulty != nil && (*req.Difficulty < 0 || *req.Difficulty > services.MaxPowDifficulty) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("难度需在 0 到 %d 之间", services.MaxPowDifficulty)})
+		return
+	}
+
+	if err := services.SetPowDifficultyOverride(req.Difficulty); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "更新失败"})
+		return
+	}
+
+	details := "恢复自动调整 PoW 难度"
+	if req.Difficulty != nil {
+		details = fmt.Sprintf("固定 PoW 难度为 %d", *req.Difficulty)
+	}
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, details) VALUES (?, ?, ?, ?)",
+		adminID, adminUsername, "pow_override", details,
+	)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": details})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReviewStats 审核时效统计：超时未处理的申请，以及整体和每位审核员从提交到决定的耗时分布
+func GetReviewStats(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if days < 0 || days > 365 {
+		days = 30
+	}
+
+	overdue, overdueTotal, err := services.ListOverdueApplications(20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+
+	overall, reviewers, err := services.GetDecisionTimeStats(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"slaHours":     int(services.ReviewSLA().Hours()),
+			"days":         days,
+			"overdueTotal": overdueTotal,
+			"overdue":      overdue,
+			"overall":      overall,
+			"reviewers":    reviewers,
+		},
+	})
+}
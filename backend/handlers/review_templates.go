@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reviewTemplateRequest 新建或修改审核模板的参数
+type reviewTemplateRequest struct {
+	Title string `json:"title" binding:"required"`
+	Body  string `json:"body" binding:"required"`
+	Kind  string `json:"kind" binding:"required"` // approval, rejection
+}
+
+// validate 校验并规范化模板参数，返回错误提示
+func (r *reviewTemplateRequest) validate() string {
+	r.Title = strings.TrimSpace(r.Title)
+	r.Body = strings.TrimSpace(r.Body)
+	if r.Title == "" || utf8.RuneCountInString(r.Title) > 50 {
+		return "模板标题不能为空且不超过 50 字"
+	}
+	if r.Body == "" || utf8.RuneCountInString(r.Body) > 2000 {
+		return "模板内容不能为空且不超过 2000 字"
+	}
+	if !services.IsValidTemplateKind(r.Kind) {
+		return "模板类型错误"
+	}
+	return ""
+}
+
+// GetReviewTemplates 获取审核模板，可按 kind 筛选
+func GetReviewTemplates(c *gin.Context) {
+	templates, err := services.ListReviewTemplates(c.Query("kind"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": templates})
+}
+
+// AddReviewTemplate 新建审核模板
+func AddReviewTemplate(c *gin.Context) {
+	var req reviewTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": msg})
+		return
+	}
+
+	adminUsername, _ := c.Get("admin_username")
+	id, err := services.CreateReviewTemplate(req.Title, req.Body, req.Kind, adminUsername.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "添加失败"})
+		return
+	}
+
+	logTemplateChange(c, "add_template", "新建审核模板 "+req.Title)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "模板已添加", "id": id})
+}
+
+// UpdateReviewTemplate 修改审核模板
+func UpdateReviewTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的模板ID"})
+		return
+	}
+
+	var req reviewTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": msg})
+		return
+	}
+
+	if err := services.UpdateReviewTemplate(id, req.Title, req.Body, req.Kind); err != nil {
+		if err == services.ErrTemplateNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "模板不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "更新失败"})
+		return
+	}
+
+	logTemplateChange(c, "update_template", "修改审核模板 "+req.Title)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "模板已更新"})
+}
+
+// DeleteReviewTemplate 删除审核模板
+func DeleteReviewTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的模板ID"})
+		return
+	}
+
+	if err := services.DeleteReviewTemplate(id); err != nil {
+		if err == services.ErrTemplateNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "模板不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "删除失败"})
+		return
+	}
+
+	logTemplateChange(c, "delete_template", "删除审核模板 #"+strconv.Itoa(id))
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "模板已删除"})
+}
+
+// GetReviewTemplateStats 获取模板使用统计，days 为近期统计天数（默认 30）
+func GetReviewTemplateStats(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if days < 1 || days > 365 {
+		days = 30
+	}
+
+	stats, err := services.GetReviewTemplateStats(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "days": days, "data": stats})
+}
+
+// logTemplateChange 记录模板变更审计日志
+func logTemplateChange(c *gin.Context, action, details string) {
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, details) VALUES (?, ?, ?, ?)",
+		adminID, adminUsername, action, details,
+	)
+}
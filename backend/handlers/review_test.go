@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"invite-backend/database"
+	"invite-backend/services"
+)
+
+func TestApplyReviewPoolExhausted(t *testing.T) {
+	setupTestDB(t)
+	appID := insertTestApplication(t, "a@example.com")
+
+	if _, err := applyReview(1, "admin", appID, reviewInput{Status: "approved"}); err != services.ErrInvitePoolExhausted {
+		t.Fatalf("approve with empty pool: err = %v, want %v", err, services.ErrInvitePoolExhausted)
+	}
+	// 领取失败时整个审核回滚，申请留在待审核队列，也不发送邮件
+	var decidedAt *int64
+	var queued int
+	database.DB.QueryRow("SELECT decided_at FROM applications WHERE id = ?", appID).Scan(&decidedAt)
+	database.DB.QueryRow("SELECT COUNT(*) FROM email_queue").Scan(&queued)
+	if status, _ := applicationState(t, appID); status != "pending" || decidedAt != nil || queued != 0 {
+		t.Errorf("after failed approval: status %s, decided_at %v, %d queued emails", status, decidedAt, queued)
+	}
+
+	importTestCodes(t, "CODE-1")
+	if status, err := applyReview(1, "admin", appID, reviewInput{Status: "approved"}); err != nil || status != "approved" {
+		t.Fatalf("approve after import: %q, %v", status, err)
+	}
+	if status, code := applicationState(t, appID); status != "approved" || code != "CODE-1" {
+		t.Errorf("after approval: status %s, code %q", status, code)
+	}
+
+	// 最后一个邀请码已发放，下一条申请再次耗尽
+	next := insertTestApplication(t, "b@example.com")
+	if _, err := applyReview(1, "admin", next, reviewInput{Status: "approved"}); err != services.ErrInvitePoolExhausted {
+		t.Errorf("second approval: err = %v, want %v", err, services.ErrInvitePoolExhausted)
+	}
+	// 拒绝不需要邀请码
+	if status, err := applyReview(1, "admin", next, reviewInput{Status: "rejected"}); err != nil || status != "rejected" {
+		t.Errorf("reject with empty pool: %q, %v", status, err)
+	}
+}
+
+func TestApplyReviewClaimLock(t *testing.T) {
+	setupTestDB(t)
+	other := insertTestAdmin(t, "reviewer2")
+	importTestCodes(t, "CODE-1", "CODE-2")
+	appID := insertTestApplication(t, "a@example.com")
+
+	if _, err := services.ClaimApplication(appID, other); err != nil {
+		t.Fatal(err)
+	}
+	// 他人持有有效认领锁时审核失败，申请保持不变
+	if _, err := applyReview(1, "admin", appID, reviewInput{Status: "rejected"}); err != errReviewConflict {
+		t.Fatalf("review while claimed by another admin: err = %v, want %v", err, errReviewConflict)
+	}
+	if status, _ := applicationState(t, appID); status != "pending" {
+		t.Errorf("status = %s after refused review", status)
+	}
+
+	// 认领人可以审核，审核后释放认领锁
+	if status, err := applyReview(other, "reviewer2", appID, reviewInput{Status: "approved"}); err != nil || status != "approved" {
+		t.Fatalf("review by lock holder: %q, %v", status, err)
+	}
+	var lockedBy *int64
+	database.DB.QueryRow("SELECT locked_by FROM applications WHERE id = ?", appID).Scan(&lockedBy)
+	if lockedBy != nil {
+		t.Errorf("lock still held by %d after review", *lockedBy)
+	}
+
+	// 已处理的申请不能再次审核
+	if _, err := applyReview(1, "admin", appID, reviewInput{Status: "rejected"}); err != errReviewConflict {
+		t.Errorf("review decided application: err = %v, want %v", err, errReviewConflict)
+	}
+	if status, code := applicationState(t, appID); status != "approved" || code != "CODE-1" {
+		t.Errorf("decided application changed to %s/%q", status, code)
+	}
+
+	// 认领锁过期后其他审核员可以审核
+	expired := insertTestApplication(t, "b@example.com")
+	if _, err := database.DB.Exec(
+		"UPDATE applications SET locked_by = ?, locked_until = ? WHERE id = ?", other, time.Now().Unix()-1, expired,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := applyReview(1, "admin", expired, reviewInput{Status: "rejected"}); err != nil || status != "rejected" {
+		t.Errorf("review after lock expired: %q, %v", status, err)
+	}
+}
+
+func TestApplyReviewDualApproval(t *testing.T) {
+	setupTestDB(t)
+	if _, err := database.DB.Exec(
+		"INSERT OR REPLACE INTO settings (key, value) VALUES ('require_dual_approval', 'true')",
+	); err != nil {
+		t.Fatal(err)
+	}
+	other := insertTestAdmin(t, "reviewer2")
+	importTestCodes(t, "CODE-1")
+	appID := insertTestApplication(t, "a@example.com")
+
+	// 首审不能指定邀请码
+	if _, err := applyReview(1, "admin", appID, reviewInput{Status: "approved", Code: "MANUAL-1"}); err != errCodeAtFirstApproval {
+		t.Fatalf("first approval with code: err = %v, want %v", err, errCodeAtFirstApproval)
+	}
+	if status, _ := applicationState(t, appID); status != "pending" {
+		t.Errorf("after refused first approval: status %s", status)
+	}
+
+	// 首次批准只进入待复核，不发放邀请码
+	if status, err := applyReview(1, "admin", appID, reviewInput{Status: "approved", Opinion: "ok"}); err != nil || status != "pending_second_review" {
+		t.Fatalf("first approval: %q, %v", status, err)
+	}
+	var firstApprovedBy int
+	database.DB.QueryRow("SELECT first_approved_by FROM applications WHERE id = ?", appID).Scan(&firstApprovedBy)
+	if status, code := applicationState(t, appID); status != "pending_second_review" || code != "" || firstApprovedBy != 1 {
+		t.Errorf("after first approval: status %s, code %q, first approver %d", status, code, firstApprovedBy)
+	}
+
+	// 同一管理员不能完成复核
+	if _, err := applyReview(1, "admin", appID, reviewInput{Status: "approved"}); err != errSecondReviewerRequired {
+		t.Fatalf("second approval by same admin: err = %v, want %v", err, errSecondReviewerRequired)
+	}
+	if status, code := applicationState(t, appID); status != "pending_second_review" || code != "" {
+		t.Errorf("after refused approval: status %s, code %q", status, code)
+	}
+
+	// 另一位管理员复核后批准并发放邀请码，沿用首审意见
+	if status, err := applyReview(other, "reviewer2", appID, reviewInput{Status: "approved"}); err != nil || status != "approved" {
+		t.Fatalf("second approval: %q, %v", status, err)
+	}
+	var opinion string
+	var decidedBy int
+	database.DB.QueryRow("SELECT review_opinion, decided_by FROM applications WHERE id = ?", appID).Scan(&opinion, &decidedBy)
+	if status, code := applicationState(t, appID); status != "approved" || code != "CODE-1" || opinion != "ok" || decidedBy != other {
+		t.Errorf("after second approval: status %s, code %q, opinion %q, decided by %d", status, code, opinion, decidedBy)
+	}
+}
+
+func TestRemoveApplicationCodes(t *testing.T) {
+	setupTestDB(t)
+	importTestCodes(t, "CODE-1")
+	appID := insertTestApplication(t, "a@example.com")
+	if _, err := applyReview(1, "admin", appID, reviewInput{Status: "approved"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 已批准申请的邀请码已随邮件发出，删除申请后作废，不再分配给其他申请
+	if _, err := removeApplication(appID); err != nil {
+		t.Fatal(err)
+	}
+	var status string
+	var owner *int64
+	database.DB.QueryRow("SELECT status, application_id FROM invitation_codes WHERE code = 'CODE-1'").Scan(&status, &owner)
+	if status != services.InviteCodeRevoked || owner != nil {
+		t.Errorf("code after deleting approved application: %s, owner %v", status, owner)
+	}
+	next := insertTestApplication(t, "b@example.com")
+	if _, err := applyReview(1, "admin", next, reviewInput{Status: "approved"}); err != services.ErrInvitePoolExhausted {
+		t.Errorf("approve after delete: err = %v, want %v", err, services.ErrInvitePoolExhausted)
+	}
+
+	// 从未批准的申请持有的邀请码归还库存
+	importTestCodes(t, "CODE-2")
+	pending := insertTestApplication(t, "c@example.com")
+	if _, err := database.DB.Exec("UPDATE invitation_codes SET status = 'assigned', application_id = ? WHERE code = 'CODE-2'", pending); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := removeApplication(pending); err != nil {
+		t.Fatal(err)
+	}
+	database.DB.QueryRow("SELECT status, application_id FROM invitation_codes WHERE code = 'CODE-2'").Scan(&status, &owner)
+	if status != services.InviteCodeAvailable || owner != nil {
+		t.Errorf("code after deleting pending application: %s, owner %v", status, owner)
+	}
+}
+
+func TestApplyReviewTemplateKindMismatch(t *testing.T) {
+	setupTestDB(t)
+	appID := insertTestApplication(t, "a@example.com")
+	templateID, err := services.CreateReviewTemplate("welcome", "Welcome {{email}}", services.TemplateKindApproval, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 拒绝时使用通过模板，审核不生效
+	if _, err := applyReview(1, "admin", appID, reviewInput{Status: "rejected", TemplateID: templateID}); err != services.ErrTemplateKindMismatch {
+		t.Fatalf("reject with approval template: err = %v, want %v", err, services.ErrTemplateKindMismatch)
+	}
+	if status, _ := applicationState(t, appID); status != "pending" {
+		t.Errorf("after mismatched template: status %s, want pending", status)
+	}
+}
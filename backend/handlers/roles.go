@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"invite-backend/database"
+	"invite-backend/middleware"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 角色名称仅允许小写字母、数字、下划线与连字符
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// GetPermissions 获取系统支持的全部权限
+func GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": services.AllPermissions})
+}
+
+// GetRoles 获取全部角色及其权限
+func GetRoles(c *gin.Context) {
+	roles, err := services.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": roles})
+}
+
+// AddRole 新建自定义角色
+func AddRole(c *gin.Context) {
+	var req struct {
+		Name        string   `json:"name" binding:"required"`
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if !roleNamePattern.MatchString(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "角色名称需为 2-32 位小写字母、数字、下划线或连字符，并以字母开头"})
+		return
+	}
+	if services.RoleExists(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "角色已存在"})
+		return
+	}
+
+	if !canGrantPermissions(c, req.Permissions) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "不能授予自己未拥有的权限"})
+		return
+	}
+
+	if err := services.SaveRole(req.Name, strings.TrimSpace(req.Description), req.Permissions, true); err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"success": false, "message": roleErrorMessage(err, "添加失败")})
+		return
+	}
+
+	logRoleChange(c, "add_role", "新建角色 "+req.Name+" 权限: "+strings.Join(req.Permissions, ", "))
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "角色已添加"})
+}
+
+// UpdateRole 修改角色的说明与权限，立即对该角色下的所有人员生效
+func UpdateRole(c *gin.Context) {
+	name := c.Param("name")
+	var req struct {
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	if !canManageRole(c, name) || !canGrantPermissions(c, req.Permissions) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "不能授予自己未拥有的权限"})
+		return
+	}
+
+	if err := services.SaveRole(name, strings.TrimSpace(req.Description), req.Permissions, false); err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"success": false, "message": roleErrorMessage(err, "更新失败")})
+		return
+	}
+
+	logRoleChange(c, "update_role", "修改角色 "+name+" 权限: "+strings.Join(req.Permissions, ", "))
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "角色已更新"})
+}
+
+// DeleteRole 删除自定义角色
+func DeleteRole(c *gin.Context) {
+	name := c.Param("name")
+
+	if err := services.DeleteRole(name); err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"success": false, "message": roleErrorMessage(err, "删除失败")})
+		return
+	}
+
+	logRoleChange(c, "delete_role", "删除角色 "+name)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "角色已删除"})
+}
+
+// canGrantPermissions 当前管理员只能授予自己拥有的权限，防止通过自定义角色越权
+func canGrantPermissions(c *gin.Context, permissions []string) bool {
+	for _, p := range permissions {
+		if !middleware.HasPermission(c, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// logRoleChange 记录角色变更审计日志
+func logRoleChange(c *gin.Context, action, details string) {
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, details) VALUES (?, ?, ?, ?)",
+		adminID, adminUsername, action, details,
+	)
+}
+
+func roleErrorStatus(err error) int {
+	switch err {
+	case services.ErrRoleNotFound:
+		return http.StatusNotFound
+	case services.ErrRoleBuiltin, services.ErrRoleInUse, services.ErrUnknownPermission:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func roleErrorMessage(err error, fallback string) string {
+	switch err {
+	case services.ErrRoleNotFound:
+		return "角色不存在"
+	case services.ErrRoleBuiltin:
+		return "内置角色不可执行该操作"
+	case services.ErrRoleInUse:
+		return "仍有人员使用该角色，无法删除"
+	case services.ErrUnknownPermission:
+		return "包含无效的权限"
+	}
+	return fallback
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupRoleManager 创建只拥有审核与人员管理权限的自定义角色及其管理员，返回管理员 ID
+func setupRoleManager(t *testing.T) int {
+	t.Helper()
+	if err := services.SaveRole("staff", "", []string{services.PermApplicationsReview, services.PermAdminsManage}, true); err != nil {
+		t.Fatal(err)
+	}
+	res, err := database.DB.Exec("INSERT INTO admins (username, role) VALUES ('manager', 'staff')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id)
+}
+
+func TestRoleGrantLimitedToOwnPermissions(t *testing.T) {
+	setupTestDB(t)
+	manager := setupRoleManager(t)
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		body    string
+		params  gin.Params
+		want    int
+	}{
+		{"create role with unheld permission", AddRole,
+			`{"name": "settings-editor", "permissions": ["settings.write"]}`, nil, http.StatusForbidden},
+		{"create role with held permissions", AddRole,
+			`{"name": "helper", "permissions": ["applications.review"]}`, nil, http.StatusOK},
+		{"add unheld permission to own-subset role", UpdateRole,
+			`{"permissions": ["applications.review", "settings.write"]}`, gin.Params{{Key: "name", Value: "helper"}}, http.StatusForbidden},
+		{"edit role holding permissions beyond own", UpdateRole,
+			`{"permissions": ["applications.review"]}`, gin.Params{{Key: "name", Value: "moderator"}}, http.StatusForbidden},
+		{"edit own-subset role", UpdateRole,
+			`{"description": "helper", "permissions": ["applications.review"]}`, gin.Params{{Key: "name", Value: "helper"}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := performAsAdmin(t, tt.handler, manager, "manager", "staff", http.MethodPost, tt.body, tt.params...)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body.String())
+		}
+	}
+
+	perms, _ := services.GetRolePermissions("moderator")
+	if !perms[services.PermAnnouncementsManage] {
+		t.Errorf("moderator permissions changed: %v", perms)
+	}
+	if services.RoleExists("settings-editor") {
+		t.Error("role with unheld permission was created")
+	}
+}
+
+func TestAssignRoleLimitedToOwnPermissions(t *testing.T) {
+	setupTestDB(t)
+	manager := setupRoleManager(t)
+	res, err := database.DB.Exec("INSERT INTO admins (username, role) VALUES ('colleague', 'staff')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	colleague, _ := res.LastInsertId()
+	id := gin.Params{{Key: "id", Value: strconv.FormatInt(colleague, 10)}}
+
+	// 不能添加拥有自己未持有权限的角色的人员，也不能添加超级管理员
+	for _, role := range []string{"moderator", services.RoleSuper} {
+		body := fmt.Sprintf(`{"username": "new-%s", "password": "password123", "role": %q}`, role, role)
+		if w := performAsAdmin(t, AddAdmin, manager, "manager", "staff", http.MethodPost, body); w.Code != http.StatusForbidden {
+			t.Errorf("add %s admin: status %d, want %d", role, w.Code, http.StatusForbidden)
+		}
+	}
+
+	// 可以管理同权限的人员，但不能把他人改为权限超出自己的角色
+	if w := performAsAdmin(t, UpdateAdmin, manager, "manager", "staff", http.MethodPut, `{"email": "c@example.com"}`, id...); w.Code != http.StatusOK {
+		t.Errorf("update colleague: status %d (%s)", w.Code, w.Body.String())
+	}
+	if w := performAsAdmin(t, UpdateAdmin, manager, "manager", "staff", http.MethodPut, `{"role": "moderator"}`, id...); w.Code != http.StatusForbidden {
+		t.Errorf("promote to moderator: status %d, want %d", w.Code, http.StatusForbidden)
+	}
+	var role string
+	database.DB.QueryRow("SELECT role FROM admins WHERE id = ?", colleague).Scan(&role)
+	if role != "staff" {
+		t.Errorf("colleague role changed to %s", role)
+	}
+
+	// 超级管理员不受限制
+	if w := performAsAdmin(t, UpdateAdmin, 1, "admin", services.RoleSuper, http.MethodPut, `{"role": "moderator"}`, id...); w.Code != http.StatusOK {
+		t.Errorf("promote by super: status %d (%s)", w.Code, w.Body.String())
+	}
+}
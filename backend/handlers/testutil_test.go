@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"invite-backend/config"
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTestDB 在临时目录中初始化一个全新的数据库，初始超级管理员的 ID 为 1
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	config.AppConfig = &config.Config{AdminUsername: "admin", AdminPassword: "test-password", JWTSecret: "test"}
+	if err := database.InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.DB.Close() })
+}
+
+// insertTestAdmin 插入一名审核员并返回 ID
+func insertTestAdmin(t *testing.T, username string) int {
+	t.Helper()
+	res, err := database.DB.Exec("INSERT INTO admins (username, role) VALUES (?, 'reviewer')", username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id)
+}
+
+// insertTestApplication 插入一条待审核申请并返回 ID
+func insertTestApplication(t *testing.T, email string) int {
+	t.Helper()
+	res, err := database.DB.Exec(
+		"INSERT INTO applications (email, reason, device_id, ip, status) VALUES (?, 'reason', ?, '127.0.0.1', 'pending')",
+		email, email,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id)
+}
+
+// importTestCodes 向库存导入可用邀请码
+func importTestCodes(t *testing.T, codes ...string) {
+	t.Helper()
+	for _, code := range codes {
+		if _, err := database.DB.Exec(
+			"INSERT INTO invitation_codes (code, status, created_at) VALUES (?, 'available', strftime('%s', 'now'))", code,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// applicationState 读取申请的状态与分配到的邀请码
+func applicationState(t *testing.T, appID int) (status, code string) {
+	t.Helper()
+	if err := database.DB.QueryRow("SELECT status FROM applications WHERE id = ?", appID).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+	database.DB.QueryRow(
+		"SELECT code FROM invitation_codes WHERE application_id = ? AND status = 'assigned'", appID,
+	).Scan(&code)
+	return status, code
+}
+
+// performAsAdmin 以指定管理员身份调用处理函数，按角色加载权限，返回响应记录
+func performAsAdmin(t *testing.T, handler gin.HandlerFunc, adminID int, username, role, method, body string, params ...gin.Param) *httptest.ResponseRecorder {
+	t.Helper()
+	permissions, err := services.GetRolePermissions(role)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	c.Set("admin_id", adminID)
+	c.Set("admin_username", username)
+	c.Set("admin_role", role)
+	c.Set("admin_permissions", permissions)
+	handler(c)
+	return w
+}
+
+// decodeResponse 解析 JSON 响应
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+}
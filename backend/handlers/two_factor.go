@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"invite-backend/config"
+	"invite-backend/database"
+	"invite-backend/services"
+	"invite-backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 每次生成的恢复码数量
+const recoveryCodeCount = 10
+
+// 两步验证连续失败次数上限及锁定时长
+const (
+	twoFactorMaxFailures = 5
+	twoFactorLockout     = 5 * time.Minute
+)
+
+// twoFactorFailures 两步验证失败计数（按管理员 ID）
+var twoFactorFailures = struct {
+	sync.Mutex
+	count map[int]int
+	until map[int]time.Time
+}{count: make(map[int]int), until: make(map[int]time.Time)}
+
+// generate2FAChallenge 生成两步验证挑战令牌，仅能用于 /admin/login/2fa
+func generate2FAChallenge(id int) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":      id,
+		"purpose": "2fa",
+		"exp":     time.Now().Add(5 * time.Minute).Unix(),
+	})
+
+	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+}
+
+// parse2FAChallenge 解析两步验证挑战令牌
+func parse2FAChallenge(tokenString string) (int, bool) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(config.AppConfig.JWTSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != "2fa" {
+		return 0, false
+	}
+	id, ok := claims["id"].(float64)
+	if !ok {
+		return 0, false
+	}
+
+	return int(id), true
+}
+
+// verifySecondFactor 校验动态码或恢复码，恢复码校验通过后即作废
+func verifySecondFactor(adminID int, code, recoveryCode string) bool {
+	twoFactorFailures.Lock()
+	if time.Now().Before(twoFactorFailures.until[adminID]) {
+		twoFactorFailures.Unlock()
+		return false
+	}
+	twoFactorFailures.Unlock()
+
+	ok := false
+	if recoveryCode != "" {
+		res, err := database.DB.Exec(
+			"UPDATE admin_recovery_codes SET used_at = ? WHERE admin_id = ? AND code_hash = ? AND used_at IS NULL",
+			time.Now().Unix(), adminID, utils.HashRecoveryCode(recoveryCode),
+		)
+		if err == nil {
+			n, _ := res.RowsAffected()
+			ok = n > 0
+		}
+	} else {
+		var secret sql.NullString
+		var lastCounter int64
+		database.DB.QueryRow(
+			"SELECT totp_secret, totp_last_counter FROM admins WHERE id = ? AND totp_enabled = 1", adminID,
+		).Scan(&secret, &lastCounter)
+
+		if counter, valid := utils.VerifyTOTP(secret.String, code, time.Now()); valid && counter > lastCounter {
+			// 仅当计数器前进时更新，防止同一动态码被并发重放
+			res, err := database.DB.Exec(
+				"UPDATE admins SET totp_last_counter = ? WHERE id = ? AND totp_last_counter < ?",
+				counter, adminID, counter,
+			)
+			if err == nil {
+				n, _ := res.RowsAffected()
+				ok = n > 0
+			}
+		}
+	}
+
+	twoFactorFailures.Lock()
+	defer twoFactorFailures.Unlock()
+	if ok {
+		delete(twoFactorFailures.count, adminID)
+		delete(twoFactorFailures.until, adminID)
+		return true
+	}
+	twoFactorFailures.count[adminID]++
+	if twoFactorFailures.count[adminID] >= twoFactorMaxFailures {
+		twoFactorFailures.until[adminID] = time.Now().Add(twoFactorLockout)
+		delete(twoFactorFailures.count, adminID)
+	}
+	return false
+}
+
+// replaceRecoveryCodes 重新生成恢复码，旧恢复码全部失效
+func replaceRecoveryCodes(tx *sql.Tx, adminID int) ([]string, error) {
+	if _, err := tx.Exec("DELETE FROM admin_recovery_codes WHERE admin_id = ?", adminID); err != nil {
+		return nil, err
+	}
+
+	codes := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	now := time.Now().Unix()
+	for _, code := range codes {
+		if _, err := tx.Exec(
+			"INSERT INTO admin_recovery_codes (admin_id, code_hash, created_at) VALUES (?, ?, ?)",
+			adminID, utils.HashRecoveryCode(code), now,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	return codes, nil
+}
+
+// AdminLogin2FA 登录第二步：校验动态码或恢复码
+func AdminLogin2FA(c *gin.Context) {
+	var req struct {
+		ChallengeToken string `json:"challengeToken" binding:"required"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recoveryCode"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	adminID, ok := parse2FAChallenge(req.ChallengeToken)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "验证已过期，请重新登录"})
+		return
+	}
+
+	if !verifySecondFactor(adminID, req.Code, req.RecoveryCode) {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "动态码或恢复码错误"})
+		return
+	}
+
+	var username, role string
+	var mustChangePassword bool
+	err := database.DB.QueryRow(
+		"SELECT username, role, must_change_password FROM admins WHERE id = ?", adminID,
+	).Scan(&username, &role, &mustChangePassword)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "账号不存在"})
+		return
+	}
+
+	tokenString, err := generateAdminToken(c, adminID, username, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "生成 Token 失败"})
+		return
+	}
+
+	var remaining int
+	database.DB.QueryRow("SELECT COUNT(*) FROM admin_recovery_codes WHERE admin_id = ? AND used_at IS NULL", adminID).Scan(&remaining)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":                true,
+		"token":                  tokenString,
+		"mustChangePassword":     mustChangePassword,
+		"recoveryCodesRemaining": remaining,
+	})
+}
+
+// Get2FAStatus 获取当前管理员的两步验证状态
+func Get2FAStatus(c *gin.Context) {
+	adminID, _ := c.Get("admin_id")
+
+	var enabled bool
+	var role string
+	database.DB.QueryRow("SELECT totp_enabled, role FROM admins WHERE id = ?", adminID).Scan(&enabled, &role)
+
+	var remaining int
+	database.DB.QueryRow("SELECT COUNT(*) FROM admin_recovery_codes WHERE admin_id = ? AND used_at IS NULL", adminID).Scan(&remaining)
+
+	settings, _ := services.GetSystemSettings()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"enabled":                enabled,
+			"required":               role == "super" && settings["require_2fa_for_super"] == "true",
+			"recoveryCodesRemaining": remaining,
+		},
+	})
+}
+
+// Setup2FA 生成新的 TOTP 密钥，需调用 Enable2FA 确认后才生效
+func Setup2FA(c *gin.Context) {
+	adminID, _ := c.Get("admin_id")
+	username, _ := c.Get("admin_username")
+
+	var enabled bool
+	database.DB.QueryRow("SELECT totp_enabled FROM admins WHERE id = ?", adminID).Scan(&enabled)
+	if enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "两步验证已启用，如需更换请先关闭"})
+		return
+	}
+
+	secret := utils.GenerateTOTPSecret()
+	_, err := database.DB.Exec("UPDATE admins SET totp_secret = ?, totp_last_counter = 0 WHERE id = ?", secret, adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "生成密钥失败"})
+		return
+	}
+
+	settings, _ := services.GetSystemSettings()
+	issuer := settings["site_name"]
+	if issuer == "" {
+		issuer = "InviteSystem"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"secret": secret,
+			"uri":    utils.TOTPURI(issuer, fmt.Sprint(username), secret),
+		},
+	})
+}
+
+// Enable2FA 校验动态码后启用两步验证，并返回一次性恢复码
+func Enable2FA(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	adminID := c.GetInt("admin_id")
+
+	var secret sql.NullString
+	var enabled bool
+	database.DB.QueryRow("SELECT totp_secret, totp_enabled FROM admins WHERE id = ?", adminID).Scan(&secret, &enabled)
+	if enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "两步验证已启用"})
+		return
+	}
+	if !secret.Valid || secret.String == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "请先生成两步验证密钥"})
+		return
+	}
+
+	counter, ok := utils.VerifyTOTP(secret.String, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "动态码错误，请检查设备时间"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"UPDATE admins SET totp_enabled = 1, totp_last_counter = ?, updated_at = ? WHERE id = ?",
+		counter, time.Now().Unix(), adminID,
+	); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "启用失败"})
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(tx, adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "生成恢复码失败"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "提交事务失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "两步验证已启用，请妥善保存恢复码",
+		"recoveryCodes": codes,
+	})
+}
+
+// Disable2FA 关闭两步验证（需验证当前密码及动态码）
+func Disable2FA(c *gin.Context) {
+	var req struct {
+		Password     string `json:"password" binding:"required"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recoveryCode"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	adminID := c.GetInt("admin_id")
+
+	var role string
+	var passwordHash sql.NullString
+	database.DB.QueryRow("SELECT role, password_hash FROM admins WHERE id = ?", adminID).Scan(&role, &passwordHash)
+
+	settings, _ := services.GetSystemSettings()
+	if role == "super" && settings["require_2fa_for_super"] == "true" {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "系统要求超级管理员必须启用两步验证"})
+		return
+	}
+
+	if ok, _ := utils.VerifyPassword(req.Password, passwordHash.String); !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "当前密码错误"})
+		return
+	}
+	if !verifySecondFactor(adminID, req.Code, req.RecoveryCode) {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "动态码或恢复码错误"})
+		return
+	}
+
+	if err := clear2FA(adminID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "关闭失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "两步验证已关闭"})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码（需验证动态码）
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误"})
+		return
+	}
+
+	adminID := c.GetInt("admin_id")
+	if !verifySecondFactor(adminID, req.Code, "") {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "动态码错误"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	codes, err := replaceRecoveryCodes(tx, adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "生成恢复码失败"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "提交事务失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "recoveryCodes": codes})
+}
+
+// ResetAdmin2FA 超级管理员重置其他管理员的两步验证（例如设备丢失）
+func ResetAdmin2FA(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "无效的管理员ID"})
+		return
+	}
+
+	var username, role string
+	if err := database.DB.QueryRow("SELECT username, role FROM admins WHERE id = ?", id).Scan(&username, &role); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "管理员不存在"})
+		return
+	}
+	if !canManageRole(c, role) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "只有超级管理员可以重置超级管理员的两步验证"})
+		return
+	}
+
+	if err := clear2FA(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "重置失败"})
+		return
+	}
+
+	// 重置通常意味着账号或设备可能已泄露，同时让该管理员的全部会话失效
+	if err := services.RevokeAdminSessions(id); err != nil {
+		log.Printf("Failed to revoke sessions for admin %d: %v", id, err)
+	}
+
+	adminID, _ := c.Get("admin_id")
+	adminUsername, _ := c.Get("admin_username")
+	_, _ = database.DB.Exec(
+		"INSERT INTO audit_logs (admin_id, admin_username, action, details) VALUES (?, ?, ?, ?)",
+		adminID, adminUsername, "reset_2fa", "重置管理员 "+username+" 的两步验证",
+	)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "两步验证已重置"})
+}
+
+// clear2FA 清除管理员的两步验证密钥与恢复码
+func clear2FA(adminID int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"UPDATE admins SET totp_secret = NULL, totp_enabled = 0, totp_last_counter = 0, updated_at = ? WHERE id = ?",
+		time.Now().Unix(), adminID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM admin_recovery_codes WHERE admin_id = ?", adminID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResetAdmin2FARevokesSessions(t *testing.T) {
+	setupTestDB(t)
+	reviewer := insertTestAdmin(t, "reviewer2")
+	if _, err := database.DB.Exec("UPDATE admins SET totp_secret = 'SECRET', totp_enabled = 1 WHERE id = ?", reviewer); err != nil {
+		t.Fatal(err)
+	}
+	jti, version, err := services.CreateAdminSession(reviewer, "127.0.0.1", "test", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := performAsAdmin(t, ResetAdmin2FA, 1, "admin", services.RoleSuper, http.MethodPost, "",
+		gin.Param{Key: "id", Value: strconv.Itoa(reviewer)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("reset 2FA: status %d (%s)", w.Code, w.Body.String())
+	}
+
+	var enabled bool
+	database.DB.QueryRow("SELECT totp_enabled FROM admins WHERE id = ?", reviewer).Scan(&enabled)
+	if enabled {
+		t.Error("2FA still enabled after reset")
+	}
+	if services.ValidateAdminSession(reviewer, jti, version) {
+		t.Error("session still valid after 2FA reset")
+	}
+}
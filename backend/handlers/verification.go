@@ -20,6 +20,9 @@ func SendVerificationCode(c *gin.Context) {
 	var req struct {
 		Email         string `json:"email" binding:"required,email"`
 		CaptchaAnswer string `json:"captchaAnswer" binding:"required"`
+		Fingerprint   string `json:"fingerprint" binding:"required"`
+		PowSalt       string `json:"powSalt" binding:"required"`
+		PowNonce      int64  `json:"powNonce"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -30,9 +33,15 @@ func SendVerificationCode(c *gin.Context) {
 	// 统一转为小写并去空格
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 
+	// 校验 PoW 挑战
+	if !checkPowSolution(c, req.Fingerprint, req.PowSalt, req.PowNonce) {
+		return
+	}
+
 	// 验证人机验证
 	storedAnswer, err := c.Cookie("captcha_answer")
 	if err != nil || storedAnswer != req.CaptchaAnswer {
+		services.RecordSecurityFailure(services.SecurityFailureCaptcha, c.ClientIP(), req.Fingerprint)
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "验证问答错误"})
 		return
 	}
@@ -75,11 +84,21 @@ func SendVerificationCode(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "该邮箱不在允许的白名单内"})
 			return
 		}
+	} else {
+		// 未配置白名单时检查一次性邮箱黑名单与 MX 记录
+		switch services.CheckEmailDomain(req.Email, c.ClientIP(), settings) {
+		case services.EmailRejectBlocklist:
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "不支持使用临时邮箱申请，请更换常用邮箱"})
+			return
+		case services.EmailRejectNoMX:
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "该邮箱域名无法接收邮件，请检查邮箱地址"})
+			return
+		}
 	}
 
-	// 检查是否已申请
+	// 检查是否已申请（已撤回的申请不计入，被拒绝的申请按重新申请限制处理）
 	var count int
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE email = ?", req.Email).Scan(&count)
+	err = database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE email = ? AND status NOT IN ('withdrawn', 'rejected')", req.Email).Scan(&count)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "系统错误"})
 		return
@@ -88,6 +107,10 @@ func SendVerificationCode(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "该邮箱已提交过申请"})
 		return
 	}
+	if msg, blocked := reapplyBlocked(req.Email); blocked {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": msg})
+		return
+	}
 
 	// 生成验证码
 	code := fmt.Sprintf("%06d", rand.Intn(900000)+100000)
@@ -95,8 +118,8 @@ func SendVerificationCode(c *gin.Context) {
 
 	// 保存验证码
 	_, err = database.DB.Exec(
-		"INSERT INTO verification_codes (email, code, expires_at) VALUES (?, ?, ?)",
-		req.Email, code, expiresAt,
+		"INSERT INTO verification_codes (email, code, ip, expires_at) VALUES (?, ?, ?, ?)",
+		req.Email, code, c.ClientIP(), expiresAt,
 	)
 	if err != nil {
 		fmt.Printf("Failed to save verification code for %s: %v\n", req.Email, err)
@@ -136,26 +159,54 @@ func GetCaptcha(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"question": question})
 }
 
-// GetSecurityChallenge PoW 挑战
+// GetSecurityChallenge 签发绑定设备指纹的 PoW 挑战
 func GetSecurityChallenge(c *gin.Context) {
-	salt := fmt.Sprintf("%x", rand.Int63())
-	difficulty := 4
+	fingerprint := strings.TrimSpace(c.Query("fingerprint"))
+	if fingerprint == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "缺少设备指纹"})
+		return
+	}
+
+	challenge, err := services.IssuePowChallenge(fingerprint, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "系统错误"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"salt":       salt,
-		"difficulty": difficulty,
+		"salt":       challenge.Salt,
+		"difficulty": challenge.Difficulty,
+		"expiresAt":  challenge.ExpiresAt,
 	})
 }
 
+// checkPowSolution 校验 PoW 解答，失败时直接写入响应并返回 false
+func checkPowSolution(c *gin.Context, fingerprint, salt string, nonce int64) bool {
+	err := services.VerifyPowSolution(fingerprint, salt, nonce)
+	switch err {
+	case nil:
+		return true
+	case services.ErrPowChallengeInvalid:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "安全校验已过期，请重试"})
+	case services.ErrPowSolutionInvalid:
+		services.RecordSecurityFailure(services.SecurityFailurePow, c.ClientIP(), fingerprint)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "安全校验失败"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "系统错误"})
+	}
+	return false
+}
+
 // GetStats 获取统计信息
 func GetStats(c *gin.Context) {
-	var total, pending, approved, rejected, processed int
+	var total, pending, approved, rejected, withdrawn, processed int
 
 	database.DB.QueryRow("SELECT COUNT(*) FROM applications").Scan(&total)
-	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE status = 'pending'").Scan(&pending)
+	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE status IN ('pending', 'pending_second_review')").Scan(&pending)
 	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE status = 'approved'").Scan(&approved)
 	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE status = 'rejected'").Scan(&rejected)
-	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE status != 'pending'").Scan(&processed)
+	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE status = 'withdrawn'").Scan(&withdrawn)
+	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE status NOT IN ('pending', 'pending_second_review', 'withdrawn')").Scan(&processed)
 
 	settings, _ := services.GetSystemSettings()
 	isOpen := settings["application_open"] != "false"
@@ -165,6 +216,7 @@ func GetStats(c *gin.Context) {
 		"pending":           pending,
 		"approved":          approved,
 		"rejected":          rejected,
+		"withdrawn":         withdrawn,
 		"processed":         processed,
 		"isApplicationOpen": isOpen,
 		"siteName":          settings["site_name"],
@@ -207,14 +259,45 @@ func CheckApplicationStatus(c *gin.Context) {
 
 	app.ID = appID
 	app.CreatedAt = time.Unix(database.ToUnixTimestamp(createdAtVal), 0)
+	// 复核属于内部流程，对申请人仍显示为待审核
+	if app.Status == "pending_second_review" {
+		app.Status = "pending"
+	}
 	if adminNote.Valid {
 		app.AdminNote = adminNote.String
 	}
 
-	// 如果已批准，获取邀请码
-	var inviteCode string
+	// 被拒绝时告知何时可以重新申请
+	var reapplyAt interface{}
+	reapplyExhausted := false
+	if app.Status == "rejected" {
+		if status, err := services.GetReapplyStatus(app.Email); err == nil {
+			reapplyExhausted = status.Exhausted
+			if !status.Exhausted && !status.AllowedAt.IsZero() {
+				reapplyAt = status.AllowedAt
+			}
+		}
+	}
+
+	// 如果已批准，获取邀请码及其实际状态（作废的邀请码不再展示）
+	var inviteCode, inviteCodeStatus string
+	var inviteExpiresAt interface{}
 	if app.Status == "approved" {
-		database.DB.QueryRow("SELECT code FROM invitation_codes WHERE application_id = ?", appID).Scan(&inviteCode)
+		var codeStatus string
+		var expiresAt sql.NullInt64
+		err := database.DB.QueryRow(
+			"SELECT code, status, expires_at FROM invitation_codes WHERE application_id = ? ORDER BY id DESC LIMIT 1",
+			appID,
+		).Scan(&inviteCode, &codeStatus, &expiresAt)
+		if err == nil {
+			inviteCodeStatus = services.EffectiveInviteCodeStatus(codeStatus, expiresAt.Int64)
+			if inviteCodeStatus == services.InviteCodeRevoked {
+				inviteCode = ""
+			}
+			if expiresAt.Valid {
+				inviteExpiresAt = time.Unix(expiresAt.Int64, 0)
+			}
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -226,6 +309,27 @@ func CheckApplicationStatus(c *gin.Context) {
 			"adminNote":  app.AdminNote,
 			"createdAt":  app.CreatedAt,
 			"inviteCode": inviteCode,
+			// available/assigned/redeemed/revoked/expired
+			"inviteCodeStatus":    inviteCodeStatus,
+			"inviteCodeExpiresAt": inviteExpiresAt,
+			// 被拒绝后可重新申请的时间，为空表示无需等待；reapplyExhausted 表示已不能再次申请
+			"reapplyAt":        reapplyAt,
+			"reapplyExhausted": reapplyExhausted,
 		},
 	})
 }
+
+// reapplyBlocked 检查邮箱是否处于被拒绝后的冷却期或已达拒绝次数上限，返回提示信息
+func reapplyBlocked(email string) (string, bool) {
+	status, err := services.GetReapplyStatus(email)
+	if err != nil || !status.Blocked() {
+		return "", false
+	}
+	if status.Disabled {
+		return "该邮箱已提交过申请", true
+	}
+	if status.Exhausted {
+		return "该邮箱被拒绝的次数已达上限，无法再次申请", true
+	}
+	return fmt.Sprintf("该邮箱的申请已被拒绝，请于 %s 后重新申请", status.AllowedAt.Format("2006-01-02 15:04")), true
+}
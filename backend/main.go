@@ -9,6 +9,7 @@ import (
 	"invite-backend/database"
 	"invite-backend/handlers"
 	"invite-backend/middleware"
+	"invite-backend/services"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -32,6 +33,39 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// 导入内置的一次性邮箱域名黑名单
+	if err := services.SeedEmailBlocklist(); err != nil {
+		log.Printf("Failed to seed email blocklist: %v", err)
+	}
+
+	// 为历史申请建立理由相似度索引
+	services.BackfillReasonIndex()
+
+	// 启动申请自动分配任务
+	services.StartAssignmentWorker()
+
+	// 启动邮件发送队列
+	services.StartEmailWorker()
+
+	// 启动审核超时检查任务
+	services.StartSLAWorker()
+
+	// 创建 Gin 引擎并注册路由
+	r := setupRouter()
+
+	// 启动服务器之前设置静态文件服务
+	ServeStatic(r)
+
+	// 启动服务器
+	addr := ":" + config.AppConfig.Port
+	log.Printf("Server starting on %s", addr)
+	if err := r.Run(addr); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+// setupRouter 创建 Gin 引擎并注册全部 API 路由
+func setupRouter() *gin.Engine {
 	// 创建 Gin 引擎
 	r := gin.New() // 使用 New 而不是 Default，避免重复注册中间件
 	r.Use(gin.Logger(), gin.Recovery())
@@ -70,6 +104,16 @@ func main() {
 		api.POST("/application/submit", handlers.SubmitApplication)
 		api.POST("/application/status", handlers.CheckApplicationStatus)
 
+		// 申请人自助撤回或修改（通过邮件中的一次性链接访问）
+		api.POST("/application/manage-link", handlers.RequestManageLink)
+		api.GET("/application/manage", handlers.GetManagedApplication)
+		api.POST("/application/withdraw", handlers.WithdrawApplication)
+		api.POST("/application/edit", handlers.EditApplication)
+
+		// 申诉（通过拒绝邮件中的签名链接访问）
+		api.GET("/appeal", handlers.GetAppeal)
+		api.POST("/appeal", handlers.SubmitAppeal)
+
 		// 公告相关
 		api.GET("/announcements", handlers.GetAnnouncements)
 
@@ -78,7 +122,9 @@ func main() {
 		{
 			// 登录登出
 			admin.POST("/login", handlers.AdminLogin)
+			admin.POST("/login/2fa", handlers.AdminLogin2FA)
 			admin.POST("/logout", handlers.AdminLogout)
+			admin.POST("/token/refresh", handlers.RefreshAdminToken)
 
 			// Linux DO 登录
 			admin.GET("/linuxdo", handlers.LinuxDoLogin)
@@ -88,44 +134,104 @@ func main() {
 			authenticated := admin.Group("", middleware.AuthMiddleware())
 			{
 				// 所有管理员都能访问的
-				authenticated.GET("/applications", handlers.GetApplications)
-				authenticated.POST("/review", handlers.ReviewApplication)
 				authenticated.POST("/change-password", handlers.ChangePassword)
 				authenticated.GET("/me", handlers.GetMe) // 获取当前用户信息
+				authenticated.GET("/invite-codes/stats", handlers.GetInviteCodeStats)
+
+				// 两步验证
+				authenticated.GET("/2fa/status", handlers.Get2FAStatus)
+				authenticated.POST("/2fa/setup", handlers.Setup2FA)
+				authenticated.POST("/2fa/enable", handlers.Enable2FA)
+				authenticated.POST("/2fa/disable", handlers.Disable2FA)
+				authenticated.POST("/2fa/recovery-codes", handlers.RegenerateRecoveryCodes)
+
+				// 申请管理
+				authenticated.GET("/applications", middleware.RequirePermission(services.PermApplicationsReview), handlers.GetApplications)
+				authenticated.GET("/applications/:id", middleware.RequirePermission(services.PermApplicationsReview), handlers.GetApplicationDetail)
+				authenticated.POST("/review", middleware.RequirePermission(services.PermApplicationsReview), handlers.ReviewApplication)
+				authenticated.POST("/review/bulk", middleware.RequirePermission(services.PermApplicationsReview), handlers.BulkReviewApplications)
+				authenticated.GET("/review-templates", middleware.RequirePermission(services.PermApplicationsReview), handlers.GetReviewTemplates)
+				authenticated.POST("/applications/:id/claim", middleware.RequirePermission(services.PermApplicationsReview), handlers.ClaimApplication)
+				authenticated.DELETE("/applications/:id/claim", middleware.RequirePermission(services.PermApplicationsReview), handlers.ReleaseApplication)
+				authenticated.PUT("/availability", middleware.RequirePermission(services.PermApplicationsReview), handlers.SetAvailability)
+				authenticated.GET("/applications/:id/comments", middleware.RequirePermission(services.PermApplicationsReview), handlers.GetApplicationComments)
+				authenticated.POST("/applications/:id/comments", middleware.RequirePermission(services.PermApplicationsReview), handlers.AddApplicationComment)
+				authenticated.DELETE("/applications/:id/comments/:commentId", middleware.RequirePermission(services.PermApplicationsReview), handlers.DeleteApplicationComment)
+				authenticated.DELETE("/applications/:id", middleware.RequirePermission(services.PermApplicationsDelete), handlers.DeleteApplication)
+
+				// 系统设置
+				settings := authenticated.Group("", middleware.RequirePermission(services.PermSettingsWrite))
+				{
+					settings.GET("/settings", handlers.GetSettings)
+					settings.POST("/settings/update", handlers.UpdateSettings)
+					settings.GET("/pow", handlers.GetPowStatus)
+					settings.PUT("/pow/override", handlers.UpdatePowOverride)
+				}
+
+				// 临时邮箱黑名单：系统设置人员可查看，增删只需黑名单管理权限
+				authenticated.GET("/email-blocklist", middleware.RequireAnyPermission(services.PermSettingsWrite, services.PermBlocklistManage), handlers.GetEmailBlocklist)
+				authenticated.POST("/email-blocklist", middleware.RequirePermission(services.PermBlocklistManage), handlers.AddEmailBlocklistDomain)
+				authenticated.DELETE("/email-blocklist/:domain", middleware.RequirePermission(services.PermBlocklistManage), handlers.RemoveEmailBlocklistDomain)
 
-				// 只有超级管理员能访问的
-				super := authenticated.Group("", middleware.RoleMiddleware("super"))
+				// 审核日志
+				authenticated.GET("/audit-logs", middleware.RequirePermission(services.PermAuditRead), handlers.GetAuditLogs)
+				authenticated.GET("/review-stats", middleware.RequirePermission(services.PermAuditRead), handlers.GetReviewStats)
+
+				// 公告管理
+				announcements := authenticated.Group("", middleware.RequirePermission(services.PermAnnouncementsManage))
+				{
+					announcements.GET("/announcements", handlers.GetAnnouncements)
+					announcements.POST("/announcements", handlers.AddAnnouncement)
+					announcements.DELETE("/announcements/:id", handlers.DeleteAnnouncement)
+					announcements.POST("/announcements/:id/toggle", handlers.ToggleAnnouncement)
+				}
+
+				// 人员与角色管理
+				admins := authenticated.Group("", middleware.RequirePermission(services.PermAdminsManage))
+				{
+					admins.GET("/admins", handlers.GetAdmins)
+					admins.POST("/admins", handlers.AddAdmin)
+					admins.DELETE("/admins/:id", handlers.DeleteAdmin)
+					admins.PUT("/admins/:id", handlers.UpdateAdmin)
+					admins.POST("/admins/:id/reset-2fa", handlers.ResetAdmin2FA)
+					admins.GET("/admins/:id/sessions", handlers.GetAdminSessions)
+					admins.DELETE("/admins/:id/sessions", handlers.RevokeAdminSessions)
+					admins.DELETE("/admins/:id/sessions/:jti", handlers.RevokeAdminSession)
+
+					admins.GET("/permissions", handlers.GetPermissions)
+					admins.GET("/roles", handlers.GetRoles)
+					admins.POST("/roles", handlers.AddRole)
+					admins.PUT("/roles/:name", handlers.UpdateRole)
+					admins.DELETE("/roles/:name", handlers.DeleteRole)
+				}
+
+				// 申诉处理
+				appeals := authenticated.Group("", middleware.RequirePermission(services.PermAppealsReview))
+				{
+					appeals.GET("/appeals", handlers.GetAppeals)
+					appeals.POST("/appeals/:id/decide", handlers.DecideAppeal)
+				}
+
+				// 审核模板管理
+				templates := authenticated.Group("", middleware.RequirePermission(services.PermTemplatesManage))
+				{
+					templates.POST("/review-templates", handlers.AddReviewTemplate)
+					templates.PUT("/review-templates/:id", handlers.UpdateReviewTemplate)
+					templates.DELETE("/review-templates/:id", handlers.DeleteReviewTemplate)
+					templates.GET("/review-templates/stats", handlers.GetReviewTemplateStats)
+				}
+
+				// 邀请码库存
+				codes := authenticated.Group("", middleware.RequirePermission(services.PermCodesImport))
 				{
-					super.GET("/settings", handlers.GetSettings)
-					super.POST("/settings/update", handlers.UpdateSettings)
-					super.GET("/audit-logs", handlers.GetAuditLogs)
-
-					// 公告管理
-					super.GET("/announcements", handlers.GetAnnouncements)
-					super.POST("/announcements", handlers.AddAnnouncement)
-					super.DELETE("/announcements/:id", handlers.DeleteAnnouncement)
-					super.POST("/announcements/:id/toggle", handlers.ToggleAnnouncement)
-
-					// 管理员管理
-					super.GET("/admins", handlers.GetAdmins)
-					super.POST("/admins", handlers.AddAdmin)
-					super.DELETE("/admins/:id", handlers.DeleteAdmin)
-					super.PUT("/admins/:id", handlers.UpdateAdmin)
-
-					// 申请管理
-					super.DELETE("/applications/:id", handlers.DeleteApplication)
+					codes.GET("/invite-codes", handlers.GetInviteCodes)
+					codes.POST("/invite-codes/import", handlers.ImportInviteCodes)
+					codes.POST("/invite-codes/redeem", handlers.RedeemInviteCodes)
+					codes.POST("/invite-codes/:id/revoke", handlers.RevokeInviteCode)
 				}
 			}
 		}
 	}
 
-	// 启动服务器之前设置静态文件服务
-	ServeStatic(r)
-
-	// 启动服务器
-	addr := ":" + config.AppConfig.Port
-	log.Printf("Server starting on %s", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
-	}
+	return r
 }
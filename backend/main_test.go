@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"invite-backend/config"
+	"invite-backend/database"
+	"invite-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// setupTestDB 在临时目录中初始化一个全新的数据库
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	config.AppConfig = &config.Config{AdminUsername: "admin", AdminPassword: "test-password", JWTSecret: "test"}
+	if err := database.InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.DB.Close() })
+}
+
+// testAdminToken 为指定角色创建管理员与会话，返回访问令牌
+func testAdminToken(t *testing.T, username, role string) string {
+	t.Helper()
+	res, err := database.DB.Exec("INSERT INTO admins (username, role) VALUES (?, ?)", username, role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id64, _ := res.LastInsertId()
+	id := int(id64)
+
+	jti, version, err := services.CreateAdminSession(id, "127.0.0.1", "test", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":       id,
+		"username": username,
+		"role":     role,
+		"jti":      jti,
+		"ver":      version,
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(config.AppConfig.JWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestRoutePermissions(t *testing.T) {
+	setupTestDB(t)
+
+	r := setupRouter()
+	moderator := testAdminToken(t, "mod", "moderator")
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/admin/settings", http.StatusForbidden},
+		{http.MethodGet, "/api/admin/announcements", http.StatusOK},
+		{http.MethodGet, "/api/admin/applications", http.StatusOK},
+		{http.MethodGet, "/api/admin/admins", http.StatusForbidden},
+		{http.MethodGet, "/api/admin/invite-codes", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+moderator)
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("moderator %s %s: status %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestBlocklistRoutesNeedOnlyBlocklistPermission(t *testing.T) {
+	setupTestDB(t)
+
+	if err := services.SaveRole("blocklist-editor", "", []string{services.PermBlocklistManage}, true); err != nil {
+		t.Fatal(err)
+	}
+	r := setupRouter()
+	editor := testAdminToken(t, "editor", "blocklist-editor")
+
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/api/admin/settings", "", http.StatusForbidden},
+		{http.MethodGet, "/api/admin/email-blocklist", "", http.StatusOK},
+		{http.MethodPost, "/api/admin/email-blocklist", `{"domain": "spam.example"}`, http.StatusOK},
+		{http.MethodDelete, "/api/admin/email-blocklist/spam.example", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "Bearer "+editor)
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("blocklist editor %s %s: status %d, want %d (%s)", tt.method, tt.path, w.Code, tt.want, w.Body.String())
+		}
+	}
+}
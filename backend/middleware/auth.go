@@ -6,67 +6,142 @@ import (
 	"strings"
 
 	"invite-backend/config"
+	"invite-backend/database"
+	"invite-backend/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// SessionStore 简单的内存会话存储
-var SessionStore = make(map[string]bool)
+// ChangePasswordPath 强制修改密码期间唯一允许访问的路由
+const ChangePasswordPath = "/api/admin/change-password"
+
+// twoFactorSetupPaths 超级管理员被要求启用两步验证时允许访问的路由
+var twoFactorSetupPaths = map[string]bool{
+	"/api/admin/2fa/status": true,
+	"/api/admin/2fa/setup":  true,
+	"/api/admin/2fa/enable": true,
+}
 
 // AuthMiddleware 管理员认证中间件
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 1. 尝试从 Authorization Header 获取 Token
-		authHeader := c.GetHeader("Authorization")
-		if authHeader != "" {
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) == 2 && parts[0] == "Bearer" {
-				tokenString := parts[1]
-				token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-					if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-						return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-					}
-					return []byte(config.AppConfig.JWTSecret), nil
-				})
-
-				if err == nil && token.Valid {
-					if claims, ok := token.Claims.(jwt.MapClaims); ok {
-						c.Set("admin_id", int(claims["id"].(float64)))
-						c.Set("admin_username", claims["username"].(string))
-						c.Set("admin_role", claims["role"].(string))
-						c.Next()
-						return
-					}
-				}
-			}
+		claims, ok := parseAdminToken(c.GetHeader("Authorization"))
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "请登录后操作"})
+			c.Abort()
+			return
 		}
 
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "请登录后操作"})
-		c.Abort()
-	}
-}
+		adminID := int(claims["id"].(float64))
+		jti, _ := claims["jti"].(string)
+		tokenVersion, _ := claims["ver"].(float64)
 
-// RoleMiddleware 角色权限中间件
-func RoleMiddleware(roles ...string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		adminRole, exists := c.Get("admin_role")
-		if !exists {
+		// 会话已吊销（登出、修改密码、角色变更、账号删除等）时令牌失效
+		if !services.ValidateAdminSession(adminID, jti, int(tokenVersion)) {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "登录已失效，请重新登录"})
+			c.Abort()
+			return
+		}
+
+		var role string
+		var mustChangePassword, totpEnabled bool
+		err := database.DB.QueryRow(
+			"SELECT role, must_change_password, totp_enabled FROM admins WHERE id = ?", adminID,
+		).Scan(&role, &mustChangePassword, &totpEnabled)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "请登录后操作"})
 			c.Abort()
 			return
 		}
 
-		roleStr := adminRole.(string)
-		allowed := false
-		for _, role := range roles {
-			if roleStr == role {
-				allowed = true
-				break
+		// 需要修改密码时仅放行修改密码接口
+		if mustChangePassword && c.FullPath() != ChangePasswordPath {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "请先修改初始密码", "mustChangePassword": true})
+			c.Abort()
+			return
+		}
+
+		// 系统要求超级管理员启用两步验证时，未启用前仅放行两步验证设置接口
+		if role == "super" && !totpEnabled && !twoFactorSetupPaths[c.FullPath()] && c.FullPath() != ChangePasswordPath {
+			var require2FA string
+			database.DB.QueryRow("SELECT value FROM settings WHERE key = 'require_2fa_for_super'").Scan(&require2FA)
+			if require2FA == "true" {
+				c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "请先启用两步验证", "require2FA": true})
+				c.Abort()
+				return
 			}
 		}
 
-		if !allowed {
+		permissions, err := services.GetRolePermissions(role)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "系统错误"})
+			c.Abort()
+			return
+		}
+
+		c.Set("admin_id", adminID)
+		c.Set("admin_username", claims["username"])
+		c.Set("admin_role", role)
+		c.Set("admin_permissions", permissions)
+		c.Set("admin_jti", jti)
+		c.Set("must_change_password", mustChangePassword)
+		c.Next()
+	}
+}
+
+// ParseAdminToken 解析 Authorization 头中的访问令牌，返回管理员 ID 与令牌 ID
+func ParseAdminToken(authHeader string) (adminID int, jti string, ok bool) {
+	claims, ok := parseAdminToken(authHeader)
+	if !ok {
+		return 0, "", false
+	}
+	jti, _ = claims["jti"].(string)
+	return int(claims["id"].(float64)), jti, true
+}
+
+// parseAdminToken 解析 Authorization 头中的访问令牌
+// 两步验证挑战令牌等带有 purpose 的令牌不能用于访问接口
+func parseAdminToken(authHeader string) (jwt.MapClaims, bool) {
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, false
+	}
+
+	token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(config.AppConfig.JWTSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, false
+	}
+	if _, hasPurpose := claims["purpose"]; hasPurpose {
+		return nil, false
+	}
+	if _, ok := claims["id"].(float64); !ok {
+		return nil, false
+	}
+	if _, ok := claims["username"].(string); !ok {
+		return nil, false
+	}
+	if _, ok := claims["role"].(string); !ok {
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// RequirePermission 权限中间件，要求当前管理员的角色拥有指定权限
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, permission) {
 			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "权限不足，无法访问该页面"})
 			c.Abort()
 			return
@@ -75,3 +150,28 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireAnyPermission 权限中间件，要求当前管理员的角色拥有任一指定权限
+func RequireAnyPermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, p := range permissions {
+			if HasPermission(c, p) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "权限不足，无法访问该页面"})
+		c.Abort()
+	}
+}
+
+// HasPermission 判断当前管理员是否拥有指定权限
+func HasPermission(c *gin.Context, permission string) bool {
+	value, exists := c.Get("admin_permissions")
+	if !exists {
+		return false
+	}
+	permissions, _ := value.(map[string]bool)
+	return permissions[permission]
+}
@@ -7,7 +7,7 @@ type Application struct {
 	ID            int       `json:"id" db:"id"`
 	Email         string    `json:"email" db:"email"`
 	Reason        string    `json:"reason" db:"reason"`
-	Status        string    `json:"status" db:"status"` // pending, approved, rejected
+	Status        string    `json:"status" db:"status"` // pending, pending_second_review, approved, rejected
 	DeviceID      string    `json:"deviceId" db:"device_id"`
 	IP            string    `json:"ip" db:"ip"`
 	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
@@ -16,6 +16,38 @@ type Application struct {
 	ReviewOpinion string    `json:"reviewOpinion" db:"review_opinion"`
 	ProcessedBy   *int      `json:"processedBy" db:"processed_by"`
 	AdminUsername string    `json:"adminUsername" db:"admin_username"`
+	// 认领锁（仅在锁定期内返回）
+	LockedBy         *int       `json:"lockedBy" db:"locked_by"`
+	LockedByUsername string     `json:"lockedByUsername" db:"locked_by_username"`
+	LockedUntil      *time.Time `json:"lockedUntil" db:"locked_until"`
+	// 自动分配的审核员
+	AssignedTo         *int       `json:"assignedTo" db:"assigned_to"`
+	AssignedToUsername string     `json:"assignedToUsername" db:"assigned_to_username"`
+	AssignedAt         *time.Time `json:"assignedAt" db:"assigned_at"`
+	// 审核时效：待审核申请为已等待时长，已处理申请为从提交到处理的时长
+	AgeSeconds int64 `json:"ageSeconds" db:"-"`
+	Overdue    bool  `json:"overdue" db:"-"`
+	// 提交时的风险评分
+	RiskScore   int       `json:"riskScore" db:"risk_score"`
+	RiskReasons []RiskHit `json:"riskReasons" db:"risk_reasons"`
+	RiskFlagged bool      `json:"riskFlagged" db:"risk_flagged"`
+	// 理由近似重复的更早申请
+	Similar []SimilarApplication `json:"similar" db:"-"`
+}
+
+// SimilarApplication 申请理由相似的历史申请
+type SimilarApplication struct {
+	ID         int     `json:"id"`
+	Email      string  `json:"email"`
+	Status     string  `json:"status"`
+	Similarity float64 `json:"similarity"` // 理由 3-gram 的 Jaccard 相似度，0-1
+}
+
+// RiskHit 申请提交时命中的风控规则
+type RiskHit struct {
+	Rule   string `json:"rule"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
 }
 
 // VerificationCode 验证码
@@ -29,11 +61,16 @@ type VerificationCode struct {
 
 // InvitationCode 邀请码
 type InvitationCode struct {
-	ID            int       `json:"id" db:"id"`
-	Code          string    `json:"code" db:"code"`
-	IsUsed        bool      `json:"isUsed" db:"is_used"`
-	ApplicationID *int      `json:"applicationId" db:"application_id"`
-	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+	ID            int        `json:"id" db:"id"`
+	Code          string     `json:"code" db:"code"`
+	IsUsed        bool       `json:"isUsed" db:"is_used"`
+	Status        string     `json:"status" db:"status"` // available, assigned, redeemed, revoked, expired
+	ApplicationID *int       `json:"applicationId" db:"application_id"`
+	AssignedAt    *time.Time `json:"assignedAt" db:"assigned_at"`
+	RedeemedAt    *time.Time `json:"redeemedAt" db:"redeemed_at"`
+	RevokedAt     *time.Time `json:"revokedAt" db:"revoked_at"`
+	ExpiresAt     *time.Time `json:"expiresAt" db:"expires_at"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
 }
 
 // Setting 系统设置
@@ -47,13 +84,20 @@ type Setting struct {
 
 // Admin 管理员账号
 type Admin struct {
-	ID           int       `json:"id" db:"id"`
-	Username     string    `json:"username" db:"username"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	Role         string    `json:"role" db:"role"` // super, reviewer
-	LinuxDoID    string    `json:"linuxdoId" db:"linuxdo_id"`
-	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+	ID           int    `json:"id" db:"id"`
+	Username     string `json:"username" db:"username"`
+	PasswordHash string `json:"-" db:"password_hash"`
+	Role         string `json:"role" db:"role"` // super, reviewer
+	LinuxDoID    string `json:"linuxdoId" db:"linuxdo_id"`
+	Email        string `json:"email" db:"email"`
+	// 是否需要在下次登录后修改密码
+	MustChangePassword bool `json:"mustChangePassword" db:"must_change_password"`
+	TOTPEnabled        bool `json:"totpEnabled" db:"totp_enabled"`
+	// 是否接收自动分配及当前待处理的分配数量
+	Available bool      `json:"available" db:"available"`
+	OpenQueue int       `json:"openQueue" db:"-"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
 }
 
 // SystemSettings 系统配置集合
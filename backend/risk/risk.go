@@ -0,0 +1,172 @@
+// Package risk 提供申请提交时的风险评分：每条规则给出风险分与原因，
+// 由策略根据总分决定自动拒绝、标记人工复核或正常受理
+package risk
+
+import (
+	"sort"
+	"strconv"
+)
+
+// 策略默认阈值
+const (
+	defaultFlagScore   = 40
+	defaultRejectScore = 100
+)
+
+// Submission 待评估的申请
+type Submission struct {
+	Email    string
+	Reason   string
+	DeviceID string
+	IP       string
+	Settings map[string]string // 系统设置，规则从中读取各自的上限
+}
+
+// RiskRule 风控规则，未命中时返回 0 分
+type RiskRule interface {
+	Name() string
+	Evaluate(s Submission) (score int, reason string)
+}
+
+// Hit 命中的规则
+type Hit struct {
+	Rule   string `json:"rule"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// Decision 策略对申请的处理决定
+type Decision string
+
+const (
+	DecisionAccept Decision = "accept" // 正常受理
+	DecisionFlag   Decision = "flag"   // 受理并标记人工复核
+	DecisionReject Decision = "reject" // 自动拒绝，不创建申请
+)
+
+// Policy 风险分阈值
+type Policy struct {
+	FlagScore   int  // 总分达到该值时标记人工复核，0 表示不标记
+	RejectScore int  // 总分达到该值时自动拒绝，0 表示不自动拒绝
+	AutoReject  bool // 关闭风控时仅评分与标记，不自动拒绝
+}
+
+// Result 评估结果，命中的规则按分数从高到低排列
+type Result struct {
+	Score    int      `json:"score"`
+	Hits     []Hit    `json:"hits"`
+	Decision Decision `json:"decision"`
+}
+
+// TopReason 返回分数最高的命中原因
+func (r Result) TopReason() string {
+	if len(r.Hits) == 0 {
+		return ""
+	}
+	return r.Hits[0].Reason
+}
+
+// PolicyFromSettings 从系统设置读取策略
+func PolicyFromSettings(settings map[string]string) Policy {
+	return Policy{
+		FlagScore:   settingInt(settings, "risk_flag_score", defaultFlagScore),
+		RejectScore: settingInt(settings, "risk_reject_score", defaultRejectScore),
+		AutoReject:  settings["risk_control_enabled"] == "true",
+	}
+}
+
+// Decide 根据总分给出处理决定
+func (p Policy) Decide(score int) Decision {
+	switch {
+	case p.AutoReject && p.RejectScore > 0 && score >= p.RejectScore:
+		return DecisionReject
+	case p.FlagScore > 0 && score >= p.FlagScore:
+		return DecisionFlag
+	default:
+		return DecisionAccept
+	}
+}
+
+// Engine 依次执行规则并汇总风险分
+type Engine struct {
+	Rules  []RiskRule
+	Policy Policy
+}
+
+// NewEngine 使用指定策略与规则创建评估引擎
+func NewEngine(policy Policy, rules ...RiskRule) *Engine {
+	return &Engine{Rules: rules, Policy: policy}
+}
+
+// Evaluate 评估申请
+func (e *Engine) Evaluate(s Submission) Result {
+	result := Result{Hits: make([]Hit, 0)}
+	for _, rule := range e.Rules {
+		score, reason := rule.Evaluate(s)
+		if score <= 0 {
+			continue
+		}
+		result.Score += score
+		result.Hits = append(result.Hits, Hit{Rule: rule.Name(), Score: score, Reason: reason})
+	}
+	sort.SliceStable(result.Hits, func(i, j int) bool { return result.Hits[i].Score > result.Hits[j].Score })
+	result.Decision = e.Policy.Decide(result.Score)
+	return result
+}
+
+// DefaultRules 内置规则
+func DefaultRules() []RiskRule {
+	return []RiskRule{
+		EmailCountRule{},
+		DeviceCountRule{},
+		IPCountRule{},
+		DisposableDomainRule{},
+		ReasonLengthRule{},
+		DuplicateReasonRule{},
+		VelocityRule{},
+	}
+}
+
+// Evaluate 使用内置规则与系统设置中的策略评估申请
+func Evaluate(s Submission) Result {
+	return NewEngine(PolicyFromSettings(s.Settings), DefaultRules()...).Evaluate(s)
+}
+
+// ReasonRules 只依赖申请理由的内置规则
+func ReasonRules() []RiskRule {
+	return []RiskRule{
+		ReasonLengthRule{},
+		DuplicateReasonRule{},
+	}
+}
+
+// ReevaluateReason 申请理由修改后重新评分：理由相关规则重新执行，其余规则沿用提交时的命中结果
+// （按次数统计的规则此时会把申请本身计入，不能重跑）。申请已创建，达到拒绝阈值时只标记人工复核
+func ReevaluateReason(s Submission, previous []Hit) Result {
+	rules := ReasonRules()
+	reasonRules := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		reasonRules[rule.Name()] = true
+	}
+
+	policy := PolicyFromSettings(s.Settings)
+	policy.AutoReject = false
+	result := NewEngine(policy, rules...).Evaluate(s)
+	for _, hit := range previous {
+		if !reasonRules[hit.Rule] {
+			result.Score += hit.Score
+			result.Hits = append(result.Hits, hit)
+		}
+	}
+	sort.SliceStable(result.Hits, func(i, j int) bool { return result.Hits[i].Score > result.Hits[j].Score })
+	result.Decision = policy.Decide(result.Score)
+	return result
+}
+
+func settingInt(settings map[string]string, key string, fallback int) int {
+	value, err := strconv.Atoi(settings[key])
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
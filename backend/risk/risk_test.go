@@ -0,0 +1,126 @@
+package risk
+
+import (
+	"strings"
+	"testing"
+)
+
+type fixedRule struct {
+	name   string
+	score  int
+	reason string
+}
+
+func (r fixedRule) Name() string { return r.name }
+
+func (r fixedRule) Evaluate(s Submission) (int, string) { return r.score, r.reason }
+
+func TestPolicyDecide(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		score  int
+		want   Decision
+	}{
+		{"below flag", Policy{FlagScore: 40, RejectScore: 100, AutoReject: true}, 39, DecisionAccept},
+		{"at flag", Policy{FlagScore: 40, RejectScore: 100, AutoReject: true}, 40, DecisionFlag},
+		{"at reject", Policy{FlagScore: 40, RejectScore: 100, AutoReject: true}, 100, DecisionReject},
+		{"reject disabled by switch", Policy{FlagScore: 40, RejectScore: 100}, 150, DecisionFlag},
+		{"reject threshold zero", Policy{FlagScore: 40, AutoReject: true}, 150, DecisionFlag},
+		{"flag threshold zero", Policy{RejectScore: 100, AutoReject: true}, 60, DecisionAccept},
+		{"everything off", Policy{}, 1000, DecisionAccept},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Decide(tt.score); got != tt.want {
+				t.Errorf("Decide(%d) = %q, want %q", tt.score, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyFromSettings(t *testing.T) {
+	policy := PolicyFromSettings(map[string]string{"risk_flag_score": "abc", "risk_reject_score": "-5"})
+	if policy != (Policy{FlagScore: defaultFlagScore, RejectScore: defaultRejectScore}) {
+		t.Errorf("invalid settings should fall back to defaults, got %+v", policy)
+	}
+
+	policy = PolicyFromSettings(map[string]string{"risk_flag_score": "0", "risk_reject_score": "80", "risk_control_enabled": "true"})
+	if policy != (Policy{FlagScore: 0, RejectScore: 80, AutoReject: true}) {
+		t.Errorf("got %+v", policy)
+	}
+}
+
+func TestEngineCumulativeScore(t *testing.T) {
+	engine := NewEngine(
+		Policy{FlagScore: 40, RejectScore: 100, AutoReject: true},
+		fixedRule{"a", 15, "a"},
+		fixedRule{"b", 0, ""},
+		fixedRule{"c", 30, "c"},
+		fixedRule{"d", 15, "d"},
+	)
+
+	result := engine.Evaluate(Submission{})
+	if result.Score != 60 {
+		t.Errorf("score = %d, want 60", result.Score)
+	}
+	if result.Decision != DecisionFlag {
+		t.Errorf("decision = %q, want %q", result.Decision, DecisionFlag)
+	}
+	// 未命中的规则不记录，其余按分数从高到低、同分保持规则顺序
+	var order []string
+	for _, hit := range result.Hits {
+		order = append(order, hit.Rule)
+	}
+	if strings.Join(order, ",") != "c,a,d" {
+		t.Errorf("hit order = %v, want [c a d]", order)
+	}
+	if result.TopReason() != "c" {
+		t.Errorf("TopReason() = %q, want %q", result.TopReason(), "c")
+	}
+
+	// 多条规则单独都不足以拒绝，累计后达到拒绝阈值
+	engine.Rules = append(engine.Rules, fixedRule{"e", 40, "e"})
+	if result := engine.Evaluate(Submission{}); result.Score != 100 || result.Decision != DecisionReject {
+		t.Errorf("result = %+v, want score 100 and reject", result)
+	}
+}
+
+func TestEngineNoHits(t *testing.T) {
+	result := NewEngine(Policy{FlagScore: 40}).Evaluate(Submission{})
+	if result.Score != 0 || result.Decision != DecisionAccept || result.Hits == nil || result.TopReason() != "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestReevaluateReason(t *testing.T) {
+	settings := map[string]string{
+		"risk_control_enabled":        "true",
+		"risk_flag_score":             "40",
+		"risk_reject_score":           "100",
+		"reason_similarity_threshold": "0",
+	}
+	previous := []Hit{
+		{Rule: "ip_count", Score: 90, Reason: "该 IP 已有 6 次提交"},
+		{Rule: "reason_length", Score: 15, Reason: "申请理由偏短（60 字）"},
+	}
+
+	// 理由改为大量重复字符：理由规则重新计分，其余命中沿用，达到拒绝阈值也只标记
+	result := ReevaluateReason(Submission{Reason: strings.Repeat("好", 60), Settings: settings}, previous)
+	if result.Score != 130 {
+		t.Errorf("score = %d, want 130", result.Score)
+	}
+	if result.Decision != DecisionFlag {
+		t.Errorf("decision = %q, want %q", result.Decision, DecisionFlag)
+	}
+	if len(result.Hits) != 2 || result.Hits[0].Rule != "ip_count" || result.Hits[1].Rule != "reason_length" || result.Hits[1].Score != repetitiveScore {
+		t.Errorf("hits = %+v", result.Hits)
+	}
+
+	// 理由改为足够长且正常：旧的理由命中被移除
+	result = ReevaluateReason(Submission{Reason: strings.Repeat("abcdefghijklmnopqrstuvwxyz0123456789", 3), Settings: settings}, previous)
+	if result.Score != 90 || len(result.Hits) != 1 || result.Hits[0].Rule != "ip_count" {
+		t.Errorf("result = %+v", result)
+	}
+}
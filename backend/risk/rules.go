@@ -0,0 +1,180 @@
+package risk
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"invite-backend/database"
+	"invite-backend/services"
+)
+
+// 内置规则的分值
+const (
+	blockingScore       = 100 // 超过硬性上限，达到默认的自动拒绝阈值
+	priorIPScore        = 15  // 同一 IP 每有一次历史提交
+	sharedDeviceScore   = 20  // 同一设备曾使用其他邮箱提交
+	disposableScore     = 60
+	shortReasonScore    = 15
+	repetitiveScore     = 40
+	duplicateScore      = 60
+	velocityScore       = 40
+	shortReasonRunes    = 80        // 理由少于该字数视为偏短（提交下限为 50 字）
+	minDistinctRatio    = 0.3       // 不同字符占比低于该值视为大量重复字符
+	velocityWindow      = time.Hour // 提交频率统计窗口
+	velocityMaxInWindow = 2         // 窗口内同一 IP/设备已有提交数达到该值即命中
+	maxDeviceSubmission = 3         // 每个设备最多提交次数（统计所有状态）
+	defaultMaxPerIP     = 3         // 单 IP 提交上限默认值
+	defaultMaxApproved  = 1         // 单邮箱/设备通过上限默认值
+)
+
+// EmailCountRule 单邮箱通过次数上限
+type EmailCountRule struct{}
+
+func (EmailCountRule) Name() string { return "email_count" }
+
+func (EmailCountRule) Evaluate(s Submission) (int, string) {
+	maxEmail := limitSetting(s.Settings, "max_applications_per_email", defaultMaxApproved)
+	var approved int
+	database.DB.QueryRow(
+		"SELECT COUNT(*) FROM applications WHERE email = ? AND status = 'approved'", s.Email,
+	).Scan(&approved)
+	if approved >= maxEmail {
+		return blockingScore, "该邮箱已成功申请过邀请码"
+	}
+	return 0, ""
+}
+
+// DeviceCountRule 单设备通过次数与提交次数上限，以及同一设备更换邮箱提交
+type DeviceCountRule struct{}
+
+func (DeviceCountRule) Name() string { return "device_count" }
+
+func (DeviceCountRule) Evaluate(s Submission) (int, string) {
+	maxDevice := limitSetting(s.Settings, "max_applications_per_device", defaultMaxApproved)
+	var approved, total, otherEmails int
+	database.DB.QueryRow(
+		"SELECT COUNT(*) FROM applications WHERE device_id = ? AND status = 'approved'", s.DeviceID,
+	).Scan(&approved)
+	if approved >= maxDevice {
+		return blockingScore, "该设备已成功申请过邀请码"
+	}
+
+	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE device_id = ?", s.DeviceID).Scan(&total)
+	if total >= maxDeviceSubmission {
+		return blockingScore, "该设备提交次数过多，请勿重复操作"
+	}
+
+	database.DB.QueryRow(
+		"SELECT COUNT(DISTINCT email) FROM applications WHERE device_id = ? AND email != ?", s.DeviceID, s.Email,
+	).Scan(&otherEmails)
+	if otherEmails > 0 {
+		return sharedDeviceScore * otherEmails, fmt.Sprintf("该设备曾使用 %d 个其他邮箱提交申请", otherEmails)
+	}
+	return 0, ""
+}
+
+// IPCountRule 单 IP 提交次数上限，未达上限时按历史提交次数计分
+type IPCountRule struct{}
+
+func (IPCountRule) Name() string { return "ip_count" }
+
+func (IPCountRule) Evaluate(s Submission) (int, string) {
+	maxIP := limitSetting(s.Settings, "max_applications_per_ip", defaultMaxPerIP)
+	var total int
+	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE ip = ?", s.IP).Scan(&total)
+	switch {
+	case total >= maxIP:
+		return blockingScore, "该 IP 提交次数过多，请联系管理员"
+	case total > 0:
+		return priorIPScore * total, fmt.Sprintf("该 IP 已有 %d 次提交", total)
+	}
+	return 0, ""
+}
+
+// DisposableDomainRule 使用一次性邮箱黑名单中的域名（关闭黑名单拦截时仍参与评分）
+type DisposableDomainRule struct{}
+
+func (DisposableDomainRule) Name() string { return "disposable_domain" }
+
+func (DisposableDomainRule) Evaluate(s Submission) (int, string) {
+	at := strings.LastIndex(s.Email, "@")
+	if at < 0 {
+		return 0, ""
+	}
+	if matched, ok := services.MatchBlockedDomain(s.Email[at+1:]); ok {
+		return disposableScore, "使用一次性邮箱域名（命中 " + matched + "）"
+	}
+	return 0, ""
+}
+
+// ReasonLengthRule 申请理由偏短或大量重复字符
+type ReasonLengthRule struct{}
+
+func (ReasonLengthRule) Name() string { return "reason_length" }
+
+func (ReasonLengthRule) Evaluate(s Submission) (int, string) {
+	runes := []rune(strings.Join(strings.Fields(s.Reason), ""))
+	if len(runes) == 0 {
+		return 0, ""
+	}
+	distinct := make(map[rune]bool)
+	for _, r := range runes {
+		distinct[r] = true
+	}
+	if float64(len(distinct))/float64(len(runes)) < minDistinctRatio {
+		return repetitiveScore, fmt.Sprintf("申请理由重复字符过多（%d 字中仅 %d 个不同字符）", len(runes), len(distinct))
+	}
+	if len(runes) < shortReasonRunes {
+		return shortReasonScore, fmt.Sprintf("申请理由偏短（%d 字）", len(runes))
+	}
+	return 0, ""
+}
+
+// DuplicateReasonRule 申请理由与其他邮箱的申请近似重复（相似度达到 reason_similarity_threshold），
+// 命中时的分值不低于标记阈值，确保申请被标记人工复核
+type DuplicateReasonRule struct{}
+
+func (DuplicateReasonRule) Name() string { return "duplicate_reason" }
+
+func (DuplicateReasonRule) Evaluate(s Submission) (int, string) {
+	threshold := services.SimilarityFlagThreshold(s.Settings)
+	if threshold == 0 {
+		return 0, ""
+	}
+	similar, err := services.FindSimilarReasons(s.Reason, s.Email, 0, 1)
+	if err != nil || len(similar) == 0 || similar[0].Similarity < threshold {
+		return 0, ""
+	}
+
+	score := duplicateScore
+	if flag := settingInt(s.Settings, "risk_flag_score", defaultFlagScore); flag > score {
+		score = flag
+	}
+	return score, fmt.Sprintf("申请理由与申请 #%d 的相似度为 %.0f%%", similar[0].ID, similar[0].Similarity*100)
+}
+
+// VelocityRule 同一 IP 或设备短时间内连续提交
+type VelocityRule struct{}
+
+func (VelocityRule) Name() string { return "velocity" }
+
+func (VelocityRule) Evaluate(s Submission) (int, string) {
+	var recent int
+	database.DB.QueryRow(
+		"SELECT COUNT(*) FROM applications WHERE (ip = ? OR device_id = ?) AND created_at >= ?",
+		s.IP, s.DeviceID, time.Now().Add(-velocityWindow).Unix(),
+	).Scan(&recent)
+	if recent >= velocityMaxInWindow {
+		return velocityScore, fmt.Sprintf("同一 IP 或设备最近 1 小时内已提交 %d 次", recent)
+	}
+	return 0, ""
+}
+
+// limitSetting 读取上限类设置，未设置或为 0 时使用默认值
+func limitSetting(settings map[string]string, key string, fallback int) int {
+	if value := settingInt(settings, key, fallback); value > 0 {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,44 @@
+package risk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReasonLengthRule(t *testing.T) {
+	varied := "abcdefghijklmnopqrstuvwxyz0123456789"
+	tests := []struct {
+		name   string
+		reason string
+		want   int
+	}{
+		{"empty", "", 0},
+		{"only whitespace", " \n\t ", 0},
+		{"short", varied, shortReasonScore},
+		{"long enough", strings.Repeat(varied, 3), 0},
+		{"whitespace not counted", strings.Join(strings.Split(varied+varied, ""), " "), shortReasonScore},
+		{"repetitive", strings.Repeat("好", 100), repetitiveScore},
+		{"repetitive and short", strings.Repeat("ab", 20), repetitiveScore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reason := ReasonLengthRule{}.Evaluate(Submission{Reason: tt.reason})
+			if score != tt.want {
+				t.Errorf("score = %d (%q), want %d", score, reason, tt.want)
+			}
+			if (score == 0) != (reason == "") {
+				t.Errorf("score %d with reason %q", score, reason)
+			}
+		})
+	}
+}
+
+func TestLimitSetting(t *testing.T) {
+	settings := map[string]string{"zero": "0", "five": "5", "bad": "x"}
+	for key, want := range map[string]int{"zero": 3, "five": 5, "bad": 3, "missing": 3} {
+		if got := limitSetting(settings, key, 3); got != want {
+			t.Errorf("limitSetting(%q) = %d, want %d", key, got, want)
+		}
+	}
+}
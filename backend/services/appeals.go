@@ -0,0 +1,288 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"invite-backend/config"
+	"invite-backend/database"
+)
+
+// 申诉状态
+const (
+	AppealPending    = "pending"
+	AppealOverturned = "overturned" // 改判通过
+	AppealUpheld     = "upheld"     // 维持拒绝
+)
+
+// 申诉错误
+var (
+	ErrAppealInvalidLink = errors.New("appeal link invalid")
+	ErrAppealNotAllowed  = errors.New("application is not rejected")
+	ErrAppealExists      = errors.New("appeal already submitted")
+	ErrAppealNotFound    = errors.New("appeal not found")
+	ErrAppealDecided     = errors.New("appeal already decided")
+)
+
+// Appeal 申请人对拒绝结果的申诉
+type Appeal struct {
+	ID               int        `json:"id"`
+	ApplicationID    int        `json:"applicationId"`
+	Email            string     `json:"email"`
+	Content          string     `json:"content"`
+	Status           string     `json:"status"`
+	ReviewerUsername string     `json:"reviewerUsername"`
+	DecisionOpinion  string     `json:"decisionOpinion"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	DecidedAt        *time.Time `json:"decidedAt"`
+	// 原申请信息，便于审核员对照
+	Reason        string `json:"reason"`
+	ReviewOpinion string `json:"reviewOpinion"`
+	RejectedBy    string `json:"rejectedBy"`
+}
+
+// AppealToken 生成申诉链接签名，绑定申请 ID 与邮箱
+func AppealToken(appID int, email string) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.JWTSecret))
+	fmt.Fprintf(mac, "appeal:%d:%s", appID, email)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AppealURL 生成拒绝邮件中的申诉链接，未配置站点地址时返回空字符串
+func AppealURL(appID int, email string) string {
+	siteURL := SiteURL()
+	if siteURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/appeal?id=%d&token=%s", siteURL, appID, url.QueryEscape(AppealToken(appID, email)))
+}
+
+// verifyAppealLink 校验申诉链接，返回申请邮箱与当前状态
+func verifyAppealLink(appID int, token string) (email, status string, err error) {
+	if err := database.DB.QueryRow("SELECT email, status FROM applications WHERE id = ?", appID).Scan(&email, &status); err != nil {
+		return "", "", ErrAppealInvalidLink
+	}
+	if !hmac.Equal([]byte(token), []byte(AppealToken(appID, email))) {
+		return "", "", ErrAppealInvalidLink
+	}
+	return email, status, nil
+}
+
+// GetApplicantAppeal 通过申诉链接查询申请状态与已提交的申诉
+func GetApplicantAppeal(appID int, token string) (status string, appeal *Appeal, err error) {
+	_, status, err = verifyAppealLink(appID, token)
+	if err != nil {
+		return "", nil, err
+	}
+
+	a, err := getAppealByApplication(appID)
+	if err == ErrAppealNotFound {
+		return status, nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return status, &a, nil
+}
+
+// SubmitAppeal 提交申诉，每个被拒绝的申请只能申诉一次
+func SubmitAppeal(appID int, token, content string) (string, error) {
+	email, status, err := verifyAppealLink(appID, token)
+	if err != nil {
+		return "", err
+	}
+	if status != "rejected" {
+		return "", ErrAppealNotAllowed
+	}
+
+	_, err = database.DB.Exec(
+		"INSERT INTO appeals (application_id, email, content, status, created_at) VALUES (?, ?, ?, ?, ?)",
+		appID, email, content, AppealPending, time.Now().Unix(),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return "", ErrAppealExists
+		}
+		return "", err
+	}
+	return email, nil
+}
+
+const appealSelect = `
+	SELECT ap.id, ap.application_id, ap.email, ap.content, ap.status, ap.reviewer_username, ap.decision_opinion,
+		ap.created_at, ap.decided_at, a.reason, a.review_opinion, ad.username
+	FROM appeals ap
+	JOIN applications a ON a.id = ap.application_id
+	LEFT JOIN admins ad ON ad.id = a.processed_by`
+
+func scanAppeal(row interface{ Scan(...interface{}) error }) (Appeal, error) {
+	var a Appeal
+	var reviewer, decisionOpinion, reviewOpinion, rejectedBy sql.NullString
+	var createdAt int64
+	var decidedAt sql.NullInt64
+	if err := row.Scan(
+		&a.ID, &a.ApplicationID, &a.Email, &a.Content, &a.Status, &reviewer, &decisionOpinion,
+		&createdAt, &decidedAt, &a.Reason, &reviewOpinion, &rejectedBy,
+	); err != nil {
+		return a, err
+	}
+	a.ReviewerUsername = reviewer.String
+	a.DecisionOpinion = decisionOpinion.String
+	a.ReviewOpinion = reviewOpinion.String
+	a.RejectedBy = rejectedBy.String
+	a.CreatedAt = time.Unix(createdAt, 0)
+	if decidedAt.Valid {
+		t := time.Unix(decidedAt.Int64, 0)
+		a.DecidedAt = &t
+	}
+	return a, nil
+}
+
+func getAppealByApplication(appID int) (Appeal, error) {
+	a, err := scanAppeal(database.DB.QueryRow(appealSelect+" WHERE ap.application_id = ?", appID))
+	if err == sql.ErrNoRows {
+		return a, ErrAppealNotFound
+	}
+	return a, err
+}
+
+// ListAppeals 获取申诉队列，status 为空时返回全部
+func ListAppeals(status string) ([]Appeal, error) {
+	query := appealSelect
+	var args []interface{}
+	if status != "" {
+		query += " WHERE ap.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY ap.created_at ASC, ap.id ASC LIMIT 200"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appeals := make([]Appeal, 0)
+	for rows.Next() {
+		a, err := scanAppeal(rows)
+		if err != nil {
+			continue
+		}
+		appeals = append(appeals, a)
+	}
+	return appeals, nil
+}
+
+// DecideAppeal 处理申诉：改判时将申请退回待审核，由调用方按正常审核流程批准（开启双人审核时进入待复核），
+// 批准失败时调用方需通过 RevertAppealOverturn 撤销改判；维持时仅记录结果并发送结果邮件。申请被他人认领中时返回 ErrApplicationLocked
+func DecideAppeal(appealID, adminID int, adminUsername string, overturn bool, opinion string) (Appeal, error) {
+	a, err := scanAppeal(database.DB.QueryRow(appealSelect+" WHERE ap.id = ?", appealID))
+	if err == sql.ErrNoRows {
+		return a, ErrAppealNotFound
+	}
+	if err != nil {
+		return a, err
+	}
+	if a.Status != AppealPending {
+		return a, ErrAppealDecided
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return a, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	decision := AppealUpheld
+	if overturn {
+		decision = AppealOverturned
+	}
+	res, err := tx.Exec(`
+		UPDATE appeals SET status = ?, reviewer_id = ?, reviewer_username = ?, decision_opinion = ?, decided_at = ?
+		WHERE id = ? AND status = ?
+	`, decision, adminID, adminUsername, opinion, now, appealID, AppealPending)
+	if err != nil {
+		return a, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return a, ErrAppealDecided
+	}
+
+	if overturn {
+		// 退回待审核时沿用审核的认领规则：他人认领中的申请不能改判
+		res, err := tx.Exec(`
+			UPDATE applications SET status = 'pending', first_approved_by = NULL
+			WHERE id = ? AND status = 'rejected' AND (locked_by IS NULL OR locked_until <= ? OR locked_by = ?)
+		`, a.ApplicationID, now, adminID)
+		if err != nil {
+			return a, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			if _, held, _ := GetApplicationLock(a.ApplicationID); held {
+				return a, ErrApplicationLocked
+			}
+			return a, ErrAppealNotAllowed
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return a, err
+	}
+
+	if !overturn {
+		EnqueueAppealUpheldEmail(a.Email, opinion)
+	}
+
+	decidedAt := time.Unix(now, 0)
+	a.Status = decision
+	a.ReviewerUsername = adminUsername
+	a.DecisionOpinion = opinion
+	a.DecidedAt = &decidedAt
+	return a, nil
+}
+
+// RevertAppealOverturn 改判后批准失败时撤销改判：申请恢复为拒绝，申诉恢复为待处理
+// 申请已不在待审核状态（已被他人处理）时不再撤销，返回 false
+func RevertAppealOverturn(a Appeal) (bool, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE applications SET status = 'rejected' WHERE id = ? AND status = 'pending'", a.ApplicationID)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if _, err := tx.Exec(`
+		UPDATE appeals SET status = ?, reviewer_id = NULL, reviewer_username = NULL, decision_opinion = NULL, decided_at = NULL
+		WHERE id = ? AND status = ?
+	`, AppealPending, a.ID, AppealOverturned); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// AppealOverturnOpinion 申诉改判通过时发送给申请人的审核意见
+func AppealOverturnOpinion(opinion string) string {
+	return appealOutcomeOpinion("您的申诉已通过，申请已改为批准。", opinion)
+}
+
+func appealOutcomeOpinion(prefix, opinion string) string {
+	if opinion == "" {
+		return prefix
+	}
+	return prefix + "\n" + opinion
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"testing"
+
+	"invite-backend/database"
+)
+
+// insertRejectedApplication 插入一条已拒绝的申请并返回 ID
+func insertRejectedApplication(t *testing.T, email string) int {
+	t.Helper()
+	appID := insertTestApplication(t, email)
+	if _, err := database.DB.Exec("UPDATE applications SET status = 'rejected', processed_by = 1 WHERE id = ?", appID); err != nil {
+		t.Fatal(err)
+	}
+	return appID
+}
+
+func TestAppealLinkVerification(t *testing.T) {
+	setupTestDB(t)
+	appID := insertRejectedApplication(t, "a@example.com")
+	other := insertRejectedApplication(t, "b@example.com")
+	token := AppealToken(appID, "a@example.com")
+
+	if status, appeal, err := GetApplicantAppeal(appID, token); err != nil || status != "rejected" || appeal != nil {
+		t.Fatalf("valid link: %q, %v, %v", status, appeal, err)
+	}
+
+	tampered := []byte(token)
+	tampered[0] ^= 1
+	tests := []struct {
+		name  string
+		appID int
+		token string
+	}{
+		{"tampered token", appID, string(tampered)},
+		{"token for another email", appID, AppealToken(appID, "b@example.com")},
+		{"token for another application", other, token},
+		{"empty token", appID, ""},
+		{"missing application", 9999, AppealToken(9999, "a@example.com")},
+	}
+	for _, tt := range tests {
+		if _, _, err := GetApplicantAppeal(tt.appID, tt.token); err != ErrAppealInvalidLink {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, ErrAppealInvalidLink)
+		}
+		if _, err := SubmitAppeal(tt.appID, tt.token, "please reconsider"); err != ErrAppealInvalidLink {
+			t.Errorf("%s: submit err = %v, want %v", tt.name, err, ErrAppealInvalidLink)
+		}
+	}
+}
+
+func TestSubmitAppealOncePerApplication(t *testing.T) {
+	setupTestDB(t)
+	appID := insertRejectedApplication(t, "a@example.com")
+	token := AppealToken(appID, "a@example.com")
+
+	if email, err := SubmitAppeal(appID, token, "please reconsider"); err != nil || email != "a@example.com" {
+		t.Fatalf("first appeal: %q, %v", email, err)
+	}
+	if _, err := SubmitAppeal(appID, token, "please reconsider again"); err != ErrAppealExists {
+		t.Errorf("second appeal: err = %v, want %v", err, ErrAppealExists)
+	}
+
+	pending := insertTestApplication(t, "b@example.com")
+	if _, err := SubmitAppeal(pending, AppealToken(pending, "b@example.com"), "please reconsider"); err != ErrAppealNotAllowed {
+		t.Errorf("appeal on pending application: err = %v, want %v", err, ErrAppealNotAllowed)
+	}
+}
+
+func TestDecideAppealUphold(t *testing.T) {
+	setupTestDB(t)
+	appID := insertRejectedApplication(t, "a@example.com")
+	if _, err := SubmitAppeal(appID, AppealToken(appID, "a@example.com"), "please reconsider"); err != nil {
+		t.Fatal(err)
+	}
+	appeal, err := getAppealByApplication(appID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decided, err := DecideAppeal(appeal.ID, 1, "admin", false, "still no")
+	if err != nil || decided.Status != AppealUpheld || decided.ReviewerUsername != "admin" || decided.DecidedAt == nil {
+		t.Fatalf("uphold: %+v, %v", decided, err)
+	}
+	var status string
+	var queued int
+	database.DB.QueryRow("SELECT status FROM applications WHERE id = ?", appID).Scan(&status)
+	database.DB.QueryRow("SELECT COUNT(*) FROM email_queue WHERE kind = ? AND recipient = 'a@example.com'", EmailKindAppealUpheld).Scan(&queued)
+	if status != "rejected" || queued != 1 {
+		t.Errorf("after uphold: application %s, %d result emails queued", status, queued)
+	}
+
+	if _, err := DecideAppeal(appeal.ID, 1, "admin", true, ""); err != ErrAppealDecided {
+		t.Errorf("decide twice: err = %v, want %v", err, ErrAppealDecided)
+	}
+	if _, err := DecideAppeal(9999, 1, "admin", true, ""); err != ErrAppealNotFound {
+		t.Errorf("decide missing appeal: err = %v, want %v", err, ErrAppealNotFound)
+	}
+}
+
+func TestDecideAppealOverturnAndRevert(t *testing.T) {
+	setupTestDB(t)
+	appID := insertRejectedApplication(t, "a@example.com")
+	if _, err := SubmitAppeal(appID, AppealToken(appID, "a@example.com"), "please reconsider"); err != nil {
+		t.Fatal(err)
+	}
+	appeal, _ := getAppealByApplication(appID)
+
+	decided, err := DecideAppeal(appeal.ID, 1, "admin", true, "ok")
+	if err != nil || decided.Status != AppealOverturned {
+		t.Fatalf("overturn: %+v, %v", decided, err)
+	}
+	var status string
+	database.DB.QueryRow("SELECT status FROM applications WHERE id = ?", appID).Scan(&status)
+	if status != "pending" {
+		t.Errorf("application after overturn: %s, want pending", status)
+	}
+
+	if reverted, err := RevertAppealOverturn(decided); err != nil || !reverted {
+		t.Fatalf("revert: %v, %v", reverted, err)
+	}
+	database.DB.QueryRow("SELECT status FROM applications WHERE id = ?", appID).Scan(&status)
+	after, _ := getAppealByApplication(appID)
+	if status != "rejected" || after.Status != AppealPending || after.DecidedAt != nil || after.ReviewerUsername != "" {
+		t.Errorf("after revert: application %s, appeal %+v", status, after)
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"invite-backend/database"
+)
+
+// 申请人自助管理参数
+const (
+	manageLinkTTL      = 30 * time.Minute
+	manageLinkInterval = time.Minute // 同一申请两次发送管理链接的最小间隔
+)
+
+// 自助管理错误
+var (
+	ErrManageLinkInvalid     = errors.New("manage link invalid or expired")
+	ErrManageLinkTooFrequent = errors.New("manage link requested too frequently")
+	ErrApplicationNotPending = errors.New("application is not pending")
+	ErrSiteURLNotConfigured  = errors.New("site url not configured")
+)
+
+// ManagedApplication 申请人通过管理链接看到的申请信息
+type ManagedApplication struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"` // 管理链接过期时间
+	CanManage bool      `json:"canManage"` // 是否仍可撤回或修改，按屏蔽前的真实状态计算
+}
+
+// ApplicationEdit 申请人修改申请理由的记录
+type ApplicationEdit struct {
+	ID        int       `json:"id"`
+	OldReason string    `json:"oldReason"`
+	NewReason string    `json:"newReason"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SiteURL 获取系统设置中的站点地址，未配置时返回空字符串
+func SiteURL() string {
+	settings, _ := GetSystemSettings()
+	return strings.TrimRight(strings.TrimSpace(settings["site_url"]), "/")
+}
+
+// SendManageLink 为邮箱最近一条待审核申请生成一次性管理链接并发送邮件
+// 没有可管理的申请时返回 false，调用方不应向请求者透露该结果
+// 链接只使用系统设置中的站点地址，未配置时返回 ErrSiteURLNotConfigured，避免伪造 Host 请求头把有效令牌发往他人域名
+func SendManageLink(email string) (bool, error) {
+	baseURL := SiteURL()
+	if baseURL == "" {
+		return false, ErrSiteURLNotConfigured
+	}
+
+	var appID int
+	err := database.DB.QueryRow(
+		"SELECT id FROM applications WHERE email = ? AND status = 'pending' ORDER BY created_at DESC, id DESC LIMIT 1",
+		email,
+	).Scan(&appID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var recent int
+	database.DB.QueryRow(
+		"SELECT COUNT(*) FROM application_manage_tokens WHERE application_id = ? AND created_at > ?",
+		appID, time.Now().Add(-manageLinkInterval).Unix(),
+	).Scan(&recent)
+	if recent > 0 {
+		return false, ErrManageLinkTooFrequent
+	}
+
+	b := make([]byte, 32)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	now := time.Now()
+	expiresAt := now.Add(manageLinkTTL)
+	if _, err := database.DB.Exec(
+		"INSERT INTO application_manage_tokens (token_hash, application_id, email, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		hashToken(token), appID, email, now.Unix(), expiresAt.Unix(),
+	); err != nil {
+		return false, err
+	}
+
+	// 顺带清理早已过期的管理令牌
+	_, _ = database.DB.Exec("DELETE FROM application_manage_tokens WHERE expires_at < ?", now.Add(-sessionRetention).Unix())
+
+	link := fmt.Sprintf("%s/manage?token=%s", baseURL, url.QueryEscape(token))
+	EnqueueManageLinkEmail(email, link)
+	return true, nil
+}
+
+// GetManagedApplication 通过管理链接查询申请，不消耗链接
+func GetManagedApplication(token string) (ManagedApplication, error) {
+	var a ManagedApplication
+	var createdAt interface{}
+	var expiresAt int64
+	err := database.DB.QueryRow(`
+		SELECT a.id, a.email, a.status, a.reason, a.created_at, t.expires_at
+		FROM application_manage_tokens t
+		JOIN applications a ON a.id = t.application_id
+		WHERE t.token_hash = ? AND t.used_at IS NULL AND t.expires_at > ?
+	`, hashToken(token), time.Now().Unix()).Scan(&a.ID, &a.Email, &a.Status, &a.Reason, &createdAt, &expiresAt)
+	if err != nil {
+		return a, ErrManageLinkInvalid
+	}
+
+	a.CreatedAt = time.Unix(database.ToUnixTimestamp(createdAt), 0)
+	a.ExpiresAt = time.Unix(expiresAt, 0)
+	a.CanManage = a.Status == "pending"
+	// 复核属于内部流程，对申请人仍显示为待审核
+	if a.Status == "pending_second_review" {
+		a.Status = "pending"
+	}
+	return a, nil
+}
+
+// consumeManageToken 在事务中消耗管理链接，返回对应的申请 ID 与邮箱
+func consumeManageToken(tx *sql.Tx, token string) (appID int, email string, err error) {
+	hash := hashToken(token)
+	res, err := tx.Exec(
+		"UPDATE application_manage_tokens SET used_at = ? WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?",
+		time.Now().Unix(), hash, time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, "", err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return 0, "", ErrManageLinkInvalid
+	}
+
+	if err := tx.QueryRow(
+		"SELECT application_id, email FROM application_manage_tokens WHERE token_hash = ?", hash,
+	).Scan(&appID, &email); err != nil {
+		return 0, "", err
+	}
+	return appID, email, nil
+}
+
+// WithdrawApplication 申请人撤回待审核的申请，同时释放审核员的分配与认领
+func WithdrawApplication(token string) (appID int, email string, err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, "", err
+	}
+	defer tx.Rollback()
+
+	appID, email, err = consumeManageToken(tx, token)
+	if err != nil {
+		return 0, "", err
+	}
+
+	res, err := tx.Exec(`
+		UPDATE applications
+		SET status = 'withdrawn', assigned_to = NULL, assigned_at = NULL, locked_by = NULL, locked_until = NULL, updated_at = ?
+		WHERE id = ? AND status = 'pending'
+	`, time.Now().Unix(), appID)
+	if err != nil {
+		return 0, "", err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, "", ErrApplicationNotPending
+	}
+
+	return appID, email, tx.Commit()
+}
+
+// EditApplicationReason 申请人修改待审核申请的理由，并记录修改前后的内容
+func EditApplicationReason(token, reason, ip string) (appID int, email string, err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, "", err
+	}
+	defer tx.Rollback()
+
+	appID, email, err = consumeManageToken(tx, token)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var status, oldReason string
+	var lockedBy, lockedUntil sql.NullInt64
+	if err := tx.QueryRow(
+		"SELECT status, reason, locked_by, locked_until FROM applications WHERE id = ?", appID,
+	).Scan(&status, &oldReason, &lockedBy, &lockedUntil); err != nil {
+		return 0, "", ErrApplicationNotFound
+	}
+	if status != "pending" {
+		return 0, "", ErrApplicationNotPending
+	}
+	// 审核员认领期间不允许修改，避免批准的内容与审核员看到的不一致
+	now := time.Now().Unix()
+	if lockedBy.Valid && lockedUntil.Int64 > now {
+		return 0, "", ErrApplicationLocked
+	}
+
+	res, err := tx.Exec(
+		"UPDATE applications SET reason = ?, updated_at = ? WHERE id = ? AND status = 'pending' AND (locked_by IS NULL OR locked_until <= ?)",
+		reason, now, appID, now,
+	)
+	if err != nil {
+		return 0, "", err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, "", ErrApplicationLocked
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO application_edits (application_id, old_reason, new_reason, ip, created_at) VALUES (?, ?, ?, ?, ?)",
+		appID, oldReason, reason, ip, now,
+	); err != nil {
+		return 0, "", err
+	}
+
+	return appID, email, tx.Commit()
+}
+
+// ListApplicationEdits 获取申请的理由修改记录，按时间顺序排列
+func ListApplicationEdits(appID int) ([]ApplicationEdit, error) {
+	rows, err := database.DB.Query(
+		"SELECT id, old_reason, new_reason, ip, created_at FROM application_edits WHERE application_id = ? ORDER BY created_at ASC, id ASC",
+		appID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edits := make([]ApplicationEdit, 0)
+	for rows.Next() {
+		var e ApplicationEdit
+		var ip sql.NullString
+		var createdAt int64
+		if err := rows.Scan(&e.ID, &e.OldReason, &e.NewReason, &ip, &createdAt); err != nil {
+			continue
+		}
+		e.IP = ip.String
+		e.CreatedAt = time.Unix(createdAt, 0)
+		edits = append(edits, e)
+	}
+	return edits, nil
+}
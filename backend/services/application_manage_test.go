@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"invite-backend/database"
+)
+
+func insertTestManageToken(t *testing.T, token string, appID int, email string) {
+	t.Helper()
+	now := time.Now().Unix()
+	if _, err := database.DB.Exec(
+		"INSERT INTO application_manage_tokens (token_hash, application_id, email, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		hashToken(token), appID, email, now, now+600,
+	); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEditApplicationReasonRespectsClaimLock(t *testing.T) {
+	setupTestDB(t)
+	appID := insertTestApplication(t, "a@example.com")
+	insertTestManageToken(t, "token", appID, "a@example.com")
+	if _, err := database.DB.Exec(
+		"UPDATE applications SET locked_by = 1, locked_until = ? WHERE id = ?", time.Now().Add(time.Minute).Unix(), appID,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := EditApplicationReason("token", "new reason", "127.0.0.1"); err != ErrApplicationLocked {
+		t.Fatalf("edit while claimed: err = %v, want %v", err, ErrApplicationLocked)
+	}
+	var reason string
+	var edits int
+	database.DB.QueryRow("SELECT reason FROM applications WHERE id = ?", appID).Scan(&reason)
+	database.DB.QueryRow("SELECT COUNT(*) FROM application_edits WHERE application_id = ?", appID).Scan(&edits)
+	if reason != "reason" || edits != 0 {
+		t.Errorf("reason = %q with %d edits, want unchanged", reason, edits)
+	}
+
+	// 认领过期后可以修改，被拒绝的修改没有消耗管理链接
+	if _, err := database.DB.Exec("UPDATE applications SET locked_until = ? WHERE id = ?", time.Now().Unix()-1, appID); err != nil {
+		t.Fatal(err)
+	}
+	if gotID, email, err := EditApplicationReason("token", "new reason", "127.0.0.1"); err != nil || gotID != appID || email != "a@example.com" {
+		t.Fatalf("edit after lock expired: %d, %q, %v", gotID, email, err)
+	}
+	database.DB.QueryRow("SELECT reason FROM applications WHERE id = ?", appID).Scan(&reason)
+	if reason != "new reason" {
+		t.Errorf("reason = %q, want updated", reason)
+	}
+	if _, _, err := EditApplicationReason("token", "again", "127.0.0.1"); err != ErrManageLinkInvalid {
+		t.Errorf("reused link: err = %v, want %v", err, ErrManageLinkInvalid)
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"invite-backend/database"
+)
+
+// 自动分配模式
+const (
+	AssignmentOff         = "off"
+	AssignmentRoundRobin  = "round_robin"
+	AssignmentLeastLoaded = "least_loaded"
+)
+
+// 自动分配默认参数
+const (
+	defaultAssignmentMaxQueue       = 20
+	defaultAssignmentTimeoutMinutes = 60
+	assignmentInterval              = time.Minute
+)
+
+// assignmentMu 保证同一时间只有一次分配在执行
+var assignmentMu sync.Mutex
+
+// AssignmentConfig 自动分配配置
+type AssignmentConfig struct {
+	Mode     string
+	MaxQueue int
+	Timeout  time.Duration
+}
+
+// GetAssignmentConfig 从系统设置读取自动分配配置
+func GetAssignmentConfig() AssignmentConfig {
+	settings, _ := GetSystemSettings()
+
+	cfg := AssignmentConfig{Mode: settings["assignment_mode"]}
+	if cfg.Mode != AssignmentRoundRobin && cfg.Mode != AssignmentLeastLoaded {
+		cfg.Mode = AssignmentOff
+	}
+
+	cfg.MaxQueue, _ = strconv.Atoi(settings["assignment_max_queue"])
+	if cfg.MaxQueue <= 0 {
+		cfg.MaxQueue = defaultAssignmentMaxQueue
+	}
+
+	minutes, _ := strconv.Atoi(settings["assignment_timeout_minutes"])
+	if minutes <= 0 {
+		minutes = defaultAssignmentTimeoutMinutes
+	}
+	cfg.Timeout = time.Duration(minutes) * time.Minute
+
+	return cfg
+}
+
+// StartAssignmentWorker 启动后台任务，定期分配新申请并回收超时未处理的分配
+func StartAssignmentWorker() {
+	go func() {
+		ticker := time.NewTicker(assignmentInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			RunAssignment()
+		}
+	}()
+}
+
+// RunAssignment 执行一轮分配：先回收超时的分配，再分配未分配的待审核申请
+func RunAssignment() {
+	assignmentMu.Lock()
+	defer assignmentMu.Unlock()
+
+	cfg := GetAssignmentConfig()
+	if cfg.Mode == AssignmentOff {
+		return
+	}
+
+	if err := reassignStale(cfg); err != nil {
+		log.Printf("Failed to reassign stale applications: %v", err)
+	}
+	if err := assignUnassigned(cfg); err != nil {
+		log.Printf("Failed to assign pending applications: %v", err)
+	}
+}
+
+// assignUnassigned 按申请时间顺序分配尚未分配的待审核申请，所有审核员队列已满时停止
+func assignUnassigned(cfg AssignmentConfig) error {
+	rows, err := database.DB.Query(
+		"SELECT id FROM applications WHERE status = 'pending' AND assigned_to IS NULL ORDER BY created_at ASC, id ASC",
+	)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		assigned, err := assignApplication(cfg, id, 0)
+		if err != nil {
+			return err
+		}
+		if !assigned {
+			break
+		}
+	}
+	return nil
+}
+
+// reassignStale 将分配后超时仍未处理的申请改派给其他审核员
+// 当前分配人正在认领审核的申请不会被改派
+func reassignStale(cfg AssignmentConfig) error {
+	now := time.Now().Unix()
+	rows, err := database.DB.Query(`
+		SELECT id, assigned_to FROM applications
+		WHERE status = 'pending' AND assigned_to IS NOT NULL AND assigned_at <= ?
+			AND NOT (COALESCE(locked_by, 0) = assigned_to AND COALESCE(locked_until, 0) > ?)
+		ORDER BY assigned_at ASC
+	`, time.Now().Add(-cfg.Timeout).Unix(), now)
+	if err != nil {
+		return err
+	}
+	type stale struct{ id, adminID int }
+	var items []stale
+	for rows.Next() {
+		var s stale
+		if err := rows.Scan(&s.id, &s.adminID); err == nil {
+			items = append(items, s)
+		}
+	}
+	rows.Close()
+
+	// 没有其他可用的审核员时保留原分配人与分配时间，避免同一轮又分回原分配人而重置超时
+	for _, s := range items {
+		if _, err := assignApplication(cfg, s.id, s.adminID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignApplication 为申请挑选审核员并分配，excludeAdminID 为需要排除的原分配人
+func assignApplication(cfg AssignmentConfig, appID, excludeAdminID int) (bool, error) {
+	adminID, ok, err := pickReviewer(cfg, excludeAdminID)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	now := time.Now().Unix()
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE applications SET assigned_to = ?, assigned_at = ? WHERE id = ? AND status = 'pending'",
+		adminID, now, appID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// 申请已被处理，视为成功以继续分配下一条
+		return true, nil
+	}
+	if _, err := tx.Exec("UPDATE admins SET last_assigned_at = ? WHERE id = ?", now, adminID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// pickReviewer 从可接单且队列未满的审核员中挑选一人
+// 轮询模式选择最久未被分配的审核员，最少负载模式选择待处理数量最少的审核员
+func pickReviewer(cfg AssignmentConfig, excludeAdminID int) (int, bool, error) {
+	order := "a.last_assigned_at IS NOT NULL, a.last_assigned_at ASC, a.id ASC"
+	if cfg.Mode == AssignmentLeastLoaded {
+		order = "open_count ASC, " + order
+	}
+
+	var adminID int
+	err := database.DB.QueryRow(`
+		SELECT a.id,
+			(SELECT COUNT(*) FROM applications ap WHERE ap.assigned_to = a.id AND ap.status = 'pending') AS open_count
+		FROM admins a
+		WHERE a.available = 1 AND a.must_change_password = 0 AND a.id != ?
+			AND (a.role = ? OR a.role IN (SELECT role_name FROM role_permissions WHERE permission = ?))
+			AND open_count < ?
+		ORDER BY `+order+`
+		LIMIT 1
+	`, excludeAdminID, RoleSuper, PermApplicationsReview, cfg.MaxQueue).Scan(&adminID, new(int))
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return adminID, true, nil
+}
+
+// OpenQueueSize 获取审核员当前待处理的分配数量
+func OpenQueueSize(adminID int) int {
+	var count int
+	database.DB.QueryRow(
+		"SELECT COUNT(*) FROM applications WHERE assigned_to = ? AND status = 'pending'", adminID,
+	).Scan(&count)
+	return count
+}
+
+// SetAdminAvailability 设置审核员是否接收自动分配
+// 设为不可用时，其名下未认领的待审核申请退回分配池
+func SetAdminAvailability(adminID int, available bool) error {
+	if _, err := database.DB.Exec(
+		"UPDATE admins SET available = ?, updated_at = ? WHERE id = ?", available, time.Now().Unix(), adminID,
+	); err != nil {
+		return err
+	}
+
+	if !available {
+		_, err := database.DB.Exec(`
+			UPDATE applications SET assigned_to = NULL, assigned_at = NULL
+			WHERE assigned_to = ? AND status = 'pending'
+				AND NOT (COALESCE(locked_by, 0) = ? AND COALESCE(locked_until, 0) > ?)
+		`, adminID, adminID, time.Now().Unix())
+		if err != nil {
+			return err
+		}
+	}
+
+	go RunAssignment()
+	return nil
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"invite-backend/database"
+)
+
+func TestReassignStaleWithoutAlternative(t *testing.T) {
+	setupTestDB(t)
+	setTestSetting(t, "assignment_mode", AssignmentRoundRobin)
+	if _, err := database.DB.Exec("UPDATE admins SET must_change_password = 0 WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	appID := insertTestApplication(t, "a@example.com")
+	staleAt := time.Now().Add(-2 * time.Hour).Unix()
+	if _, err := database.DB.Exec("UPDATE applications SET assigned_to = 1, assigned_at = ? WHERE id = ?", staleAt, appID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 唯一的审核员就是原分配人：保留分配且不重置分配时间
+	RunAssignment()
+	var assignedTo, assignedAt int64
+	database.DB.QueryRow("SELECT assigned_to, assigned_at FROM applications WHERE id = ?", appID).Scan(&assignedTo, &assignedAt)
+	if assignedTo != 1 || assignedAt != staleAt {
+		t.Errorf("without alternative: assigned to %d at %d, want 1 at %d", assignedTo, assignedAt, staleAt)
+	}
+
+	// 有其他审核员后改派
+	res, err := database.DB.Exec("INSERT INTO admins (username, role) VALUES ('reviewer2', 'reviewer')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := res.LastInsertId()
+	RunAssignment()
+	database.DB.QueryRow("SELECT assigned_to, assigned_at FROM applications WHERE id = ?", appID).Scan(&assignedTo, &assignedAt)
+	if assignedTo != other || assignedAt == staleAt {
+		t.Errorf("with alternative: assigned to %d at %d, want %d with a new time", assignedTo, assignedAt, other)
+	}
+}
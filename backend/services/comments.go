@@ -0,0 +1,183 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"invite-backend/database"
+)
+
+// mentionPattern 匹配评论中的 @用户名，结尾的 . 与 - 通常是标点，查找用户时会先尝试去掉
+var mentionPattern = regexp.MustCompile(`@([\p{L}\p{N}_.\-]+)`)
+
+// 评论错误
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrCommentNotAuthor = errors.New("comment belongs to another admin")
+)
+
+// ApplicationComment 申请的内部评论，仅管理员可见
+type ApplicationComment struct {
+	ID            int       `json:"id"`
+	ApplicationID int       `json:"applicationId"`
+	AdminID       int       `json:"adminId"`
+	AdminUsername string    `json:"adminUsername"`
+	Content       string    `json:"content"`
+	Mentions      []string  `json:"mentions"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// AddApplicationComment 添加内部评论，并通知被 @ 的管理员
+func AddApplicationComment(appID, adminID int, adminUsername, content string) (ApplicationComment, error) {
+	now := time.Now().Unix()
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return ApplicationComment{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"INSERT INTO application_comments (application_id, admin_id, admin_username, content, created_at) VALUES (?, ?, ?, ?, ?)",
+		appID, adminID, adminUsername, content, now,
+	)
+	if err != nil {
+		return ApplicationComment{}, err
+	}
+	commentID, _ := res.LastInsertId()
+
+	type mention struct {
+		id       int
+		username string
+		email    string
+	}
+	var mentions []mention
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		var mt mention
+		var email sql.NullString
+		found := false
+		for _, username := range mentionCandidates(m[1]) {
+			if err := tx.QueryRow("SELECT id, username, email FROM admins WHERE username = ?", username).Scan(&mt.id, &mt.username, &email); err == nil {
+				found = true
+				break
+			}
+		}
+		if !found || seen[mt.username] {
+			continue
+		}
+		seen[mt.username] = true
+		mt.email = email.String
+		if _, err := tx.Exec(
+			"INSERT OR IGNORE INTO application_comment_mentions (comment_id, admin_id) VALUES (?, ?)", commentID, mt.id,
+		); err != nil {
+			return ApplicationComment{}, err
+		}
+		mentions = append(mentions, mt)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ApplicationComment{}, err
+	}
+
+	comment := ApplicationComment{
+		ID:            int(commentID),
+		ApplicationID: appID,
+		AdminID:       adminID,
+		AdminUsername: adminUsername,
+		Content:       content,
+		Mentions:      make([]string, 0, len(mentions)),
+		CreatedAt:     time.Unix(now, 0),
+	}
+	for _, mt := range mentions {
+		comment.Mentions = append(comment.Mentions, mt.username)
+	}
+
+	// 通知邮件加入发送队列（不通知自己，未设置邮箱的跳过）
+	var email string
+	database.DB.QueryRow("SELECT email FROM applications WHERE id = ?", appID).Scan(&email)
+	for _, mt := range mentions {
+		if mt.id == adminID || mt.email == "" {
+			continue
+		}
+		body := fmt.Sprintf("%s 在申请 #%d（%s）的内部评论中提到了您：\n\n%s", adminUsername, appID, email, content)
+		EnqueueAdminNotification(mt.email, "您在申请评论中被提及", body)
+	}
+
+	return comment, nil
+}
+
+// mentionCandidates 返回 @ 后文本可能对应的用户名：去掉结尾标点的版本优先，其次为原文
+func mentionCandidates(raw string) []string {
+	trimmed := strings.TrimRight(raw, ".-")
+	if trimmed == raw || trimmed == "" {
+		return []string{raw}
+	}
+	return []string{trimmed, raw}
+}
+
+// ListApplicationComments 获取申请的内部评论（按时间正序）
+func ListApplicationComments(appID int) ([]ApplicationComment, error) {
+	rows, err := database.DB.Query(`
+		SELECT c.id, c.application_id, c.admin_id, c.admin_username, c.content, c.created_at,
+			(SELECT GROUP_CONCAT(a.username, ',') FROM application_comment_mentions m
+				JOIN admins a ON a.id = m.admin_id WHERE m.comment_id = c.id)
+		FROM application_comments c
+		WHERE c.application_id = ?
+		ORDER BY c.created_at ASC, c.id ASC
+	`, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := make([]ApplicationComment, 0)
+	for rows.Next() {
+		var cm ApplicationComment
+		var createdAt int64
+		var mentions sql.NullString
+		if err := rows.Scan(&cm.ID, &cm.ApplicationID, &cm.AdminID, &cm.AdminUsername, &cm.Content, &createdAt, &mentions); err != nil {
+			continue
+		}
+		cm.CreatedAt = time.Unix(createdAt, 0)
+		cm.Mentions = make([]string, 0)
+		if mentions.String != "" {
+			cm.Mentions = strings.Split(mentions.String, ",")
+		}
+		comments = append(comments, cm)
+	}
+
+	return comments, nil
+}
+
+// DeleteApplicationComment 删除申请下的评论，仅作者本人可删除
+func DeleteApplicationComment(appID, commentID, adminID int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var authorID int
+	if err := tx.QueryRow(
+		"SELECT admin_id FROM application_comments WHERE id = ? AND application_id = ?", commentID, appID,
+	).Scan(&authorID); err != nil {
+		return ErrCommentNotFound
+	}
+	if authorID != adminID {
+		return ErrCommentNotAuthor
+	}
+
+	if _, err := tx.Exec("DELETE FROM application_comments WHERE id = ?", commentID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM application_comment_mentions WHERE comment_id = ?", commentID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"invite-backend/database"
+)
+
+// insertTestAdmin 插入一名审核员并返回 ID，email 为空时不设置通知邮箱
+func insertTestAdmin(t *testing.T, username, email string) int {
+	t.Helper()
+	res, err := database.DB.Exec("INSERT INTO admins (username, role, email) VALUES (?, 'reviewer', ?)", username, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id)
+}
+
+func TestMentionCandidates(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{"bob", []string{"bob"}},
+		{"bob.", []string{"bob", "bob."}},
+		{"bob-.", []string{"bob", "bob-."}},
+		{"alice-dev", []string{"alice-dev"}},
+		{"...", []string{"..."}},
+	}
+	for _, tt := range tests {
+		if got := mentionCandidates(tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mentionCandidates(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestAddApplicationCommentMentions(t *testing.T) {
+	setupTestDB(t)
+	if _, err := database.DB.Exec("UPDATE admins SET email = 'admin@example.com' WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	insertTestAdmin(t, "bob", "bob@example.com")
+	insertTestAdmin(t, "alice-dev", "alice@example.com")
+	insertTestAdmin(t, "carol", "")
+	appID := insertTestApplication(t, "a@example.com")
+
+	// 结尾标点被去掉，重复提及只记一次，不存在的用户忽略，自己与未设置邮箱的不发通知
+	content := "@bob. please check, cc @alice-dev @carol @bob @nobody @admin"
+	comment, err := AddApplicationComment(appID, 1, "admin", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"bob", "alice-dev", "carol", "admin"}
+	if !reflect.DeepEqual(comment.Mentions, want) {
+		t.Errorf("mentions = %v, want %v", comment.Mentions, want)
+	}
+
+	rows, err := database.DB.Query("SELECT recipient FROM email_queue WHERE kind = ? ORDER BY id", EmailKindAdminNotice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recipients []string
+	for rows.Next() {
+		var r string
+		rows.Scan(&r)
+		recipients = append(recipients, r)
+	}
+	rows.Close()
+	if !reflect.DeepEqual(recipients, []string{"bob@example.com", "alice@example.com"}) {
+		t.Errorf("notified %v", recipients)
+	}
+
+	comments, err := ListApplicationComments(appID)
+	if err != nil || len(comments) != 1 || len(comments[0].Mentions) != len(want) {
+		t.Errorf("listed comments: %+v, %v", comments, err)
+	}
+}
+
+func TestDeleteApplicationCommentAuthorOnly(t *testing.T) {
+	setupTestDB(t)
+	bob := insertTestAdmin(t, "bob", "")
+	appID := insertTestApplication(t, "a@example.com")
+	comment, err := AddApplicationComment(appID, 1, "admin", "note @bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteApplicationComment(appID, comment.ID, bob); err != ErrCommentNotAuthor {
+		t.Errorf("delete by non-author: err = %v, want %v", err, ErrCommentNotAuthor)
+	}
+	if err := DeleteApplicationComment(appID+1, comment.ID, 1); err != ErrCommentNotFound {
+		t.Errorf("delete under another application: err = %v, want %v", err, ErrCommentNotFound)
+	}
+	if err := DeleteApplicationComment(appID, comment.ID, 1); err != nil {
+		t.Fatalf("delete by author: %v", err)
+	}
+
+	var mentions int
+	database.DB.QueryRow("SELECT COUNT(*) FROM application_comment_mentions WHERE comment_id = ?", comment.ID).Scan(&mentions)
+	if comments, _ := ListApplicationComments(appID); len(comments) != 0 || mentions != 0 {
+		t.Errorf("after delete: %d comments, %d mentions", len(comments), mentions)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"crypto/tls"
 	"fmt"
+	"html"
 
 	"gopkg.in/gomail.v2"
 )
@@ -219,13 +220,27 @@ func (e *EmailService) SendApprovalEmail(to, code, note string) error {
 	return d.DialAndSend(m)
 }
 
-// SendRejectionEmail 发送拒绝邮件
-func (e *EmailService) SendRejectionEmail(to, reason string) error {
+// SendRejectionEmail 发送拒绝邮件，appealURL 非空时附带申诉入口，reapply 非空时替换默认的重新申请提示
+func (e *EmailService) SendRejectionEmail(to, reason, appealURL, reapply string) error {
 	m := gomail.NewMessage()
 	m.SetHeader("From", e.User)
 	m.SetHeader("To", to)
 	m.SetHeader("Subject", "关于您的邀请码申请 - L站")
 
+	appealHTML, appealText := "", ""
+	if appealURL != "" {
+		appealHTML = fmt.Sprintf(`
+            <div style="text-align: center; margin: 25px 0;">
+                <p style="color: #64748b; font-size: 14px; margin-bottom: 12px;">如果您认为审核结果有误，可以提交一次申诉：</p>
+                <a href="%s" style="display: inline-block; background: #6366f1; color: #ffffff; padding: 12px 28px; border-radius: 8px; text-decoration: none; font-weight: 600;">提交申诉</a>
+            </div>`, appealURL)
+		appealText = fmt.Sprintf("\n如果您认为审核结果有误，可以通过以下链接提交一次申诉：\n%s\n", appealURL)
+	}
+
+	if reapply == "" {
+		reapply = "您可以在完善相关信息后重新申请"
+	}
+
 	htmlBody := fmt.Sprintf(`
 <!DOCTYPE html>
 <html>
@@ -276,13 +291,15 @@ func (e *EmailService) SendRejectionEmail(to, reason string) error {
             <div class="tips">
                 <div class="tips-title">温馨建议</div>
                 <ul class="tips-list">
-                    <li>您可以在完善相关信息后重新申请</li>
+                    <li>%s</li>
                     <li>申请理由请尽量详细、真诚</li>
                     <li>确保提供的邮箱真实有效</li>
                     <li>遇到问题可联系管理员咨询</li>
                 </ul>
             </div>
 
+            %s
+
             <div class="divider"></div>
             
             <div class="quote">
@@ -302,7 +319,7 @@ func (e *EmailService) SendRejectionEmail(to, reason string) error {
     </div>
 </body>
 </html>
-	`, reason)
+	`, reason, reapply, appealHTML)
 
 	m.SetBody("text/html", htmlBody)
 	// 纯文本备用
@@ -314,16 +331,16 @@ func (e *EmailService) SendRejectionEmail(to, reason string) error {
 拒绝原因：%s
 
 温馨建议：
-• 您可以在完善相关信息后重新申请
+• %s
 • 申请理由请尽量详细、真诚
 • 确保提供的邮箱真实有效
-
+%s
 如有疑问，请联系管理员。
 
 ---
 此邮件由系统自动发送，请勿回复
 © 2026 L站邀请码分发系统
-	`, reason)
+	`, reason, reapply, appealText)
 	m.AddAlternative("text/plain", textBody)
 
 	d := gomail.NewDialer(e.Host, e.Port, e.User, e.Password)
@@ -331,3 +348,155 @@ func (e *EmailService) SendRejectionEmail(to, reason string) error {
 
 	return d.DialAndSend(m)
 }
+
+// SendAppealUpheldEmail 发送申诉维持原判的结果邮件
+func (e *EmailService) SendAppealUpheldEmail(to, opinion string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.User)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", "关于您的申诉结果 - L站")
+
+	if opinion == "" {
+		opinion = "经复核，原审核结果维持不变。"
+	}
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body { font-family: 'Arial', 'Microsoft YaHei', sans-serif; background-color: #fdfbf7; margin: 0; padding: 0; }
+        .container { max-width: 600px; margin: 40px auto; background: #ffffff; border-radius: 16px; overflow: hidden; box-shadow: 0 4px 20px rgba(0,0,0,0.08); }
+        .header { background: linear-gradient(135deg, #f6d365 0%%, #fda085 100%%); padding: 30px; text-align: center; }
+        .header h1 { color: #ffffff; margin: 0; font-size: 24px; font-weight: 600; }
+        .content { padding: 30px; color: #334155; line-height: 1.8; }
+        .reason-box { background: #fef3c7; border-left: 4px solid #f59e0b; padding: 20px 25px; margin: 25px 0; border-radius: 8px; color: #78350f; white-space: pre-line; }
+        .footer { background: #f8f9fa; padding: 20px 30px; text-align: center; color: #6c757d; font-size: 12px; border-top: 1px solid #e9ecef; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>申诉结果通知</h1>
+        </div>
+        <div class="content">
+            <p>亲爱的用户：</p>
+            <p>我们已复核您提交的申诉，很遗憾，原审核结果维持不变。</p>
+            <div class="reason-box">%s</div>
+            <p style="color: #64748b; font-size: 14px;">每个申请仅可申诉一次，感谢您的理解。</p>
+        </div>
+        <div class="footer">
+            <p style="margin: 5px 0;">此邮件由系统自动发送，请勿回复</p>
+            <p style="margin: 5px 0;">© 2026 L站邀请码分发系统</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, opinion)
+
+	m.SetBody("text/html", htmlBody)
+	m.AddAlternative("text/plain", fmt.Sprintf("申诉结果通知\n\n我们已复核您提交的申诉，很遗憾，原审核结果维持不变。\n\n%s\n\n每个申请仅可申诉一次，感谢您的理解。", opinion))
+
+	d := gomail.NewDialer(e.Host, e.Port, e.User, e.Password)
+	d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+	return d.DialAndSend(m)
+}
+
+// SendManageLinkEmail 发送申请自助管理链接，用于撤回或修改待审核的申请
+func (e *EmailService) SendManageLinkEmail(to, link string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.User)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", "管理您的邀请码申请 - L站")
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body { font-family: 'Arial', 'Microsoft YaHei', sans-serif; background-color: #fdfbf7; margin: 0; padding: 0; }
+        .container { max-width: 600px; margin: 40px auto; background: #ffffff; border-radius: 16px; overflow: hidden; box-shadow: 0 4px 20px rgba(0,0,0,0.08); }
+        .header { background: linear-gradient(135deg, #a1c4fd 0%%, #c2e9fb 100%%); padding: 30px; text-align: center; }
+        .header h1 { color: #ffffff; margin: 0; font-size: 24px; font-weight: 600; }
+        .content { padding: 30px; color: #334155; line-height: 1.8; }
+        .footer { background: #f8f9fa; padding: 20px 30px; text-align: center; color: #6c757d; font-size: 12px; border-top: 1px solid #e9ecef; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>管理您的申请</h1>
+        </div>
+        <div class="content">
+            <p>亲爱的用户：</p>
+            <p>我们收到了管理您邀请码申请的请求。在申请审核前，您可以通过以下链接撤回申请或修改申请理由：</p>
+            <div style="text-align: center; margin: 25px 0;">
+                <a href="%s" style="display: inline-block; background: #6366f1; color: #ffffff; padding: 12px 28px; border-radius: 8px; text-decoration: none; font-weight: 600;">管理申请</a>
+            </div>
+            <p style="color: #64748b; font-size: 14px;">链接 30 分钟内有效，且只能使用一次。如果这不是您本人的操作，请忽略此邮件。</p>
+        </div>
+        <div class="footer">
+            <p style="margin: 5px 0;">此邮件由系统自动发送，请勿回复</p>
+            <p style="margin: 5px 0;">© 2026 L站邀请码分发系统</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, link)
+
+	m.SetBody("text/html", htmlBody)
+	m.AddAlternative("text/plain", fmt.Sprintf("管理您的申请\n\n在申请审核前，您可以通过以下链接撤回申请或修改申请理由：\n%s\n\n链接 30 分钟内有效，且只能使用一次。如果这不是您本人的操作，请忽略此邮件。", link))
+
+	d := gomail.NewDialer(e.Host, e.Port, e.User, e.Password)
+	d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+	return d.DialAndSend(m)
+}
+
+// SendAdminNotification 发送管理员系统通知
+func (e *EmailService) SendAdminNotification(to, subject, content string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.User)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject+" - L站邀请码管理后台")
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body { font-family: 'Arial', 'Microsoft YaHei', sans-serif; background-color: #fdfbf7; margin: 0; padding: 0; }
+        .container { max-width: 600px; margin: 40px auto; background: #ffffff; border-radius: 16px; overflow: hidden; box-shadow: 0 4px 20px rgba(0,0,0,0.08); }
+        .header { background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); padding: 30px; text-align: center; }
+        .header h1 { color: #ffffff; margin: 0; font-size: 24px; font-weight: 600; }
+        .content { padding: 30px; color: #334155; line-height: 1.8; white-space: pre-line; }
+        .footer { background: #f8f9fa; padding: 20px 30px; text-align: center; color: #6c757d; font-size: 12px; border-top: 1px solid #e9ecef; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>%s</h1>
+        </div>
+        <div class="content">%s</div>
+        <div class="footer">
+            <p style="margin: 5px 0;">此邮件由系统自动发送给管理员，请勿回复</p>
+            <p style="margin: 5px 0;">© 2026 L站邀请码分发系统</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, html.EscapeString(subject), html.EscapeString(content))
+
+	m.SetBody("text/html", htmlBody)
+	m.AddAlternative("text/plain", content)
+
+	d := gomail.NewDialer(e.Host, e.Port, e.User, e.Password)
+	d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+	return d.DialAndSend(m)
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"invite-backend/database"
+)
+
+//go:embed disposable_domains.txt
+var bundledDisposableDomains string
+
+// 黑名单来源
+const (
+	BlocklistSourceBundled = "bundled"
+	BlocklistSourceCustom  = "custom"
+)
+
+// 邮箱域名被拒绝的原因
+const (
+	EmailRejectBlocklist = "blocklist" // 命中一次性邮箱黑名单
+	EmailRejectNoMX      = "no_mx"     // 域名没有 MX 记录，无法收信
+)
+
+const mxLookupTimeout = 3 * time.Second
+
+// 黑名单错误
+var (
+	ErrBlockedDomainInvalid  = errors.New("invalid blocklist domain")
+	ErrBlockedDomainNotFound = errors.New("blocklist domain not found")
+)
+
+var blockedDomainPattern = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z0-9-]{2,}$`)
+
+// MXResolver 查询域名的 MX 记录，*net.Resolver 满足该接口，离线测试时可替换为自定义实现
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// EmailMXResolver MX 检查使用的解析器
+var EmailMXResolver MXResolver = net.DefaultResolver
+
+// BlockedDomain 黑名单中的域名
+type BlockedDomain struct {
+	Domain    string    `json:"domain"`
+	Source    string    `json:"source"`
+	Hits      int       `json:"hits"` // 因该条目被拒绝的次数
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// EmailRejectionStats 邮箱域名拒绝次数统计
+type EmailRejectionStats struct {
+	Total     int `json:"total"`
+	Blocklist int `json:"blocklist"`
+	NoMX      int `json:"noMx"`
+}
+
+// SeedEmailBlocklist 导入内置的一次性邮箱域名，已被管理员移除的内置域名不会重新加入
+func SeedEmailBlocklist() error {
+	now := time.Now().Unix()
+	scanner := bufio.NewScanner(strings.NewReader(bundledDisposableDomains))
+	for scanner.Scan() {
+		domain := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if domain == "" || strings.HasPrefix(domain, "#") {
+			continue
+		}
+		if _, err := database.DB.Exec(
+			"INSERT INTO email_domain_blocklist (domain, source, created_at) VALUES (?, ?, ?) ON CONFLICT(domain) DO NOTHING",
+			domain, BlocklistSourceBundled, now,
+		); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// MatchBlockedDomain 检查域名是否在黑名单中，返回命中的条目
+// 条目 example.com 只匹配该域名本身，*.example.com 匹配其任意层级的子域名
+func MatchBlockedDomain(domain string) (string, bool) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain == "" {
+		return "", false
+	}
+
+	candidates := []interface{}{domain}
+	labels := strings.Split(domain, ".")
+	for i := 1; i < len(labels)-1; i++ {
+		candidates = append(candidates, "*."+strings.Join(labels[i:], "."))
+	}
+
+	var matched string
+	err := database.DB.QueryRow(
+		"SELECT domain FROM email_domain_blocklist WHERE removed_at IS NULL AND domain IN (?"+strings.Repeat(", ?", len(candidates)-1)+") LIMIT 1",
+		candidates...,
+	).Scan(&matched)
+	if err != nil {
+		return "", false
+	}
+	return matched, true
+}
+
+// CheckEmailDomain 按系统设置检查邮箱域名，返回拒绝原因（允许时为空）并记录拒绝
+func CheckEmailDomain(email, ip string, settings map[string]string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	domain := strings.ToLower(email[at+1:])
+
+	if settings["email_blocklist_enabled"] == "true" {
+		if matched, ok := MatchBlockedDomain(domain); ok {
+			recordEmailRejection(email, domain, EmailRejectBlocklist, matched, ip)
+			return EmailRejectBlocklist
+		}
+	}
+	if settings["email_mx_check_enabled"] == "true" && !domainAcceptsMail(EmailMXResolver, domain) {
+		recordEmailRejection(email, domain, EmailRejectNoMX, "", ip)
+		return EmailRejectNoMX
+	}
+	return ""
+}
+
+// domainAcceptsMail 判断域名是否有可用的 MX 记录
+// 仅在确认域名不存在、没有 MX 记录或声明了空 MX（RFC 7505）时返回 false，查询超时等临时错误一律放行
+func domainAcceptsMail(resolver MXResolver, domain string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), mxLookupTimeout)
+	defer cancel()
+
+	records, err := resolver.LookupMX(ctx, domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		return !(errors.As(err, &dnsErr) && dnsErr.IsNotFound)
+	}
+	if len(records) == 0 {
+		return false
+	}
+	if len(records) == 1 && (records[0].Host == "." || records[0].Host == "") {
+		return false
+	}
+	return true
+}
+
+func recordEmailRejection(email, domain, reason, matched, ip string) {
+	_, _ = database.DB.Exec(
+		"INSERT INTO email_domain_rejections (email, domain, reason, matched, ip, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		email, domain, reason, matched, ip, time.Now().Unix(),
+	)
+}
+
+// GetEmailRejectionStats 统计邮箱域名被拒绝的次数
+func GetEmailRejectionStats() EmailRejectionStats {
+	var stats EmailRejectionStats
+	database.DB.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(SUM(CASE WHEN reason = ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN reason = ? THEN 1 ELSE 0 END), 0)
+		FROM email_domain_rejections
+	`, EmailRejectBlocklist, EmailRejectNoMX).Scan(&stats.Total, &stats.Blocklist, &stats.NoMX)
+	return stats
+}
+
+// ListBlockedDomains 获取黑名单，search 按域名模糊匹配
+func ListBlockedDomains(search string) ([]BlockedDomain, error) {
+	query := `
+		SELECT b.domain, b.source, COALESCE(r.hits, 0), b.created_at
+		FROM email_domain_blocklist b
+		LEFT JOIN (
+			SELECT matched, COUNT(*) AS hits FROM email_domain_rejections WHERE reason = ? GROUP BY matched
+		) r ON r.matched = b.domain
+		WHERE b.removed_at IS NULL`
+	args := []interface{}{EmailRejectBlocklist}
+	if search != "" {
+		query += " AND b.domain LIKE ?"
+		args = append(args, "%"+strings.ToLower(search)+"%")
+	}
+	query += " ORDER BY b.source DESC, b.domain ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]BlockedDomain, 0)
+	for rows.Next() {
+		var d BlockedDomain
+		var createdAt int64
+		if err := rows.Scan(&d.Domain, &d.Source, &d.Hits, &createdAt); err != nil {
+			continue
+		}
+		d.CreatedAt = time.Unix(createdAt, 0)
+		items = append(items, d)
+	}
+	return items, nil
+}
+
+// NormalizeBlockedDomain 规范化管理员输入的域名，支持 *.example.com 形式
+func NormalizeBlockedDomain(domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "@")
+	domain = strings.TrimSuffix(domain, ".")
+	if !blockedDomainPattern.MatchString(domain) {
+		return "", ErrBlockedDomainInvalid
+	}
+	return domain, nil
+}
+
+// AddBlockedDomain 添加黑名单条目，此前被移除的内置条目会恢复
+func AddBlockedDomain(domain string, adminID int) error {
+	_, err := database.DB.Exec(`
+		INSERT INTO email_domain_blocklist (domain, source, created_by, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(domain) DO UPDATE SET removed_at = NULL
+	`, domain, BlocklistSourceCustom, adminID, time.Now().Unix())
+	return err
+}
+
+// RemoveBlockedDomain 移除黑名单条目：自定义条目直接删除，内置条目保留记录以免重启后重新导入
+func RemoveBlockedDomain(domain string) error {
+	var source string
+	err := database.DB.QueryRow(
+		"SELECT source FROM email_domain_blocklist WHERE domain = ? AND removed_at IS NULL", domain,
+	).Scan(&source)
+	if err != nil {
+		return ErrBlockedDomainNotFound
+	}
+
+	if source == BlocklistSourceBundled {
+		_, err = database.DB.Exec("UPDATE email_domain_blocklist SET removed_at = ? WHERE domain = ?", time.Now().Unix(), domain)
+	} else {
+		_, err = database.DB.Exec("DELETE FROM email_domain_blocklist WHERE domain = ?", domain)
+	}
+	return err
+}
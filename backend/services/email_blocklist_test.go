@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"invite-backend/database"
+)
+
+type fakeMXResolver struct {
+	records []*net.MX
+	err     error
+}
+
+func (r fakeMXResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return r.records, r.err
+}
+
+func TestDomainAcceptsMail(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver fakeMXResolver
+		want     bool
+	}{
+		{"valid mx", fakeMXResolver{records: []*net.MX{{Host: "mx.example.com.", Pref: 10}}}, true},
+		{"nxdomain", fakeMXResolver{err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}, false},
+		{"empty mx", fakeMXResolver{records: []*net.MX{}}, false},
+		{"null mx", fakeMXResolver{records: []*net.MX{{Host: ".", Pref: 0}}}, false},
+		{"null mx with other records", fakeMXResolver{records: []*net.MX{{Host: ".", Pref: 0}, {Host: "mx.example.com.", Pref: 10}}}, true},
+		{"temporary dns error", fakeMXResolver{err: &net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}}, true},
+		{"timeout", fakeMXResolver{err: &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}}, true},
+		{"other error", fakeMXResolver{err: errors.New("network unreachable")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := domainAcceptsMail(tt.resolver, "example.com"); got != tt.want {
+				t.Errorf("domainAcceptsMail() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeBlockedDomain(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"Example.COM", "example.com", false},
+		{" @example.com ", "example.com", false},
+		{"example.com.", "example.com", false},
+		{"*.Example.com", "*.example.com", false},
+		{"localhost", "", true},
+		{"*.com", "", true},
+		{"exa mple.com", "", true},
+		{"a.*.example.com", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeBlockedDomain(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeBlockedDomain(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMatchBlockedDomainWildcard(t *testing.T) {
+	setupTestDB(t)
+	for _, domain := range []string{"*.example.com", "mailer.test"} {
+		if _, err := database.DB.Exec(
+			"INSERT INTO email_domain_blocklist (domain, source, created_at) VALUES (?, 'custom', 0)", domain,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		domain  string
+		matched string
+		ok      bool
+	}{
+		{"a.example.com", "*.example.com", true},
+		{"a.b.example.com", "*.example.com", true},
+		{"A.B.Example.com", "*.example.com", true},
+		{"example.com", "", false},
+		{"notexample.com", "", false},
+		{"mailer.test", "mailer.test", true},
+		{"sub.mailer.test", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		matched, ok := MatchBlockedDomain(tt.domain)
+		if matched != tt.matched || ok != tt.ok {
+			t.Errorf("MatchBlockedDomain(%q) = %q, %v; want %q, %v", tt.domain, matched, ok, tt.matched, tt.ok)
+		}
+	}
+}
+
+func TestMatchBlockedDomainIgnoresRemoved(t *testing.T) {
+	setupTestDB(t)
+	if _, err := database.DB.Exec(
+		"INSERT INTO email_domain_blocklist (domain, source, created_at, removed_at) VALUES ('*.example.com', 'bundled', 0, 1)",
+	); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := MatchBlockedDomain("a.example.com"); ok {
+		t.Error("removed entry should not match")
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"invite-backend/database"
+)
+
+// 队列邮件类型
+const (
+	EmailKindApproval     = "approval"
+	EmailKindRejection    = "rejection"
+	EmailKindAppealUpheld = "appeal_upheld"
+	EmailKindManageLink   = "manage_link"
+	EmailKindAdminNotice  = "admin_notice"
+)
+
+// 邮件队列参数
+const (
+	emailQueueInterval    = 30 * time.Second
+	emailQueueBatchSize   = 20
+	emailQueueMaxAttempts = 5
+	emailQueueRetention   = 30 * 24 * time.Hour
+)
+
+// clearedEmailPayload 发送完成或放弃后替换原邮件内容
+const clearedEmailPayload = "{}"
+
+// emailQueueWake 有新邮件入队时唤醒发送任务
+var emailQueueWake = make(chan struct{}, 1)
+
+// emailPayload 队列邮件的内容参数
+type emailPayload struct {
+	Code      string `json:"code,omitempty"`
+	Opinion   string `json:"opinion,omitempty"`
+	AppealURL string `json:"appealUrl,omitempty"`
+	Link      string `json:"link,omitempty"`
+	Reapply   string `json:"reapply,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+// EnqueueApprovalEmail 将通过邮件加入发送队列
+func EnqueueApprovalEmail(to, code, opinion string) {
+	enqueueEmail(EmailKindApproval, to, emailPayload{Code: code, Opinion: opinion})
+}
+
+// EnqueueRejectionEmail 将拒绝邮件加入发送队列，appealURL 非空时邮件中附带申诉链接
+// reapply 为重新申请时间的说明，在入队时生成以免发送延迟影响内容
+func EnqueueRejectionEmail(to, opinion, appealURL, reapply string) {
+	enqueueEmail(EmailKindRejection, to, emailPayload{Opinion: opinion, AppealURL: appealURL, Reapply: reapply})
+}
+
+// EnqueueAppealUpheldEmail 将申诉维持原判的结果邮件加入发送队列
+func EnqueueAppealUpheldEmail(to, opinion string) {
+	enqueueEmail(EmailKindAppealUpheld, to, emailPayload{Opinion: opinion})
+}
+
+// EnqueueManageLinkEmail 将申请自助管理链接邮件加入发送队列
+func EnqueueManageLinkEmail(to, link string) {
+	enqueueEmail(EmailKindManageLink, to, emailPayload{Link: link})
+}
+
+// EnqueueAdminNotification 将管理员系统通知加入发送队列
+func EnqueueAdminNotification(to, subject, content string) {
+	enqueueEmail(EmailKindAdminNotice, to, emailPayload{Subject: subject, Content: content})
+}
+
+func enqueueEmail(kind, to string, payload emailPayload) {
+	data, _ := json.Marshal(payload)
+	now := time.Now().Unix()
+	if _, err := database.DB.Exec(
+		"INSERT INTO email_queue (kind, recipient, payload, created_at, next_attempt_at) VALUES (?, ?, ?, ?, ?)",
+		kind, to, string(data), now, now,
+	); err != nil {
+		log.Printf("Failed to enqueue %s email to %s: %v", kind, to, err)
+		return
+	}
+
+	select {
+	case emailQueueWake <- struct{}{}:
+	default:
+	}
+}
+
+// StartEmailWorker 启动后台任务，按入队顺序逐封发送邮件，失败的邮件按退避时间重试
+func StartEmailWorker() {
+	go func() {
+		ticker := time.NewTicker(emailQueueInterval)
+		defer ticker.Stop()
+		for {
+			processEmailQueue()
+			select {
+			case <-ticker.C:
+			case <-emailQueueWake:
+			}
+		}
+	}()
+}
+
+// processEmailQueue 发送所有到期的待发邮件
+func processEmailQueue() {
+	cutoff := time.Now().Add(-emailQueueRetention).Unix()
+	_, _ = database.DB.Exec("DELETE FROM email_queue WHERE (sent_at IS NOT NULL OR failed_at IS NOT NULL) AND created_at < ?", cutoff)
+	// 邮件内容含邀请码、管理链接等敏感信息，发送完成或放弃后只保留元数据
+	_, _ = database.DB.Exec("UPDATE email_queue SET payload = ? WHERE (sent_at IS NOT NULL OR failed_at IS NOT NULL) AND payload != ?", clearedEmailPayload, clearedEmailPayload)
+
+	for {
+		type job struct {
+			id       int
+			kind, to string
+			payload  string
+			attempts int
+		}
+		rows, err := database.DB.Query(`
+			SELECT id, kind, recipient, payload, attempts FROM email_queue
+			WHERE sent_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?
+			ORDER BY id ASC
+			LIMIT ?
+		`, time.Now().Unix(), emailQueueBatchSize)
+		if err != nil {
+			log.Printf("Failed to read email queue: %v", err)
+			return
+		}
+		var jobs []job
+		for rows.Next() {
+			var j job
+			if err := rows.Scan(&j.id, &j.kind, &j.to, &j.payload, &j.attempts); err == nil {
+				jobs = append(jobs, j)
+			}
+		}
+		rows.Close()
+
+		if len(jobs) == 0 {
+			return
+		}
+
+		emailService, err := GetEmailService()
+		for _, j := range jobs {
+			sendErr := err
+			if sendErr == nil {
+				sendErr = sendQueuedEmail(emailService, j.kind, j.to, j.payload)
+			}
+
+			now := time.Now()
+			if sendErr == nil {
+				_, _ = database.DB.Exec("UPDATE email_queue SET sent_at = ?, attempts = attempts + 1, last_error = NULL, payload = ? WHERE id = ?", now.Unix(), clearedEmailPayload, j.id)
+				continue
+			}
+
+			log.Printf("Failed to send %s email to %s (attempt %d): %v", j.kind, j.to, j.attempts+1, sendErr)
+			if j.attempts+1 >= emailQueueMaxAttempts {
+				_, _ = database.DB.Exec(
+					"UPDATE email_queue SET attempts = attempts + 1, last_error = ?, failed_at = ?, payload = ? WHERE id = ?",
+					sendErr.Error(), now.Unix(), clearedEmailPayload, j.id,
+				)
+				continue
+			}
+			// 重试间隔随失败次数递增：1、4、9、16 分钟
+			backoff := time.Duration((j.attempts+1)*(j.attempts+1)) * time.Minute
+			_, _ = database.DB.Exec(
+				"UPDATE email_queue SET attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?",
+				sendErr.Error(), now.Add(backoff).Unix(), j.id,
+			)
+		}
+	}
+}
+
+// sendQueuedEmail 按邮件类型调用对应的发送方法
+func sendQueuedEmail(emailService *EmailService, kind, to, rawPayload string) error {
+	var payload emailPayload
+	if err := json.Unmarshal([]byte(rawPayload), &payload); err != nil {
+		return err
+	}
+
+	switch kind {
+	case EmailKindApproval:
+		return emailService.SendApprovalEmail(to, payload.Code, payload.Opinion)
+	case EmailKindRejection:
+		return emailService.SendRejectionEmail(to, payload.Opinion, payload.AppealURL, payload.Reapply)
+	case EmailKindAppealUpheld:
+		return emailService.SendAppealUpheldEmail(to, payload.Opinion)
+	case EmailKindManageLink:
+		return emailService.SendManageLinkEmail(to, payload.Link)
+	case EmailKindAdminNotice:
+		return emailService.SendAdminNotification(to, payload.Subject, payload.Content)
+	default:
+		return fmt.Errorf("unknown email kind %q", kind)
+	}
+}
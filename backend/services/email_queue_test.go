@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+
+	"invite-backend/database"
+)
+
+func TestEmailQueueClearsPayloadAfterGivingUp(t *testing.T) {
+	setupTestDB(t)
+	EnqueueApprovalEmail("a@example.com", "SECRET-CODE", "ok")
+	if _, err := database.DB.Exec("UPDATE email_queue SET attempts = ?", emailQueueMaxAttempts-1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未配置 SMTP，最后一次重试失败后放弃发送，邮件内容被清除
+	processEmailQueue()
+	var payload string
+	var failedAt *int64
+	database.DB.QueryRow("SELECT payload, failed_at FROM email_queue").Scan(&payload, &failedAt)
+	if failedAt == nil || payload != clearedEmailPayload {
+		t.Errorf("after giving up: failed_at %v, payload %q", failedAt, payload)
+	}
+
+	// 历史遗留的已发送邮件内容也会被清除
+	EnqueueManageLinkEmail("b@example.com", "https://example.com/manage?token=secret")
+	if _, err := database.DB.Exec("UPDATE email_queue SET sent_at = 1 WHERE recipient = 'b@example.com'"); err != nil {
+		t.Fatal(err)
+	}
+	processEmailQueue()
+	database.DB.QueryRow("SELECT payload FROM email_queue WHERE recipient = 'b@example.com'").Scan(&payload)
+	if payload != clearedEmailPayload {
+		t.Errorf("sent email payload = %q, want cleared", payload)
+	}
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"invite-backend/database"
+)
+
+// 邀请码生命周期状态
+const (
+	InviteCodeAvailable = "available" // 在库，可分配
+	InviteCodeAssigned  = "assigned"  // 已分配给申请
+	InviteCodeRedeemed  = "redeemed"  // 已被使用（注册）
+	InviteCodeRevoked   = "revoked"   // 已作废
+	InviteCodeExpired   = "expired"   // 已过期（由 expires_at 推导，不落库）
+)
+
+// InviteCodeStatusSQL 计算邀请码实际状态的 SQL 表达式（需传入当前时间戳作为参数）
+const InviteCodeStatusSQL = `CASE
+	WHEN status IN ('available', 'assigned') AND expires_at IS NOT NULL AND expires_at <= ? THEN 'expired'
+	ELSE status END`
+
+// ErrInvitePoolExhausted 邀请码库存已耗尽
+var ErrInvitePoolExhausted = errors.New("invitation code pool exhausted")
+
+// ErrInviteCodeTaken 指定的邀请码已分配给其他申请
+var ErrInviteCodeTaken = errors.New("invitation code already assigned")
+
+// ErrInviteCodeUnavailable 指定的邀请码已使用、已作废或已过期
+var ErrInviteCodeUnavailable = errors.New("invitation code redeemed, revoked or expired")
+
+// EffectiveInviteCodeStatus 根据存储状态与过期时间计算邀请码的实际状态
+func EffectiveInviteCodeStatus(status string, expiresAt int64) string {
+	if (status == InviteCodeAvailable || status == InviteCodeAssigned) && expiresAt > 0 && expiresAt <= time.Now().Unix() {
+		return InviteCodeExpired
+	}
+	return status
+}
+
+// AssignInvitationCode 在事务内为申请分配邀请码
+// manualCode 不为空时使用人工指定的邀请码，否则从库存中领取最早导入的可用邀请码
+func AssignInvitationCode(tx *sql.Tx, appID int, manualCode string) (string, error) {
+	manualCode = strings.TrimSpace(manualCode)
+	now := time.Now().Unix()
+
+	// 已分配过有效邀请码（例如重复审核）且未指定新邀请码时直接复用
+	var existing string
+	err := tx.QueryRow(`
+		SELECT code FROM invitation_codes
+		WHERE application_id = ? AND status IN ('assigned', 'redeemed')
+			AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY id DESC LIMIT 1
+	`, appID, now).Scan(&existing)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if existing != "" && (manualCode == "" || manualCode == existing) {
+		return existing, nil
+	}
+
+	if manualCode != "" {
+		return assignManualCode(tx, appID, manualCode, existing)
+	}
+
+	// 原子领取最早导入的可用邀请码
+	var code string
+	err = tx.QueryRow(`
+		UPDATE invitation_codes SET application_id = ?, status = 'assigned', assigned_at = ?
+		WHERE id = (
+			SELECT id FROM invitation_codes
+			WHERE status = 'available' AND application_id IS NULL
+				AND (expires_at IS NULL OR expires_at > ?)
+			ORDER BY created_at ASC, id ASC
+			LIMIT 1
+		) AND status = 'available'
+		RETURNING code
+	`, appID, now, now).Scan(&code)
+	if err == sql.ErrNoRows {
+		return "", ErrInvitePoolExhausted
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// assignManualCode 分配人工指定的邀请码，库存中已有则领取，没有则直接登记
+func assignManualCode(tx *sql.Tx, appID int, code, previous string) (string, error) {
+	now := time.Now().Unix()
+
+	var id int
+	var status string
+	var ownerID, expiresAt sql.NullInt64
+	err := tx.QueryRow("SELECT id, status, application_id, expires_at FROM invitation_codes WHERE code = ?", code).Scan(&id, &status, &ownerID, &expiresAt)
+	switch {
+	case err == sql.ErrNoRows:
+		if _, err = tx.Exec(
+			"INSERT INTO invitation_codes (code, status, application_id, assigned_at, created_at) VALUES (?, 'assigned', ?, ?, ?)",
+			code, appID, now, now,
+		); err != nil {
+			return "", err
+		}
+	case err != nil:
+		return "", err
+	case ownerID.Valid && int(ownerID.Int64) != appID:
+		return "", ErrInviteCodeTaken
+	default:
+		// 已使用的邀请码在删除申请后不再有归属，也不能再分配给其他申请
+		if effective := EffectiveInviteCodeStatus(status, expiresAt.Int64); effective != InviteCodeAvailable && effective != InviteCodeAssigned {
+			return "", ErrInviteCodeUnavailable
+		}
+		if _, err = tx.Exec("UPDATE invitation_codes SET application_id = ?, status = 'assigned', assigned_at = ? WHERE id = ?", appID, now, id); err != nil {
+			return "", err
+		}
+	}
+
+	// 人工覆盖时，原先分配的邀请码已随通过邮件发出，作废而不归还库存
+	if previous != "" {
+		if _, err = tx.Exec(
+			"UPDATE invitation_codes SET status = 'revoked', revoked_at = ? WHERE code = ? AND status = 'assigned'",
+			now, previous,
+		); err != nil {
+			return "", err
+		}
+	}
+
+	return code, nil
+}
+
+// ReleaseApplicationCodes 在事务内解除申请与邀请码的关联（例如删除申请时）
+// 申请已批准时邀请码已随通过邮件发出，作废处理；从未批准的申请将已分配未使用的邀请码归还库存
+func ReleaseApplicationCodes(tx *sql.Tx, appID int) error {
+	var status string
+	if err := tx.QueryRow("SELECT status FROM applications WHERE id = ?", appID).Scan(&status); err != nil {
+		return err
+	}
+
+	if status == "approved" {
+		if _, err := tx.Exec(
+			"UPDATE invitation_codes SET status = 'revoked', revoked_at = ? WHERE application_id = ? AND status = 'assigned'",
+			time.Now().Unix(), appID,
+		); err != nil {
+			return err
+		}
+	} else if _, err := tx.Exec(
+		"UPDATE invitation_codes SET status = 'available', assigned_at = NULL WHERE application_id = ? AND status = 'assigned'", appID,
+	); err != nil {
+		return err
+	}
+
+	// 已使用或已作废的邀请码保留记录
+	_, err := tx.Exec("UPDATE invitation_codes SET application_id = NULL WHERE application_id = ?", appID)
+	return err
+}
+
+// ParseInviteCodes 解析批量导入的邀请码文本
+// CSV 模式下每行取第一列（自动跳过表头），否则按换行、逗号、分号及空白分隔
+func ParseInviteCodes(content string, isCSV bool) []string {
+	var raw []string
+
+	if isCSV {
+		reader := csv.NewReader(strings.NewReader(content))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		reader.TrimLeadingSpace = true
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				continue
+			}
+			if len(record) > 0 {
+				raw = append(raw, record[0])
+			}
+		}
+	} else {
+		raw = strings.FieldsFunc(content, func(r rune) bool {
+			return r == ',' || r == ';' || r == '，' || r == '；' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		})
+	}
+
+	seen := make(map[string]bool)
+	codes := make([]string, 0, len(raw))
+	for _, code := range raw {
+		code = strings.Trim(strings.TrimSpace(code), `"'`)
+		if code == "" || seen[code] {
+			continue
+		}
+		lower := strings.ToLower(code)
+		if lower == "code" || lower == "invite_code" || lower == "邀请码" {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+
+	return codes
+}
+
+// ImportInviteCodes 批量导入可用邀请码，expiresAt 为 0 表示永不过期（存储为 NULL），返回新增数量与重复数量
+func ImportInviteCodes(tx *sql.Tx, codes []string, expiresAt int64) (imported, duplicates int, err error) {
+	stmt, err := tx.Prepare("INSERT INTO invitation_codes (code, status, expires_at, created_at) VALUES (?, 'available', ?, ?) ON CONFLICT(code) DO NOTHING")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer stmt.Close()
+
+	var expires interface{}
+	if expiresAt > 0 {
+		expires = expiresAt
+	}
+
+	now := time.Now().Unix()
+	for _, code := range codes {
+		res, err := stmt.Exec(code, expires, now)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			imported++
+		} else {
+			duplicates++
+		}
+	}
+
+	return imported, duplicates, nil
+}
+
+// InvitePoolStats 邀请码库存统计
+type InvitePoolStats struct {
+	Total         int     `json:"total"`
+	Unused        int     `json:"unused"`
+	Used          int     `json:"used"` // 已分配 + 已使用
+	Assigned      int     `json:"assigned"`
+	Redeemed      int     `json:"redeemed"`
+	Revoked       int     `json:"revoked"`
+	Expired       int     `json:"expired"`
+	Days          int     `json:"days"`
+	DailyRate     float64 `json:"dailyRate"`     // 统计窗口内平均每日消耗
+	DaysRemaining float64 `json:"daysRemaining"` // 按当前消耗速度预计可用天数，-1 表示无消耗
+	Threshold     int     `json:"threshold"`
+	Low           bool    `json:"low"`
+}
+
+// GetInvitePoolStats 统计邀请码库存，days 为计算消耗速度的时间窗口
+func GetInvitePoolStats(days int) (*InvitePoolStats, error) {
+	if days < 1 {
+		days = 7
+	}
+	now := time.Now().Unix()
+	stats := &InvitePoolStats{Days: days, DaysRemaining: -1}
+
+	rows, err := database.DB.Query("SELECT "+InviteCodeStatusSQL+" AS state, COUNT(*) FROM invitation_codes GROUP BY state", now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var state string
+		var count int
+		if err := rows.Scan(&state, &count); err != nil {
+			continue
+		}
+		stats.Total += count
+		switch state {
+		case InviteCodeAvailable:
+			stats.Unused = count
+		case InviteCodeAssigned:
+			stats.Assigned = count
+		case InviteCodeRedeemed:
+			stats.Redeemed = count
+		case InviteCodeRevoked:
+			stats.Revoked = count
+		case InviteCodeExpired:
+			stats.Expired = count
+		}
+	}
+	stats.Used = stats.Assigned + stats.Redeemed
+
+	var consumed int
+	since := time.Now().AddDate(0, 0, -days).Unix()
+	database.DB.QueryRow("SELECT COUNT(*) FROM invitation_codes WHERE assigned_at >= ?", since).Scan(&consumed)
+	stats.DailyRate = float64(consumed) / float64(days)
+	if stats.DailyRate > 0 {
+		stats.DaysRemaining = float64(stats.Unused) / stats.DailyRate
+	}
+
+	settings, _ := GetSystemSettings()
+	stats.Threshold, _ = strconv.Atoi(settings["invite_pool_low_threshold"])
+	stats.Low = stats.Threshold > 0 && stats.Unused < stats.Threshold
+
+	return stats, nil
+}
+
+// 库存告警最短间隔，避免每次审核都发送邮件
+const invitePoolAlertInterval = 6 * time.Hour
+
+var invitePoolAlert = struct {
+	sync.Mutex
+	armed  bool // 库存恢复到阈值以上后重新触发
+	lastAt time.Time
+}{armed: true}
+
+// CheckInvitePoolLevel 检查邀请码库存，低于阈值时邮件通知超级管理员
+func CheckInvitePoolLevel() {
+	stats, err := GetInvitePoolStats(7)
+	if err != nil {
+		log.Printf("Failed to check invite pool level: %v", err)
+		return
+	}
+
+	invitePoolAlert.Lock()
+	if !stats.Low {
+		invitePoolAlert.armed = true
+		invitePoolAlert.Unlock()
+		return
+	}
+	if !invitePoolAlert.armed || time.Since(invitePoolAlert.lastAt) < invitePoolAlertInterval {
+		invitePoolAlert.Unlock()
+		return
+	}
+	invitePoolAlert.armed = false
+	invitePoolAlert.lastAt = time.Now()
+	invitePoolAlert.Unlock()
+
+	emailService, err := GetEmailService()
+	if err != nil {
+		log.Printf("Invite pool low (%d/%d), but SMTP not configured", stats.Unused, stats.Threshold)
+		return
+	}
+
+	content := fmt.Sprintf("当前剩余可用邀请码 %d 个，已低于告警阈值 %d 个。近 %d 天平均每日消耗 %.1f 个，请尽快导入新的邀请码。",
+		stats.Unused, stats.Threshold, stats.Days, stats.DailyRate)
+	for _, to := range GetSuperAdminEmails() {
+		if err := emailService.SendAdminNotification(to, "⚠️ 邀请码库存不足", content); err != nil {
+			log.Printf("Failed to send invite pool alert to %s: %v", to, err)
+		}
+	}
+}
+
+// RevokeInviteCode 作废邀请码（例如泄露或被转卖），返回原关联的申请 ID
+func RevokeInviteCode(id int) (appID sql.NullInt64, code string, err error) {
+	// 条件更新并返回关联的申请，避免读取与更新之间被并发分配或核销覆盖
+	err = database.DB.QueryRow(`
+		UPDATE invitation_codes SET status = 'revoked', revoked_at = ?
+		WHERE id = ? AND status IN ('available', 'assigned', 'redeemed')
+		RETURNING code, application_id
+	`, time.Now().Unix(), id).Scan(&code, &appID)
+	if err != sql.ErrNoRows {
+		return appID, code, err
+	}
+
+	// 未更新：区分邀请码不存在与已作废
+	var exists int
+	if err := database.DB.QueryRow("SELECT 1 FROM invitation_codes WHERE id = ?", id).Scan(&exists); err != nil {
+		return appID, "", err
+	}
+	return appID, "", ErrInviteCodeUnavailable
+}
+
+// MarkInviteCodesRedeemed 将已分配且未过期的邀请码标记为已使用，返回成功标记的邀请码及无法标记的邀请码
+func MarkInviteCodesRedeemed(codes []string) (redeemed, skipped []string, err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		UPDATE invitation_codes SET status = 'redeemed', is_used = 1, redeemed_at = ?
+		WHERE code = ? AND status = 'assigned' AND (expires_at IS NULL OR expires_at > ?)
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stmt.Close()
+
+	now := time.Now().Unix()
+	for _, code := range codes {
+		res, err := stmt.Exec(now, code, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			redeemed = append(redeemed, code)
+		} else {
+			skipped = append(skipped, code)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return redeemed, skipped, nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"invite-backend/database"
+)
+
+// insertTestApplication 插入一条待审核申请并返回 ID
+func insertTestApplication(t *testing.T, email string) int {
+	t.Helper()
+	res, err := database.DB.Exec(
+		"INSERT INTO applications (email, reason, device_id, ip, status) VALUES (?, 'reason', ?, '127.0.0.1', 'pending')",
+		email, email,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id)
+}
+
+// insertTestCode 插入邀请码，appID 为 0 表示无归属
+func insertTestCode(t *testing.T, code, status string, appID int, expiresAt int64) {
+	t.Helper()
+	var owner, expires interface{}
+	if appID > 0 {
+		owner = appID
+	}
+	if expiresAt != 0 {
+		expires = expiresAt
+	}
+	if _, err := database.DB.Exec(
+		"INSERT INTO invitation_codes (code, status, application_id, expires_at, created_at) VALUES (?, ?, ?, ?, ?)",
+		code, status, owner, expires, time.Now().Unix(),
+	); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assignInTx(t *testing.T, appID int, manualCode string) (string, error) {
+	t.Helper()
+	tx, err := database.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	code, err := AssignInvitationCode(tx, appID, manualCode)
+	if err == nil {
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return code, err
+}
+
+func codeState(t *testing.T, code string) (status string, appID int64) {
+	t.Helper()
+	var owner *int64
+	if err := database.DB.QueryRow("SELECT status, application_id FROM invitation_codes WHERE code = ?", code).Scan(&status, &owner); err != nil {
+		t.Fatal(err)
+	}
+	if owner != nil {
+		appID = *owner
+	}
+	return status, appID
+}
+
+func TestAssignManualCode(t *testing.T) {
+	setupTestDB(t)
+	now := time.Now().Unix()
+	owner := insertTestApplication(t, "owner@example.com")
+	appID := insertTestApplication(t, "new@example.com")
+
+	insertTestCode(t, "AVAILABLE", InviteCodeAvailable, 0, 0)
+	insertTestCode(t, "TAKEN", InviteCodeAssigned, owner, 0)
+	insertTestCode(t, "ORPHAN-REDEEMED", InviteCodeRedeemed, 0, 0) // 申请被删除后保留的已使用邀请码
+	insertTestCode(t, "REVOKED", InviteCodeRevoked, 0, 0)
+	insertTestCode(t, "EXPIRED", InviteCodeAvailable, 0, now-1)
+
+	tests := []struct {
+		code string
+		err  error
+	}{
+		{"TAKEN", ErrInviteCodeTaken},
+		{"ORPHAN-REDEEMED", ErrInviteCodeUnavailable},
+		{"REVOKED", ErrInviteCodeUnavailable},
+		{"EXPIRED", ErrInviteCodeUnavailable},
+	}
+	for _, tt := range tests {
+		before, beforeOwner := codeState(t, tt.code)
+		if _, err := assignInTx(t, appID, tt.code); err != tt.err {
+			t.Errorf("%s: err = %v, want %v", tt.code, err, tt.err)
+		}
+		if after, afterOwner := codeState(t, tt.code); after != before || afterOwner != beforeOwner {
+			t.Errorf("%s: code changed to %s/%d", tt.code, after, afterOwner)
+		}
+	}
+
+	if code, err := assignInTx(t, appID, "AVAILABLE"); err != nil || code != "AVAILABLE" {
+		t.Fatalf("available code: %q, %v", code, err)
+	}
+	if status, owner := codeState(t, "AVAILABLE"); status != InviteCodeAssigned || owner != int64(appID) {
+		t.Errorf("available code is %s/%d after assignment", status, owner)
+	}
+
+	// 人工改填其他邀请码时，原邀请码已随邮件发出，作废而不归还库存
+	if code, err := assignInTx(t, appID, "NOT-IN-POOL"); err != nil || code != "NOT-IN-POOL" {
+		t.Fatalf("unknown code: %q, %v", code, err)
+	}
+	if status, owner := codeState(t, "NOT-IN-POOL"); status != InviteCodeAssigned || owner != int64(appID) {
+		t.Errorf("unknown code is %s/%d after assignment", status, owner)
+	}
+	if status, _ := codeState(t, "AVAILABLE"); status != InviteCodeRevoked {
+		t.Errorf("replaced code is %s, want %s", status, InviteCodeRevoked)
+	}
+}
+
+func TestAssignInvitationCodeFromPool(t *testing.T) {
+	setupTestDB(t)
+	now := time.Now().Unix()
+	first := insertTestApplication(t, "a@example.com")
+	second := insertTestApplication(t, "b@example.com")
+
+	insertTestCode(t, "EXPIRED", InviteCodeAvailable, 0, now-1)
+	insertTestCode(t, "REDEEMED", InviteCodeRedeemed, 0, 0)
+	insertTestCode(t, "POOL-1", InviteCodeAvailable, 0, now+3600)
+
+	code, err := assignInTx(t, first, "")
+	if err != nil || code != "POOL-1" {
+		t.Fatalf("first assignment: %q, %v", code, err)
+	}
+	// 重复审核时复用已分配的邀请码
+	if again, err := assignInTx(t, first, ""); err != nil || again != code {
+		t.Errorf("repeated assignment: %q, %v; want %q", again, err, code)
+	}
+	// 库存中只剩过期与已使用的邀请码
+	if code, err := assignInTx(t, second, ""); err != ErrInvitePoolExhausted {
+		t.Errorf("exhausted pool: %q, %v; want %v", code, err, ErrInvitePoolExhausted)
+	}
+	if status, owner := codeState(t, "REDEEMED"); status != InviteCodeRedeemed || owner != 0 {
+		t.Errorf("redeemed code is %s/%d", status, owner)
+	}
+}
+
+func TestMarkInviteCodesRedeemed(t *testing.T) {
+	setupTestDB(t)
+	now := time.Now().Unix()
+	appID := insertTestApplication(t, "a@example.com")
+
+	insertTestCode(t, "ASSIGNED", InviteCodeAssigned, appID, 0)
+	insertTestCode(t, "ASSIGNED-VALID", InviteCodeAssigned, appID, now+3600)
+	insertTestCode(t, "ASSIGNED-EXPIRED", InviteCodeAssigned, appID, now-1)
+	insertTestCode(t, "AVAILABLE", InviteCodeAvailable, 0, 0)
+	insertTestCode(t, "REVOKED", InviteCodeRevoked, 0, 0)
+
+	redeemed, skipped, err := MarkInviteCodesRedeemed([]string{"ASSIGNED", "ASSIGNED-VALID", "ASSIGNED-EXPIRED", "AVAILABLE", "REVOKED", "MISSING"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(redeemed) != 2 || redeemed[0] != "ASSIGNED" || redeemed[1] != "ASSIGNED-VALID" {
+		t.Errorf("redeemed = %v", redeemed)
+	}
+	if len(skipped) != 4 || skipped[0] != "ASSIGNED-EXPIRED" {
+		t.Errorf("skipped = %v", skipped)
+	}
+	if status, _ := codeState(t, "ASSIGNED-EXPIRED"); status != InviteCodeAssigned {
+		t.Errorf("expired code status = %s, want unchanged", status)
+	}
+}
+
+func TestRevokeInviteCode(t *testing.T) {
+	setupTestDB(t)
+	appID := insertTestApplication(t, "a@example.com")
+	insertTestCode(t, "ASSIGNED", InviteCodeAssigned, appID, 0)
+	var id int
+	database.DB.QueryRow("SELECT id FROM invitation_codes WHERE code = 'ASSIGNED'").Scan(&id)
+
+	owner, code, err := RevokeInviteCode(id)
+	if err != nil || code != "ASSIGNED" || !owner.Valid || int(owner.Int64) != appID {
+		t.Fatalf("revoke assigned code: owner %v, code %q, err %v", owner, code, err)
+	}
+	var status string
+	database.DB.QueryRow("SELECT status FROM invitation_codes WHERE id = ?", id).Scan(&status)
+	if status != InviteCodeRevoked {
+		t.Errorf("status after revoke = %s, want %s", status, InviteCodeRevoked)
+	}
+
+	if _, _, err := RevokeInviteCode(id); err != ErrInviteCodeUnavailable {
+		t.Errorf("revoke twice: err = %v, want %v", err, ErrInviteCodeUnavailable)
+	}
+	if _, _, err := RevokeInviteCode(9999); err != sql.ErrNoRows {
+		t.Errorf("revoke missing code: err = %v, want %v", err, sql.ErrNoRows)
+	}
+}
+
+func TestImportInviteCodesWithoutExpiry(t *testing.T) {
+	setupTestDB(t)
+	tx, err := database.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ImportInviteCodes(tx, []string{"NO-EXPIRY"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 永不过期存储为 NULL，列表状态与分配、核销对其含义一致
+	var expiresAt sql.NullInt64
+	var status string
+	database.DB.QueryRow(
+		"SELECT expires_at, "+InviteCodeStatusSQL+" FROM invitation_codes WHERE code = 'NO-EXPIRY'", time.Now().Unix(),
+	).Scan(&expiresAt, &status)
+	if expiresAt.Valid || status != InviteCodeAvailable {
+		t.Errorf("imported code: expires_at %v, status %s", expiresAt, status)
+	}
+}
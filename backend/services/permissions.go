@@ -0,0 +1,223 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"invite-backend/database"
+)
+
+// 权限名称
+const (
+	PermApplicationsReview  = "applications.review"
+	PermApplicationsDelete  = "applications.delete"
+	PermSettingsWrite       = "settings.write"
+	PermAdminsManage        = "admins.manage"
+	PermAnnouncementsManage = "announcements.manage"
+	PermAuditRead           = "audit.read"
+	PermCodesImport         = "codes.import"
+	PermTemplatesManage     = "templates.manage"
+	PermAppealsReview       = "appeals.review"
+	PermBlocklistManage     = "blocklist.manage"
+)
+
+// RoleSuper 超级管理员角色，始终拥有全部权限且不可修改
+const RoleSuper = "super"
+
+// Permission 权限说明
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// AllPermissions 系统支持的全部权限
+var AllPermissions = []Permission{
+	{PermApplicationsReview, "查看与审核申请"},
+	{PermApplicationsDelete, "删除申请"},
+	{PermSettingsWrite, "查看与修改系统设置"},
+	{PermAdminsManage, "管理人员与角色"},
+	{PermAnnouncementsManage, "管理系统公告"},
+	{PermAuditRead, "查看审核日志"},
+	{PermCodesImport, "管理邀请码库存"},
+	{PermTemplatesManage, "管理审核意见模板"},
+	{PermAppealsReview, "处理申请人申诉"},
+	{PermBlocklistManage, "添加或移除临时邮箱黑名单域名"},
+}
+
+// Role 角色及其权限
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Builtin     bool     `json:"builtin"`
+	AdminCount  int      `json:"adminCount"`
+}
+
+// 角色错误
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleBuiltin       = errors.New("builtin role cannot be modified")
+	ErrRoleInUse         = errors.New("role is assigned to admins")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+// IsValidPermission 判断权限名称是否存在
+func IsValidPermission(name string) bool {
+	for _, p := range AllPermissions {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRolePermissions 获取角色拥有的权限集合
+func GetRolePermissions(role string) (map[string]bool, error) {
+	perms := make(map[string]bool)
+	if role == RoleSuper {
+		for _, p := range AllPermissions {
+			perms[p.Name] = true
+		}
+		return perms, nil
+	}
+
+	rows, err := database.DB.Query("SELECT permission FROM role_permissions WHERE role_name = ?", role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err == nil {
+			perms[p] = true
+		}
+	}
+	return perms, nil
+}
+
+// RoleExists 判断角色是否存在
+func RoleExists(name string) bool {
+	var count int
+	database.DB.QueryRow("SELECT COUNT(*) FROM roles WHERE name = ?", name).Scan(&count)
+	return count > 0
+}
+
+// ListRoles 获取全部角色
+func ListRoles() ([]Role, error) {
+	rows, err := database.DB.Query(`
+		SELECT r.name, r.description, r.builtin, (SELECT COUNT(*) FROM admins a WHERE a.role = r.name)
+		FROM roles r
+		ORDER BY r.builtin DESC, r.created_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]Role, 0)
+	for rows.Next() {
+		var r Role
+		var description sql.NullString
+		if err := rows.Scan(&r.Name, &description, &r.Builtin, &r.AdminCount); err != nil {
+			continue
+		}
+		r.Description = description.String
+		roles = append(roles, r)
+	}
+	rows.Close()
+
+	for i := range roles {
+		perms, err := GetRolePermissions(roles[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		roles[i].Permissions = make([]string, 0, len(perms))
+		for _, p := range AllPermissions {
+			if perms[p.Name] {
+				roles[i].Permissions = append(roles[i].Permissions, p.Name)
+			}
+		}
+	}
+
+	return roles, nil
+}
+
+// SaveRole 新建或更新自定义角色
+func SaveRole(name, description string, permissions []string, create bool) error {
+	for _, p := range permissions {
+		if !IsValidPermission(p) {
+			return ErrUnknownPermission
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	if create {
+		if _, err := tx.Exec(
+			"INSERT INTO roles (name, description, builtin, created_at, updated_at) VALUES (?, ?, 0, ?, ?)",
+			name, description, now, now,
+		); err != nil {
+			return err
+		}
+	} else {
+		var builtin bool
+		if err := tx.QueryRow("SELECT builtin FROM roles WHERE name = ?", name).Scan(&builtin); err != nil {
+			return ErrRoleNotFound
+		}
+		if name == RoleSuper {
+			return ErrRoleBuiltin
+		}
+		if _, err := tx.Exec("UPDATE roles SET description = ?, updated_at = ? WHERE name = ?", description, now, name); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM role_permissions WHERE role_name = ?", name); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range permissions {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO role_permissions (role_name, permission) VALUES (?, ?)", name, p); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteRole 删除自定义角色（内置角色及仍有人员使用的角色不可删除）
+func DeleteRole(name string) error {
+	var builtin bool
+	if err := database.DB.QueryRow("SELECT builtin FROM roles WHERE name = ?", name).Scan(&builtin); err != nil {
+		return ErrRoleNotFound
+	}
+	if builtin {
+		return ErrRoleBuiltin
+	}
+
+	var count int
+	database.DB.QueryRow("SELECT COUNT(*) FROM admins WHERE role = ?", name).Scan(&count)
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM role_permissions WHERE role_name = ?", name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM roles WHERE name = ?", name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
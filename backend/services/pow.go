@@ -0,0 +1,96 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"invite-backend/database"
+)
+
+// PoW 挑战参数
+const (
+	powChallengeTTL  = 5 * time.Minute
+	MaxPowDifficulty = 8 // 人工固定或环境变量设置的难度上限
+	// MaxAdaptivePowDifficulty 自动加成后的难度上限，浏览器求解 6 位前导零平均约需 1600 万次哈希，
+	// 再高会让共用出口 IP 的正常用户长时间无法提交；人工固定难度不受此限制
+	MaxAdaptivePowDifficulty = 6
+)
+
+// PoW 错误
+var (
+	ErrPowChallengeInvalid = errors.New("pow challenge invalid or expired")
+	ErrPowSolutionInvalid  = errors.New("pow solution does not meet difficulty")
+)
+
+// PowChallenge 下发给客户端的 PoW 挑战
+// 客户端需要找到 nonce，使 sha256(salt:fingerprint:nonce) 的十六进制结果以 difficulty 个 0 开头
+type PowChallenge struct {
+	Salt       string    `json:"salt"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// IssuePowChallenge 为设备指纹签发 PoW 挑战并持久化
+func IssuePowChallenge(fingerprint, ip string) (PowChallenge, error) {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	now := time.Now()
+	challenge := PowChallenge{
+		Salt:       hex.EncodeToString(b),
+		Difficulty: powDifficultyFor(fingerprint, ip),
+		ExpiresAt:  time.Unix(now.Add(powChallengeTTL).Unix(), 0),
+	}
+
+	if _, err := database.DB.Exec(
+		"INSERT INTO pow_challenges (salt, fingerprint, ip, difficulty, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		challenge.Salt, fingerprint, ip, challenge.Difficulty, now.Unix(), challenge.ExpiresAt.Unix(),
+	); err != nil {
+		return PowChallenge{}, err
+	}
+
+	// 顺带清理超过保留时长的挑战，保留期内的记录用于绘制难度曲线
+	_, _ = database.DB.Exec("DELETE FROM pow_challenges WHERE created_at < ?", now.Add(-powHistoryRetention).Unix())
+
+	return challenge, nil
+}
+
+// VerifyPowSolution 校验 PoW 解答并将挑战标记为已使用
+// 挑战必须由同一设备指纹获取、未过期且未被使用过
+func VerifyPowSolution(fingerprint, salt string, nonce int64) error {
+	var difficulty int
+	err := database.DB.QueryRow(
+		"SELECT difficulty FROM pow_challenges WHERE salt = ? AND fingerprint = ? AND used_at IS NULL AND expires_at > ?",
+		salt, fingerprint, time.Now().Unix(),
+	).Scan(&difficulty)
+	if err != nil {
+		return ErrPowChallengeInvalid
+	}
+
+	if !powHashMeets(salt, fingerprint, nonce, difficulty) {
+		return ErrPowSolutionInvalid
+	}
+
+	// 条件更新保证并发提交同一解答时只有一个请求通过
+	res, err := database.DB.Exec(
+		"UPDATE pow_challenges SET used_at = ? WHERE salt = ? AND used_at IS NULL", time.Now().Unix(), salt,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return ErrPowChallengeInvalid
+	}
+	return nil
+}
+
+// powHashMeets 判断解答的哈希是否满足难度要求
+func powHashMeets(salt, fingerprint string, nonce int64, difficulty int) bool {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d", salt, fingerprint, nonce)))
+	return strings.HasPrefix(hex.EncodeToString(sum[:]), strings.Repeat("0", difficulty))
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"invite-backend/config"
+	"invite-backend/database"
+)
+
+// 自适应难度参数
+const (
+	powVolumeWindow       = 10 * time.Minute   // 统计全局提交量的时间窗口
+	powFailureWindow      = time.Hour          // 统计 IP/设备校验失败次数的时间窗口
+	powRejectionWindow    = 7 * 24 * time.Hour // 统计 IP/设备被拒绝申请的时间窗口
+	powHistoryRetention   = 24 * time.Hour     // 挑战记录与校验失败记录的保留时长，用于绘制难度曲线
+	defaultPowSurgeVolume = 30                 // 时间窗口内的提交量达到该值视为流量激增
+	powCurveBucket        = 10 * time.Minute
+)
+
+// 校验失败类型
+const (
+	SecurityFailurePow     = "pow"
+	SecurityFailureCaptcha = "captcha"
+	SecurityFailureCode    = "code"
+)
+
+// PowDifficultyPoint 难度曲线上的一个时间段
+type PowDifficultyPoint struct {
+	Time          time.Time `json:"time"`
+	Challenges    int       `json:"challenges"`
+	AvgDifficulty float64   `json:"avgDifficulty"`
+	MaxDifficulty int       `json:"maxDifficulty"`
+}
+
+// PowStatus 当前的难度状态
+type PowStatus struct {
+	Base        int                  `json:"base"`        // 环境变量设置的基础难度
+	Override    *int                 `json:"override"`    // 人工固定的难度，为空表示自动调整
+	Current     int                  `json:"current"`     // 当前对普通访客生效的难度
+	Volume      int                  `json:"volume"`      // 最近时间窗口内的提交量
+	SurgeVolume int                  `json:"surgeVolume"` // 流量激增阈值
+	Max         int                  `json:"max"`
+	Curve       []PowDifficultyPoint `json:"curve"`
+}
+
+// RecordSecurityFailure 记录一次校验失败，用于提高该 IP/设备后续挑战的难度
+func RecordSecurityFailure(kind, ip, fingerprint string) {
+	now := time.Now()
+	_, _ = database.DB.Exec(
+		"INSERT INTO security_failures (kind, ip, fingerprint, created_at) VALUES (?, ?, ?, ?)",
+		kind, ip, fingerprint, now.Unix(),
+	)
+	_, _ = database.DB.Exec("DELETE FROM security_failures WHERE created_at < ?", now.Add(-powHistoryRetention).Unix())
+}
+
+// PowDifficultyOverride 获取人工固定的难度，未固定时返回 false
+func PowDifficultyOverride() (int, bool) {
+	settings, _ := GetSystemSettings()
+	value := strings.TrimSpace(settings["pow_difficulty_override"])
+	if value == "" {
+		return 0, false
+	}
+	difficulty, err := strconv.Atoi(value)
+	if err != nil || difficulty < 0 {
+		return 0, false
+	}
+	return clampPowDifficulty(difficulty), true
+}
+
+// SetPowDifficultyOverride 固定难度，difficulty 为空时恢复自动调整
+func SetPowDifficultyOverride(difficulty *int) error {
+	value := ""
+	if difficulty != nil {
+		value = strconv.Itoa(clampPowDifficulty(*difficulty))
+	}
+	return UpdateSettings(map[string]string{"pow_difficulty_override": value})
+}
+
+// powDifficultyFor 计算签发给指定设备与 IP 的挑战难度：
+// 基础难度叠加全局流量加成与该 IP/设备的可疑程度加成，加成随统计窗口滑动自然回落
+func powDifficultyFor(fingerprint, ip string) int {
+	if difficulty, ok := PowDifficultyOverride(); ok {
+		return difficulty
+	}
+	globalDifficulty, _, _ := globalPowDifficulty()
+	return capAdaptivePowDifficulty(globalDifficulty + suspicionBoost(fingerprint, ip))
+}
+
+// globalPowDifficulty 根据最近的全局提交量计算难度，同时返回提交量与激增阈值
+func globalPowDifficulty() (difficulty, volume, surge int) {
+	settings, _ := GetSystemSettings()
+	surge, err := strconv.Atoi(settings["pow_surge_volume"])
+	if err != nil || surge <= 0 {
+		surge = defaultPowSurgeVolume
+	}
+
+	since := time.Now().Add(-powVolumeWindow).Unix()
+	var applications, codes int
+	database.DB.QueryRow("SELECT COUNT(*) FROM applications WHERE created_at >= ?", since).Scan(&applications)
+	database.DB.QueryRow("SELECT COUNT(*) FROM verification_codes WHERE created_at >= ?", since).Scan(&codes)
+	volume = applications + codes
+
+	difficulty = config.AppConfig.PowDifficulty
+	switch {
+	case volume >= surge*4:
+		difficulty += 3
+	case volume >= surge*2:
+		difficulty += 2
+	case volume >= surge:
+		difficulty++
+	}
+	return capAdaptivePowDifficulty(difficulty), volume, surge
+}
+
+// suspicionBoost 根据 IP/设备最近的校验失败次数与被拒绝的申请计算难度加成
+func suspicionBoost(fingerprint, ip string) int {
+	now := time.Now()
+	var failures, rejections int
+	database.DB.QueryRow(
+		"SELECT COUNT(*) FROM security_failures WHERE (ip = ? OR fingerprint = ?) AND created_at >= ?",
+		ip, fingerprint, now.Add(-powFailureWindow).Unix(),
+	).Scan(&failures)
+	database.DB.QueryRow(
+		"SELECT COUNT(*) FROM applications WHERE (ip = ? OR device_id = ?) AND status = 'rejected' AND updated_at >= ?",
+		ip, fingerprint, now.Add(-powRejectionWindow).Unix(),
+	).Scan(&rejections)
+
+	boost := 0
+	switch {
+	case failures >= 10:
+		boost += 2
+	case failures >= 3:
+		boost++
+	}
+	if rejections > 0 {
+		boost++
+	}
+	return boost
+}
+
+// capAdaptivePowDifficulty 限制自动加成后的难度，基础难度本身高于上限时以基础难度为准
+func capAdaptivePowDifficulty(difficulty int) int {
+	limit := MaxAdaptivePowDifficulty
+	if base := clampPowDifficulty(config.AppConfig.PowDifficulty); base > limit {
+		limit = base
+	}
+	if difficulty > limit {
+		difficulty = limit
+	}
+	return clampPowDifficulty(difficulty)
+}
+
+func clampPowDifficulty(difficulty int) int {
+	if difficulty < 0 {
+		return 0
+	}
+	if difficulty > MaxPowDifficulty {
+		return MaxPowDifficulty
+	}
+	return difficulty
+}
+
+// GetPowStatus 获取当前难度状态与最近 hours 小时的难度曲线（按实际签发的挑战统计）
+func GetPowStatus(hours int) (PowStatus, error) {
+	current, volume, surge := globalPowDifficulty()
+	status := PowStatus{
+		Base:        clampPowDifficulty(config.AppConfig.PowDifficulty),
+		Current:     current,
+		Volume:      volume,
+		SurgeVolume: surge,
+		Max:         MaxPowDifficulty,
+		Curve:       make([]PowDifficultyPoint, 0),
+	}
+	if difficulty, ok := PowDifficultyOverride(); ok {
+		status.Override = &difficulty
+		status.Current = difficulty
+	}
+
+	bucket := int64(powCurveBucket.Seconds())
+	rows, err := database.DB.Query(`
+		SELECT created_at / ? * ? AS bucket, COUNT(*), AVG(difficulty), MAX(difficulty)
+		FROM pow_challenges
+		WHERE created_at >= ?
+		GROUP BY bucket
+		ORDER BY bucket ASC
+	`, bucket, bucket, time.Now().Add(-time.Duration(hours)*time.Hour).Unix())
+	if err != nil {
+		return status, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p PowDifficultyPoint
+		var at int64
+		if err := rows.Scan(&at, &p.Challenges, &p.AvgDifficulty, &p.MaxDifficulty); err != nil {
+			continue
+		}
+		p.Time = time.Unix(at, 0)
+		status.Curve = append(status.Curve, p)
+	}
+	return status, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"invite-backend/config"
+	"invite-backend/database"
+)
+
+func TestPowDifficultyAdaptiveCap(t *testing.T) {
+	setupTestDB(t)
+	config.AppConfig.PowDifficulty = 4
+	setTestSetting(t, "pow_surge_volume", "1")
+
+	if got := powDifficultyFor("device-1", "10.0.0.1"); got != 4 {
+		t.Fatalf("quiet difficulty = %d, want base 4", got)
+	}
+
+	// 流量激增 +3，校验失败 10 次 +2，近期被拒绝 +1，合计远超上限
+	now := time.Now().Unix()
+	for i := 0; i < 4; i++ {
+		insertTestApplication(t, "surge@example.com")
+	}
+	if _, err := database.DB.Exec(
+		"INSERT INTO applications (email, reason, device_id, ip, status, updated_at) VALUES ('r@example.com', 'reason', 'device-1', '10.0.0.1', 'rejected', ?)", now,
+	); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		RecordSecurityFailure(SecurityFailurePow, "10.0.0.1", "device-1")
+	}
+
+	if got := powDifficultyFor("device-1", "10.0.0.1"); got != MaxAdaptivePowDifficulty {
+		t.Errorf("suspicious difficulty = %d, want capped at %d", got, MaxAdaptivePowDifficulty)
+	}
+	if status, err := GetPowStatus(1); err != nil || status.Current != MaxAdaptivePowDifficulty {
+		t.Errorf("status current = %d, %v; want %d", status.Current, err, MaxAdaptivePowDifficulty)
+	}
+
+	// 基础难度本身高于上限时不降低
+	config.AppConfig.PowDifficulty = 7
+	if got := powDifficultyFor("device-1", "10.0.0.1"); got != 7 {
+		t.Errorf("difficulty with base 7 = %d, want 7", got)
+	}
+
+	// 人工固定难度不受自动上限限制
+	fixed := MaxPowDifficulty
+	if err := SetPowDifficultyOverride(&fixed); err != nil {
+		t.Fatal(err)
+	}
+	if got := powDifficultyFor("device-2", "10.0.0.2"); got != MaxPowDifficulty {
+		t.Errorf("override difficulty = %d, want %d", got, MaxPowDifficulty)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"invite-backend/database"
+)
+
+// solvePow 暴力搜索满足难度的 nonce
+func solvePow(t *testing.T, salt, fingerprint string, difficulty int) int64 {
+	t.Helper()
+	for nonce := int64(0); nonce < 1<<24; nonce++ {
+		if powHashMeets(salt, fingerprint, nonce, difficulty) {
+			return nonce
+		}
+	}
+	t.Fatalf("no nonce found for difficulty %d", difficulty)
+	return 0
+}
+
+func TestPowHashMeets(t *testing.T) {
+	salt, fingerprint := "0123456789abcdef", "device-1"
+	nonce := solvePow(t, salt, fingerprint, 3)
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d", salt, fingerprint, nonce)))
+	digest := hex.EncodeToString(sum[:])
+	if !strings.HasPrefix(digest, "000") {
+		t.Fatalf("hash %s does not have 3 leading zeros", digest)
+	}
+	zeros := len(digest) - len(strings.TrimLeft(digest, "0"))
+
+	for difficulty := 0; difficulty <= zeros; difficulty++ {
+		if !powHashMeets(salt, fingerprint, nonce, difficulty) {
+			t.Errorf("difficulty %d should be met by %s", difficulty, digest)
+		}
+	}
+	if powHashMeets(salt, fingerprint, nonce, zeros+1) {
+		t.Errorf("difficulty %d should not be met by %s", zeros+1, digest)
+	}
+}
+
+func TestVerifyPowSolution(t *testing.T) {
+	setupTestDB(t)
+	now := time.Now().Unix()
+	insert := func(salt, fingerprint string, expiresAt int64) {
+		if _, err := database.DB.Exec(
+			"INSERT INTO pow_challenges (salt, fingerprint, ip, difficulty, created_at, expires_at) VALUES (?, ?, '127.0.0.1', 2, ?, ?)",
+			salt, fingerprint, now, expiresAt,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert("fresh", "device-1", now+300)
+	insert("expired", "device-1", now-1)
+
+	nonce := solvePow(t, "fresh", "device-1", 2)
+	wrongNonce := nonce + 1
+	for powHashMeets("fresh", "device-1", wrongNonce, 2) {
+		wrongNonce++
+	}
+
+	if err := VerifyPowSolution("device-1", "fresh", wrongNonce); err != ErrPowSolutionInvalid {
+		t.Errorf("wrong nonce: err = %v, want %v", err, ErrPowSolutionInvalid)
+	}
+	if err := VerifyPowSolution("device-2", "fresh", nonce); err != ErrPowChallengeInvalid {
+		t.Errorf("other fingerprint: err = %v, want %v", err, ErrPowChallengeInvalid)
+	}
+	if err := VerifyPowSolution("device-1", "unknown", nonce); err != ErrPowChallengeInvalid {
+		t.Errorf("unknown salt: err = %v, want %v", err, ErrPowChallengeInvalid)
+	}
+	if err := VerifyPowSolution("device-1", "expired", solvePow(t, "expired", "device-1", 2)); err != ErrPowChallengeInvalid {
+		t.Errorf("expired challenge: err = %v, want %v", err, ErrPowChallengeInvalid)
+	}
+
+	if err := VerifyPowSolution("device-1", "fresh", nonce); err != nil {
+		t.Fatalf("valid solution: err = %v", err)
+	}
+	if err := VerifyPowSolution("device-1", "fresh", nonce); err != ErrPowChallengeInvalid {
+		t.Errorf("reused challenge: err = %v, want %v", err, ErrPowChallengeInvalid)
+	}
+}
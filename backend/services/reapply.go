@@ -0,0 +1,97 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"invite-backend/database"
+)
+
+// 重新申请冷却默认参数，两项均为 0 时不允许被拒绝的邮箱重新申请，保持升级前的行为
+const (
+	defaultReapplyCooldownDays = 0
+	defaultMaxRejections       = 0
+)
+
+// ReapplyPolicy 被拒绝后重新申请的限制
+type ReapplyPolicy struct {
+	Cooldown      time.Duration // 最近一次被拒绝后需要等待的时长，0 表示可立即重新申请
+	MaxRejections int           // 累计被拒绝次数上限，0 表示不限制
+}
+
+// Enabled 判断是否允许被拒绝的邮箱重新申请，冷却与次数上限均未设置时不允许
+func (p ReapplyPolicy) Enabled() bool {
+	return p.Cooldown > 0 || p.MaxRejections > 0
+}
+
+// ReapplyStatus 邮箱当前的重新申请资格
+type ReapplyStatus struct {
+	Rejections int
+	AllowedAt  time.Time // 可重新申请的时间，零值表示无需等待
+	Exhausted  bool      // 被拒绝次数已达上限，不可再申请
+	Disabled   bool      // 未开启重新申请，被拒绝的邮箱不可再申请
+}
+
+// Blocked 判断当前是否不能重新申请
+func (s ReapplyStatus) Blocked() bool {
+	return s.Disabled || s.Exhausted || time.Now().Before(s.AllowedAt)
+}
+
+// GetReapplyPolicy 从系统设置读取重新申请限制，未设置或格式错误时使用默认值
+func GetReapplyPolicy() ReapplyPolicy {
+	settings, _ := GetSystemSettings()
+
+	days, err := strconv.Atoi(settings["reapply_cooldown_days"])
+	if err != nil || days < 0 {
+		days = defaultReapplyCooldownDays
+	}
+	maxRejections, err := strconv.Atoi(settings["max_rejections_per_email"])
+	if err != nil || maxRejections < 0 {
+		maxRejections = defaultMaxRejections
+	}
+
+	return ReapplyPolicy{
+		Cooldown:      time.Duration(days) * 24 * time.Hour,
+		MaxRejections: maxRejections,
+	}
+}
+
+// GetReapplyStatus 根据邮箱被拒绝的次数与最近一次被拒绝的时间计算重新申请资格
+func GetReapplyStatus(email string) (ReapplyStatus, error) {
+	var status ReapplyStatus
+	var lastRejectedAt sql.NullInt64
+	err := database.DB.QueryRow(
+		"SELECT COUNT(*), MAX(updated_at) FROM applications WHERE email = ? AND status = 'rejected'", email,
+	).Scan(&status.Rejections, &lastRejectedAt)
+	if err != nil || status.Rejections == 0 {
+		return status, err
+	}
+
+	policy := GetReapplyPolicy()
+	if !policy.Enabled() {
+		status.Disabled = true
+		return status, nil
+	}
+	status.Exhausted = policy.MaxRejections > 0 && status.Rejections >= policy.MaxRejections
+	if policy.Cooldown > 0 && lastRejectedAt.Valid {
+		status.AllowedAt = time.Unix(lastRejectedAt.Int64, 0).Add(policy.Cooldown)
+	}
+	return status, nil
+}
+
+// ReapplyNotice 生成告知申请人何时可以重新申请的文字，没有限制或未开启重新申请时返回空字符串
+func ReapplyNotice(email string) string {
+	status, err := GetReapplyStatus(email)
+	if err != nil || status.Disabled {
+		return ""
+	}
+	if status.Exhausted {
+		return "该邮箱被拒绝的次数已达上限，无法再次申请"
+	}
+	if !status.AllowedAt.IsZero() {
+		return fmt.Sprintf("您可于 %s 后重新申请", status.AllowedAt.Format("2006-01-02 15:04"))
+	}
+	return ""
+}
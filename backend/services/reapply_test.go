@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"invite-backend/database"
+)
+
+func TestGetReapplyStatus(t *testing.T) {
+	setupTestDB(t)
+	rejectedAt := time.Now().Add(-24 * time.Hour).Unix()
+	for _, status := range []string{"rejected", "rejected", "withdrawn"} {
+		if _, err := database.DB.Exec(
+			"INSERT INTO applications (email, reason, device_id, ip, status, updated_at) VALUES ('a@example.com', 'reason', 'd', '127.0.0.1', ?, ?)",
+			status, rejectedAt,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 默认两项均为 0：不开启重新申请，被拒绝的邮箱保持拦截
+	status, err := GetReapplyStatus("a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Disabled || !status.Blocked() || status.Exhausted || !status.AllowedAt.IsZero() {
+		t.Errorf("default status = %+v, want disabled and blocked", status)
+	}
+	if notice := ReapplyNotice("a@example.com"); notice != "" {
+		t.Errorf("default notice = %q, want empty", notice)
+	}
+
+	// 未被拒绝过的邮箱不受影响
+	if status, _ := GetReapplyStatus("b@example.com"); status.Blocked() {
+		t.Errorf("new email status = %+v, want not blocked", status)
+	}
+
+	setTestSetting(t, "reapply_cooldown_days", "2")
+	status, _ = GetReapplyStatus("a@example.com")
+	if status.Disabled || status.Exhausted || !status.Blocked() || status.AllowedAt.Unix() != rejectedAt+2*86400 {
+		t.Errorf("cooldown status = %+v", status)
+	}
+
+	setTestSetting(t, "reapply_cooldown_days", "1")
+	if status, _ := GetReapplyStatus("a@example.com"); status.Blocked() {
+		t.Errorf("status after cooldown = %+v, want not blocked", status)
+	}
+
+	// 撤回的申请不计入被拒绝次数
+	setTestSetting(t, "reapply_cooldown_days", "0")
+	setTestSetting(t, "max_rejections_per_email", "3")
+	if status, _ := GetReapplyStatus("a@example.com"); status.Rejections != 2 || status.Blocked() {
+		t.Errorf("status below limit = %+v", status)
+	}
+	setTestSetting(t, "max_rejections_per_email", "2")
+	if status, _ := GetReapplyStatus("a@example.com"); !status.Exhausted || !status.Blocked() {
+		t.Errorf("status at limit = %+v", status)
+	}
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"invite-backend/database"
+	"invite-backend/models"
+)
+
+// 近似重复检测参数：按字符 3-gram 切分理由，计算 64 维 MinHash 签名，
+// 再分为 16 个 band（每个 4 行）写入 LSH 索引。两段理由的 Jaccard 相似度为 s 时，
+// 至少有一个 band 相同的概率为 1-(1-s^4)^16，s=0.5 时约 64%，s=0.8 时接近 100%
+const (
+	shingleSize           = 3
+	minhashBands          = 16
+	minhashRows           = 4
+	minhashSize           = minhashBands * minhashRows
+	maxSimilarCandidates  = 200 // 单次比对的候选申请上限
+	maxStoredSimilar      = 5   // 每条申请保存的相似申请数
+	minStoredSimilarity   = 0.5 // 低于该相似度的不保存
+	defaultSimilarityFlag = 80  // 默认自动标记阈值（百分比）
+)
+
+// SimilarityFlagThreshold 从系统设置读取自动标记阈值（0-1），返回 0 表示不自动标记
+func SimilarityFlagThreshold(settings map[string]string) float64 {
+	percent, err := strconv.Atoi(settings["reason_similarity_threshold"])
+	if err != nil || percent < 0 || percent > 100 {
+		percent = defaultSimilarityFlag
+	}
+	return float64(percent) / 100
+}
+
+// FindSimilarReasons 在历史申请中查找与理由相似的申请，按相似度从高到低返回
+// excludeEmail 的申请（同一申请人的历次申请）不参与比对，beforeID 大于 0 时只比对更早提交的申请
+func FindSimilarReasons(reason, excludeEmail string, beforeID, limit int) ([]models.SimilarApplication, error) {
+	shingles := reasonShingles(reason)
+	items := make([]models.SimilarApplication, 0)
+	if len(shingles) == 0 {
+		return items, nil
+	}
+
+	buckets := lshBuckets(minhashSignature(shingles))
+	conds := make([]string, 0, len(buckets))
+	args := make([]interface{}, 0, len(buckets)*2+3)
+	for band, bucket := range buckets {
+		conds = append(conds, "(i.band = ? AND i.bucket = ?)")
+		args = append(args, band, bucket)
+	}
+	args = append(args, excludeEmail)
+	idCond := ""
+	if beforeID > 0 {
+		idCond = " AND a.id < ?"
+		args = append(args, beforeID)
+	}
+	args = append(args, maxSimilarCandidates)
+
+	rows, err := database.DB.Query(`
+		SELECT DISTINCT a.id, a.email, a.status, a.reason
+		FROM reason_lsh_index i
+		JOIN applications a ON a.id = i.application_id
+		WHERE (`+strings.Join(conds, " OR ")+`) AND a.email != ?`+idCond+`
+		ORDER BY a.id DESC
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.SimilarApplication
+		var candidate string
+		if err := rows.Scan(&s.ID, &s.Email, &s.Status, &candidate); err != nil {
+			continue
+		}
+		s.Similarity = jaccard(shingles, reasonShingles(candidate))
+		if s.Similarity >= minStoredSimilarity {
+			items = append(items, s)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Similarity > items[j].Similarity })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// IndexApplicationReason 将申请理由写入 LSH 索引，并保存与更早申请的相似度
+// 理由被修改后再次调用会重建该申请的索引
+func IndexApplicationReason(appID int) error {
+	var email, reason string
+	if err := database.DB.QueryRow("SELECT email, reason FROM applications WHERE id = ?", appID).Scan(&email, &reason); err != nil {
+		return ErrApplicationNotFound
+	}
+
+	similar, err := FindSimilarReasons(reason, email, appID, maxStoredSimilar)
+	if err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM reason_lsh_index WHERE application_id = ?", appID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM application_similarities WHERE application_id = ?", appID); err != nil {
+		return err
+	}
+	if err := insertReasonBuckets(tx, appID, reason); err != nil {
+		return err
+	}
+	for _, s := range similar {
+		if _, err := tx.Exec(
+			"INSERT INTO application_similarities (application_id, similar_id, similarity) VALUES (?, ?, ?)",
+			appID, s.ID, s.Similarity,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// BackfillReasonIndex 为尚未建立索引的历史申请写入 LSH 索引（不计算相似度）
+func BackfillReasonIndex() {
+	rows, err := database.DB.Query(`
+		SELECT id, reason FROM applications
+		WHERE id NOT IN (SELECT DISTINCT application_id FROM reason_lsh_index)
+	`)
+	if err != nil {
+		log.Printf("Failed to backfill reason index: %v", err)
+		return
+	}
+	type pending struct {
+		id     int
+		reason string
+	}
+	var apps []pending
+	for rows.Next() {
+		var p pending
+		if rows.Scan(&p.id, &p.reason) == nil {
+			apps = append(apps, p)
+		}
+	}
+	rows.Close()
+	if len(apps) == 0 {
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Printf("Failed to backfill reason index: %v", err)
+		return
+	}
+	defer tx.Rollback()
+	for _, p := range apps {
+		if err := insertReasonBuckets(tx, p.id, p.reason); err != nil {
+			log.Printf("Failed to backfill reason index: %v", err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Failed to backfill reason index: %v", err)
+		return
+	}
+	log.Printf("Indexed reasons of %d existing applications", len(apps))
+}
+
+// GetSimilarApplications 批量获取申请已保存的相似申请，每条最多 limit 个
+func GetSimilarApplications(appIDs []int, limit int) (map[int][]models.SimilarApplication, error) {
+	result := make(map[int][]models.SimilarApplication)
+	if len(appIDs) == 0 {
+		return result, nil
+	}
+
+	args := make([]interface{}, len(appIDs))
+	for i, id := range appIDs {
+		args[i] = id
+	}
+	rows, err := database.DB.Query(`
+		SELECT s.application_id, s.similar_id, a.email, a.status, s.similarity
+		FROM application_similarities s
+		JOIN applications a ON a.id = s.similar_id
+		WHERE s.application_id IN (?`+strings.Repeat(", ?", len(appIDs)-1)+`)
+		ORDER BY s.application_id, s.similarity DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var appID int
+		var s models.SimilarApplication
+		if err := rows.Scan(&appID, &s.ID, &s.Email, &s.Status, &s.Similarity); err != nil {
+			continue
+		}
+		if len(result[appID]) < limit {
+			result[appID] = append(result[appID], s)
+		}
+	}
+	return result, nil
+}
+
+func insertReasonBuckets(tx *sql.Tx, appID int, reason string) error {
+	shingles := reasonShingles(reason)
+	if len(shingles) == 0 {
+		return nil
+	}
+	for band, bucket := range lshBuckets(minhashSignature(shingles)) {
+		if _, err := tx.Exec(
+			"INSERT INTO reason_lsh_index (application_id, band, bucket) VALUES (?, ?, ?)", appID, band, bucket,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reasonShingles 将理由规范化（忽略大小写、空白与标点）后按字符 n-gram 切分并取哈希
+func reasonShingles(reason string) map[uint64]struct{} {
+	var runes []rune
+	for _, r := range strings.ToLower(reason) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+		}
+	}
+
+	shingles := make(map[uint64]struct{})
+	if len(runes) == 0 {
+		return shingles
+	}
+	if len(runes) < shingleSize {
+		shingles[hashString(string(runes))] = struct{}{}
+		return shingles
+	}
+	for i := 0; i+shingleSize <= len(runes); i++ {
+		shingles[hashString(string(runes[i:i+shingleSize]))] = struct{}{}
+	}
+	return shingles
+}
+
+// minhashSignature 对每个哈希函数取所有 shingle 的最小值
+func minhashSignature(shingles map[uint64]struct{}) []uint64 {
+	signature := make([]uint64, minhashSize)
+	for i := range signature {
+		signature[i] = ^uint64(0)
+	}
+	for shingle := range shingles {
+		for i := range signature {
+			if h := splitmix64(shingle + uint64(i)*0x9e3779b97f4a7c15); h < signature[i] {
+				signature[i] = h
+			}
+		}
+	}
+	return signature
+}
+
+// lshBuckets 将签名按 band 分组，每组哈希为一个桶号
+func lshBuckets(signature []uint64) []int64 {
+	buckets := make([]int64, minhashBands)
+	buf := make([]byte, 8)
+	for band := range buckets {
+		h := fnv.New64a()
+		for _, v := range signature[band*minhashRows : (band+1)*minhashRows] {
+			binary.LittleEndian.PutUint64(buf, v)
+			h.Write(buf)
+		}
+		buckets[band] = int64(h.Sum64())
+	}
+	return buckets
+}
+
+func jaccard(a, b map[uint64]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for k := range a {
+		if _, ok := b[k]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"invite-backend/database"
+)
+
+func TestReasonShinglesNormalization(t *testing.T) {
+	a := reasonShingles("Hello, World! 你好")
+	b := reasonShingles("hello world   你好。")
+	if len(a) == 0 || jaccard(a, b) != 1 {
+		t.Errorf("case, whitespace and punctuation should be ignored, jaccard = %v", jaccard(a, b))
+	}
+
+	if got := reasonShingles(" ,.!? "); len(got) != 0 {
+		t.Errorf("punctuation only should produce no shingles, got %d", len(got))
+	}
+	if got := reasonShingles("ab"); len(got) != 1 {
+		t.Errorf("text shorter than a shingle should produce one shingle, got %d", len(got))
+	}
+	// abcab -> abc, bca, cab
+	if got := reasonShingles("abcab"); len(got) != 3 {
+		t.Errorf("got %d shingles, want 3", len(got))
+	}
+	// 重复的 n-gram 只计一次
+	if got := reasonShingles("aaaaaa"); len(got) != 1 {
+		t.Errorf("got %d shingles, want 1", len(got))
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	a := reasonShingles("abcdef")     // abc bcd cde def
+	b := reasonShingles("abcdefgh")   // abc bcd cde def efg fgh
+	c := reasonShingles("uvwxyz0123") // 与 a 无交集
+
+	tests := []struct {
+		name string
+		x, y map[uint64]struct{}
+		want float64
+	}{
+		{"identical", a, a, 1},
+		{"subset", a, b, 4.0 / 6.0},
+		{"disjoint", a, c, 0},
+		{"empty", a, map[uint64]struct{}{}, 0},
+		{"both empty", map[uint64]struct{}{}, map[uint64]struct{}{}, 0},
+	}
+	for _, tt := range tests {
+		if got := jaccard(tt.x, tt.y); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: jaccard = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := jaccard(tt.y, tt.x); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: jaccard is not symmetric", tt.name)
+		}
+	}
+}
+
+func TestMinhashEstimatesJaccard(t *testing.T) {
+	base := "我是一名独立开发者，长期关注开源社区，希望使用这个平台来管理我的多个开源项目并和其他贡献者协作，提升开发效率。"
+	edited := "我是一名独立开发者，长期关注开源社区，希望使用这个平台来管理我的开源项目并和团队成员协作，提升整体开发效率。"
+	a, b := reasonShingles(base), reasonShingles(edited)
+	sigA, sigB := minhashSignature(a), minhashSignature(b)
+	if len(sigA) != minhashSize {
+		t.Fatalf("signature size = %d, want %d", len(sigA), minhashSize)
+	}
+
+	equal := 0
+	for i := range sigA {
+		if sigA[i] == sigB[i] {
+			equal++
+		}
+	}
+	estimate := float64(equal) / float64(minhashSize)
+	// 64 个哈希函数的估计标准差约为 0.06
+	if exact := jaccard(a, b); math.Abs(estimate-exact) > 0.2 {
+		t.Errorf("minhash estimate %.2f too far from jaccard %.2f", estimate, exact)
+	}
+}
+
+func TestLSHBuckets(t *testing.T) {
+	reason := "希望使用这个平台来管理我的多个开源项目"
+	a := lshBuckets(minhashSignature(reasonShingles(reason)))
+	b := lshBuckets(minhashSignature(reasonShingles(reason + "！")))
+	if len(a) != minhashBands {
+		t.Fatalf("got %d buckets, want %d", len(a), minhashBands)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("identical normalized text should share every bucket, band %d differs", i)
+		}
+	}
+
+	c := lshBuckets(minhashSignature(reasonShingles("完全不同的一段内容，用于验证分桶结果")))
+	shared := 0
+	for i := range a {
+		if a[i] == c[i] {
+			shared++
+		}
+	}
+	if shared == minhashBands {
+		t.Error("unrelated text should not share every bucket")
+	}
+}
+
+func TestSimilarityFlagThreshold(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"", 0.8},
+		{"abc", 0.8},
+		{"-1", 0.8},
+		{"101", 0.8},
+		{"0", 0},
+		{"65", 0.65},
+		{"100", 1},
+	}
+	for _, tt := range tests {
+		got := SimilarityFlagThreshold(map[string]string{"reason_similarity_threshold": tt.value})
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("SimilarityFlagThreshold(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFindSimilarReasons(t *testing.T) {
+	setupTestDB(t)
+	reason := "我是一名独立开发者，长期关注开源社区，希望使用这个平台来管理我的多个开源项目并和其他贡献者协作。"
+	apps := []struct {
+		email  string
+		reason string
+	}{
+		{"a@example.com", reason},
+		{"b@example.com", "我在一家初创公司负责后端开发，团队正在评估新的协作工具，希望获得邀请码进行试用。"},
+		{"c@example.com", reason + "谢谢！"},
+	}
+	for i, app := range apps {
+		if _, err := database.DB.Exec(
+			"INSERT INTO applications (email, reason, device_id, ip, status) VALUES (?, ?, ?, '127.0.0.1', 'pending')",
+			app.email, app.reason, app.email,
+		); err != nil {
+			t.Fatal(err)
+		}
+		if err := IndexApplicationReason(i + 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	similar, err := FindSimilarReasons(reason, "a@example.com", 0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(similar) != 1 || similar[0].ID != 3 || similar[0].Similarity < 0.8 {
+		t.Errorf("similar = %+v, want only application 3", similar)
+	}
+
+	// 第 3 条申请索引时只与更早的申请比对
+	stored, err := GetSimilarApplications([]int{1, 3}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored[1]) != 0 || len(stored[3]) != 1 || stored[3][0].ID != 1 {
+		t.Errorf("stored = %+v", stored)
+	}
+}
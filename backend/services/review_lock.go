@@ -0,0 +1,117 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"invite-backend/database"
+)
+
+// 默认认领锁定时长（分钟）
+const defaultReviewLockMinutes = 15
+
+// 认领错误
+var (
+	ErrApplicationNotFound = errors.New("application not found")
+	ErrApplicationLocked   = errors.New("application locked by another admin")
+	ErrApplicationDecided  = errors.New("application already decided")
+)
+
+// ApplicationLock 申请的认领锁
+type ApplicationLock struct {
+	AdminID     int       `json:"adminId"`
+	Username    string    `json:"username"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+// ReviewLockDuration 从系统设置读取认领锁定时长
+func ReviewLockDuration() time.Duration {
+	settings, _ := GetSystemSettings()
+	minutes, err := strconv.Atoi(settings["review_lock_minutes"])
+	if err != nil || minutes <= 0 {
+		minutes = defaultReviewLockMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// ClaimApplication 认领待审核或待复核的申请，在锁定期内只有认领人可以审核
+// 锁由他人持有且未过期时返回 ErrApplicationLocked 及当前持有人，申请已处理时返回 ErrApplicationDecided
+func ClaimApplication(appID, adminID int) (ApplicationLock, error) {
+	now := time.Now()
+	until := now.Add(ReviewLockDuration())
+
+	res, err := database.DB.Exec(`
+		UPDATE applications SET locked_by = ?, locked_until = ?
+		WHERE id = ? AND status IN ('pending', 'pending_second_review')
+			AND (locked_by IS NULL OR locked_until <= ? OR locked_by = ?)
+	`, adminID, until.Unix(), appID, now.Unix(), adminID)
+	if err != nil {
+		return ApplicationLock{}, err
+	}
+
+	if n, _ := res.RowsAffected(); n == 1 {
+		lock := ApplicationLock{AdminID: adminID, LockedUntil: time.Unix(until.Unix(), 0)}
+		database.DB.QueryRow("SELECT username FROM admins WHERE id = ?", adminID).Scan(&lock.Username)
+		return lock, nil
+	}
+
+	var status string
+	if err := database.DB.QueryRow("SELECT status FROM applications WHERE id = ?", appID).Scan(&status); err != nil {
+		return ApplicationLock{}, ErrApplicationNotFound
+	}
+	if status != "pending" && status != "pending_second_review" {
+		return ApplicationLock{}, ErrApplicationDecided
+	}
+
+	lock, held, err := GetApplicationLock(appID)
+	if err == sql.ErrNoRows {
+		return ApplicationLock{}, ErrApplicationNotFound
+	}
+	if err != nil {
+		return ApplicationLock{}, err
+	}
+	if !held {
+		// 锁恰好在两次查询之间过期，由调用方重试即可
+		return ApplicationLock{}, ErrApplicationLocked
+	}
+	return lock, ErrApplicationLocked
+}
+
+// ReleaseApplication 释放本人持有的认领锁
+func ReleaseApplication(appID, adminID int) (bool, error) {
+	res, err := database.DB.Exec(
+		"UPDATE applications SET locked_by = NULL, locked_until = NULL WHERE id = ? AND locked_by = ?",
+		appID, adminID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// GetApplicationLock 获取申请当前有效的认领锁
+func GetApplicationLock(appID int) (lock ApplicationLock, held bool, err error) {
+	var lockedBy, lockedUntil sql.NullInt64
+	var username sql.NullString
+	err = database.DB.QueryRow(`
+		SELECT a.locked_by, a.locked_until, ad.username
+		FROM applications a
+		LEFT JOIN admins ad ON a.locked_by = ad.id
+		WHERE a.id = ?
+	`, appID).Scan(&lockedBy, &lockedUntil, &username)
+	if err != nil {
+		return ApplicationLock{}, false, err
+	}
+
+	if !lockedBy.Valid || lockedUntil.Int64 <= time.Now().Unix() {
+		return ApplicationLock{}, false, nil
+	}
+	return ApplicationLock{
+		AdminID:     int(lockedBy.Int64),
+		Username:    username.String,
+		LockedUntil: time.Unix(lockedUntil.Int64, 0),
+	}, true, nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"invite-backend/database"
+)
+
+func TestClaimApplication(t *testing.T) {
+	setupTestDB(t)
+	res, err := database.DB.Exec("INSERT INTO admins (username, role) VALUES ('reviewer2', 'reviewer')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other64, _ := res.LastInsertId()
+	other := int(other64)
+	appID := insertTestApplication(t, "a@example.com")
+
+	lock, err := ClaimApplication(appID, 1)
+	if err != nil || lock.AdminID != 1 || lock.Username != "admin" {
+		t.Fatalf("first claim: %+v, %v", lock, err)
+	}
+	// 认领人可以续期，其他人拿到当前持有人
+	if _, err := ClaimApplication(appID, 1); err != nil {
+		t.Errorf("renew own claim: %v", err)
+	}
+	if lock, err := ClaimApplication(appID, other); err != ErrApplicationLocked || lock.AdminID != 1 {
+		t.Errorf("claim held by another admin: %+v, %v; want holder 1 and %v", lock, err, ErrApplicationLocked)
+	}
+
+	// 锁过期后可被他人接手
+	if _, err := database.DB.Exec("UPDATE applications SET locked_until = ? WHERE id = ?", time.Now().Unix()-1, appID); err != nil {
+		t.Fatal(err)
+	}
+	if lock, err := ClaimApplication(appID, other); err != nil || lock.AdminID != other {
+		t.Errorf("claim expired lock: %+v, %v", lock, err)
+	}
+
+	if released, err := ReleaseApplication(appID, 1); err != nil || released {
+		t.Errorf("release by non-holder: %v, %v", released, err)
+	}
+	if released, err := ReleaseApplication(appID, other); err != nil || !released {
+		t.Errorf("release by holder: %v, %v", released, err)
+	}
+
+	if _, err := database.DB.Exec("UPDATE applications SET status = 'approved' WHERE id = ?", appID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ClaimApplication(appID, 1); err != ErrApplicationDecided {
+		t.Errorf("claim decided application: err = %v, want %v", err, ErrApplicationDecided)
+	}
+	if _, err := ClaimApplication(9999, 1); err != ErrApplicationNotFound {
+		t.Errorf("claim missing application: err = %v, want %v", err, ErrApplicationNotFound)
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"invite-backend/database"
+)
+
+// 审核模板类型
+const (
+	TemplateKindApproval  = "approval"
+	TemplateKindRejection = "rejection"
+)
+
+// 审核模板错误
+var (
+	ErrTemplateNotFound     = errors.New("review template not found")
+	ErrTemplateKindMismatch = errors.New("review template kind does not match review status")
+)
+
+// ReviewTemplate 可复用的审核意见模板
+type ReviewTemplate struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Kind      string    `json:"kind"` // approval, rejection
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ReviewTemplateStat 模板使用统计
+type ReviewTemplateStat struct {
+	ID         int        `json:"id"`
+	Title      string     `json:"title"`
+	Kind       string     `json:"kind"`
+	TotalUses  int        `json:"totalUses"`
+	RecentUses int        `json:"recentUses"` // 统计周期内的使用次数
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+// IsValidTemplateKind 判断模板类型是否有效
+func IsValidTemplateKind(kind string) bool {
+	return kind == TemplateKindApproval || kind == TemplateKindRejection
+}
+
+// ListReviewTemplates 获取审核模板，kind 为空时返回全部
+func ListReviewTemplates(kind string) ([]ReviewTemplate, error) {
+	query := "SELECT id, title, body, kind, created_by, created_at, updated_at FROM review_templates"
+	var args []interface{}
+	if kind != "" {
+		query += " WHERE kind = ?"
+		args = append(args, kind)
+	}
+	query += " ORDER BY kind ASC, title ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := make([]ReviewTemplate, 0)
+	for rows.Next() {
+		var t ReviewTemplate
+		var createdBy sql.NullString
+		var createdAt, updatedAt int64
+		if err := rows.Scan(&t.ID, &t.Title, &t.Body, &t.Kind, &createdBy, &createdAt, &updatedAt); err != nil {
+			continue
+		}
+		t.CreatedBy = createdBy.String
+		t.CreatedAt = time.Unix(createdAt, 0)
+		t.UpdatedAt = time.Unix(updatedAt, 0)
+		templates = append(templates, t)
+	}
+
+	return templates, nil
+}
+
+// CreateReviewTemplate 新建审核模板
+func CreateReviewTemplate(title, body, kind, createdBy string) (int, error) {
+	now := time.Now().Unix()
+	res, err := database.DB.Exec(
+		"INSERT INTO review_templates (title, body, kind, created_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		title, body, kind, createdBy, now, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+// UpdateReviewTemplate 修改审核模板
+func UpdateReviewTemplate(id int, title, body, kind string) error {
+	res, err := database.DB.Exec(
+		"UPDATE review_templates SET title = ?, body = ?, kind = ?, updated_at = ? WHERE id = ?",
+		title, body, kind, time.Now().Unix(), id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// DeleteReviewTemplate 删除审核模板，已有的使用记录保留用于统计
+func DeleteReviewTemplate(id int) error {
+	res, err := database.DB.Exec("DELETE FROM review_templates WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// RenderReviewTemplate 按审核状态渲染模板，替换 {{email}} 与 {{site_name}} 占位符
+func RenderReviewTemplate(id int, status, email string) (string, error) {
+	var body, kind string
+	if err := database.DB.QueryRow("SELECT body, kind FROM review_templates WHERE id = ?", id).Scan(&body, &kind); err != nil {
+		return "", ErrTemplateNotFound
+	}
+	if (status == "approved") != (kind == TemplateKindApproval) {
+		return "", ErrTemplateKindMismatch
+	}
+
+	settings, _ := GetSystemSettings()
+	return strings.NewReplacer(
+		"{{email}}", email,
+		"{{site_name}}", settings["site_name"],
+	).Replace(body), nil
+}
+
+// RecordTemplateUsage 记录一次模板使用
+func RecordTemplateUsage(templateID, appID, adminID int) {
+	_, _ = database.DB.Exec(
+		"INSERT INTO review_template_usage (template_id, application_id, admin_id, used_at) VALUES (?, ?, ?, ?)",
+		templateID, appID, adminID, time.Now().Unix(),
+	)
+}
+
+// GetReviewTemplateStats 统计各模板的使用次数，按使用次数从高到低排序
+func GetReviewTemplateStats(days int) ([]ReviewTemplateStat, error) {
+	since := time.Now().AddDate(0, 0, -days).Unix()
+	rows, err := database.DB.Query(`
+		SELECT t.id, t.title, t.kind,
+			COUNT(u.id),
+			COALESCE(SUM(CASE WHEN u.used_at >= ? THEN 1 ELSE 0 END), 0),
+			MAX(u.used_at)
+		FROM review_templates t
+		LEFT JOIN review_template_usage u ON u.template_id = t.id
+		GROUP BY t.id
+		ORDER BY COUNT(u.id) DESC, t.id ASC
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]ReviewTemplateStat, 0)
+	for rows.Next() {
+		var s ReviewTemplateStat
+		var lastUsedAt sql.NullInt64
+		if err := rows.Scan(&s.ID, &s.Title, &s.Kind, &s.TotalUses, &s.RecentUses, &lastUsedAt); err != nil {
+			continue
+		}
+		if lastUsedAt.Valid {
+			t := time.Unix(lastUsedAt.Int64, 0)
+			s.LastUsedAt = &t
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import "testing"
+
+func TestRenderReviewTemplate(t *testing.T) {
+	setupTestDB(t)
+	setTestSetting(t, "site_name", "Star Moon")
+	approval, err := CreateReviewTemplate("welcome", "Hi {{email}}, welcome to {{site_name}}. {{email}}", TemplateKindApproval, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejection, err := CreateReviewTemplate("too short", "Reason too short", TemplateKindRejection, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := RenderReviewTemplate(approval, "approved", "a@example.com")
+	if want := "Hi a@example.com, welcome to Star Moon. a@example.com"; err != nil || got != want {
+		t.Errorf("render approval: %q, %v; want %q", got, err, want)
+	}
+	if got, err := RenderReviewTemplate(rejection, "rejected", "a@example.com"); err != nil || got != "Reason too short" {
+		t.Errorf("render rejection: %q, %v", got, err)
+	}
+
+	// 模板类型必须与审核结果一致
+	if _, err := RenderReviewTemplate(approval, "rejected", "a@example.com"); err != ErrTemplateKindMismatch {
+		t.Errorf("approval template for rejection: err = %v, want %v", err, ErrTemplateKindMismatch)
+	}
+	if _, err := RenderReviewTemplate(rejection, "approved", "a@example.com"); err != ErrTemplateKindMismatch {
+		t.Errorf("rejection template for approval: err = %v, want %v", err, ErrTemplateKindMismatch)
+	}
+	if _, err := RenderReviewTemplate(9999, "approved", "a@example.com"); err != ErrTemplateNotFound {
+		t.Errorf("missing template: err = %v, want %v", err, ErrTemplateNotFound)
+	}
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"invite-backend/database"
+)
+
+// AdminSession 管理员登录会话
+type AdminSession struct {
+	JTI        string    `json:"jti"`
+	AdminID    int       `json:"adminId"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// 刷新令牌错误
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token invalid")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// 会话过期后在表中保留的时长，便于审计
+const sessionRetention = 7 * 24 * time.Hour
+
+// 最近活跃时间的更新间隔，避免每个请求都写库
+const sessionTouchInterval = time.Minute
+
+// CreateAdminSession 登记新的登录会话，返回令牌 ID（jti）与管理员当前的令牌版本
+func CreateAdminSession(adminID int, ip, userAgent string, expiresAt time.Time) (jti string, tokenVersion int, err error) {
+	if err = database.DB.QueryRow("SELECT token_version FROM admins WHERE id = ?", adminID).Scan(&tokenVersion); err != nil {
+		return "", 0, err
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	jti = hex.EncodeToString(b)
+
+	now := time.Now().Unix()
+	_, err = database.DB.Exec(`
+		INSERT INTO admin_sessions (jti, admin_id, ip, user_agent, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, jti, adminID, ip, userAgent, now, now, expiresAt.Unix())
+	if err != nil {
+		return "", 0, err
+	}
+
+	// 顺带清理早已过期的会话及其刷新令牌
+	cutoff := time.Now().Add(-sessionRetention).Unix()
+	_, _ = database.DB.Exec("DELETE FROM admin_refresh_tokens WHERE expires_at < ?", cutoff)
+	_, _ = database.DB.Exec("DELETE FROM admin_sessions WHERE expires_at < ?", cutoff)
+
+	return jti, tokenVersion, nil
+}
+
+// ValidateAdminSession 校验会话未被吊销且令牌版本与管理员当前版本一致
+func ValidateAdminSession(adminID int, jti string, tokenVersion int) bool {
+	var currentVersion int
+	var revokedAt sql.NullInt64
+	var lastSeenAt int64
+	err := database.DB.QueryRow(`
+		SELECT a.token_version, s.revoked_at, s.last_seen_at
+		FROM admin_sessions s
+		JOIN admins a ON a.id = s.admin_id
+		WHERE s.jti = ? AND s.admin_id = ?
+	`, jti, adminID).Scan(&currentVersion, &revokedAt, &lastSeenAt)
+	if err != nil || revokedAt.Valid || currentVersion != tokenVersion {
+		return false
+	}
+
+	if time.Since(time.Unix(lastSeenAt, 0)) > sessionTouchInterval {
+		_, _ = database.DB.Exec("UPDATE admin_sessions SET last_seen_at = ? WHERE jti = ?", time.Now().Unix(), jti)
+	}
+
+	return true
+}
+
+// RevokeSession 吊销单个会话
+func RevokeSession(jti string) error {
+	_, err := database.DB.Exec("UPDATE admin_sessions SET revoked_at = ? WHERE jti = ? AND revoked_at IS NULL", time.Now().Unix(), jti)
+	return err
+}
+
+// RevokeAdminSessions 吊销管理员的全部会话，并递增令牌版本使已签发的令牌全部失效
+func RevokeAdminSessions(adminID int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE admins SET token_version = token_version + 1 WHERE id = ?", adminID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"UPDATE admin_sessions SET revoked_at = ? WHERE admin_id = ? AND revoked_at IS NULL",
+		time.Now().Unix(), adminID,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListAdminSessions 获取管理员当前有效的会话
+func ListAdminSessions(adminID int) ([]AdminSession, error) {
+	rows, err := database.DB.Query(`
+		SELECT jti, admin_id, ip, user_agent, created_at, last_seen_at, expires_at
+		FROM admin_sessions
+		WHERE admin_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_seen_at DESC
+	`, adminID, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]AdminSession, 0)
+	for rows.Next() {
+		var s AdminSession
+		var ip, userAgent sql.NullString
+		var createdAt, lastSeenAt, expiresAt int64
+		if err := rows.Scan(&s.JTI, &s.AdminID, &ip, &userAgent, &createdAt, &lastSeenAt, &expiresAt); err != nil {
+			continue
+		}
+		s.IP = ip.String
+		s.UserAgent = userAgent.String
+		s.CreatedAt = time.Unix(createdAt, 0)
+		s.LastSeenAt = time.Unix(lastSeenAt, 0)
+		s.ExpiresAt = time.Unix(expiresAt, 0)
+		sessions = append(sessions, s)
+	}
+
+	return sessions, nil
+}
+
+// IssueRefreshToken 为会话签发新的刷新令牌，返回令牌明文（仅存储哈希）
+func IssueRefreshToken(jti string, adminID int, expiresAt time.Time) (string, error) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	_, err := database.DB.Exec(
+		"INSERT INTO admin_refresh_tokens (token_hash, session_jti, admin_id, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		hashToken(token), jti, adminID, time.Now().Unix(), expiresAt.Unix(),
+	)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RotateRefreshToken 使用刷新令牌换取新的刷新令牌
+// 已轮换过的令牌再次出现时视为被盗用，吊销整个令牌族（会话）
+func RotateRefreshToken(token string) (adminID int, jti string, tokenVersion int, newToken string, err error) {
+	var usedAt, revokedAt sql.NullInt64
+	var expiresAt int64
+	err = database.DB.QueryRow(`
+		SELECT r.admin_id, r.session_jti, r.expires_at, r.used_at, s.revoked_at, a.token_version
+		FROM admin_refresh_tokens r
+		JOIN admin_sessions s ON s.jti = r.session_jti
+		JOIN admins a ON a.id = r.admin_id
+		WHERE r.token_hash = ?
+	`, hashToken(token)).Scan(&adminID, &jti, &expiresAt, &usedAt, &revokedAt, &tokenVersion)
+	if err != nil {
+		return 0, "", 0, "", ErrRefreshTokenInvalid
+	}
+
+	if usedAt.Valid {
+		_ = RevokeSession(jti)
+		return adminID, jti, 0, "", ErrRefreshTokenReused
+	}
+	if revokedAt.Valid || expiresAt <= time.Now().Unix() {
+		return 0, "", 0, "", ErrRefreshTokenInvalid
+	}
+
+	// 条件更新保证并发请求中只有一个能完成轮换，其余按重放处理
+	res, err := database.DB.Exec(
+		"UPDATE admin_refresh_tokens SET used_at = ? WHERE token_hash = ? AND used_at IS NULL",
+		time.Now().Unix(), hashToken(token),
+	)
+	if err != nil {
+		return 0, "", 0, "", err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		_ = RevokeSession(jti)
+		return adminID, jti, 0, "", ErrRefreshTokenReused
+	}
+
+	// 新令牌沿用令牌族的过期时间，登录会话的总时长不因刷新而延长
+	newToken, err = IssueRefreshToken(jti, adminID, time.Unix(expiresAt, 0))
+	if err != nil {
+		return 0, "", 0, "", err
+	}
+
+	return adminID, jti, tokenVersion, newToken, nil
+}
+
+// RefreshTokenSession 查询刷新令牌所属的会话
+func RefreshTokenSession(token string) (jti string, ok bool) {
+	err := database.DB.QueryRow(
+		"SELECT session_jti FROM admin_refresh_tokens WHERE token_hash = ?", hashToken(token),
+	).Scan(&jti)
+	return jti, err == nil
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
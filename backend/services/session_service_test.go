@@ -0,0 +1,112 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"invite-backend/database"
+)
+
+// newTestSession 为初始管理员（ID 1）创建会话并签发刷新令牌
+func newTestSession(t *testing.T) (jti string, tokenVersion int, refresh string) {
+	t.Helper()
+	expiresAt := time.Now().Add(time.Hour)
+	jti, tokenVersion, err := CreateAdminSession(1, "127.0.0.1", "test", expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh, err = IssueRefreshToken(jti, 1, expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jti, tokenVersion, refresh
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	setupTestDB(t)
+	jti, tokenVersion, first := newTestSession(t)
+
+	adminID, gotJTI, gotVersion, second, err := RotateRefreshToken(first)
+	if err != nil || adminID != 1 || gotJTI != jti || gotVersion != tokenVersion || second == "" || second == first {
+		t.Fatalf("rotate = %d, %q, %d, %q, %v", adminID, gotJTI, gotVersion, second, err)
+	}
+	third := rotateOK(t, second)
+
+	// 新令牌不延长令牌族的过期时间
+	var firstExpires, thirdExpires int64
+	database.DB.QueryRow("SELECT expires_at FROM admin_refresh_tokens WHERE token_hash = ?", hashToken(first)).Scan(&firstExpires)
+	database.DB.QueryRow("SELECT expires_at FROM admin_refresh_tokens WHERE token_hash = ?", hashToken(third)).Scan(&thirdExpires)
+	if firstExpires != thirdExpires {
+		t.Errorf("rotated token expires at %d, want %d", thirdExpires, firstExpires)
+	}
+
+	if _, _, _, _, err := RotateRefreshToken("unknown"); err != ErrRefreshTokenInvalid {
+		t.Errorf("unknown token: err = %v, want %v", err, ErrRefreshTokenInvalid)
+	}
+}
+
+func TestRotateRefreshTokenReuseRevokesSession(t *testing.T) {
+	setupTestDB(t)
+	jti, tokenVersion, stolen := newTestSession(t)
+	current := rotateOK(t, stolen)
+
+	// 已轮换过的令牌再次出现：判定为盗用，整个会话失效
+	if _, gotJTI, _, _, err := RotateRefreshToken(stolen); err != ErrRefreshTokenReused || gotJTI != jti {
+		t.Fatalf("reused token: jti %q, err = %v; want %v", gotJTI, err, ErrRefreshTokenReused)
+	}
+	if ValidateAdminSession(1, jti, tokenVersion) {
+		t.Error("session should be revoked after refresh token reuse")
+	}
+	// 合法持有者手中的最新令牌也随会话一起失效
+	if _, _, _, _, err := RotateRefreshToken(current); err != ErrRefreshTokenInvalid {
+		t.Errorf("latest token after reuse: err = %v, want %v", err, ErrRefreshTokenInvalid)
+	}
+
+	// 其他会话不受影响
+	otherJTI, otherVersion, other := newTestSession(t)
+	rotateOK(t, other)
+	if !ValidateAdminSession(1, otherJTI, otherVersion) {
+		t.Error("unrelated session should stay valid")
+	}
+}
+
+func TestRotateRefreshTokenExpiredOrRevoked(t *testing.T) {
+	setupTestDB(t)
+	_, _, expired := newTestSession(t)
+	if _, err := database.DB.Exec("UPDATE admin_refresh_tokens SET expires_at = ? WHERE token_hash = ?", time.Now().Unix()-1, hashToken(expired)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, _, err := RotateRefreshToken(expired); err != ErrRefreshTokenInvalid {
+		t.Errorf("expired token: err = %v, want %v", err, ErrRefreshTokenInvalid)
+	}
+
+	jti, _, revoked := newTestSession(t)
+	if err := RevokeSession(jti); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, _, err := RotateRefreshToken(revoked); err != ErrRefreshTokenInvalid {
+		t.Errorf("token of revoked session: err = %v, want %v", err, ErrRefreshTokenInvalid)
+	}
+
+	// 吊销全部会话后令牌版本递增，旧访问令牌失效
+	jti, version, _ := newTestSession(t)
+	if err := RevokeAdminSessions(1); err != nil {
+		t.Fatal(err)
+	}
+	if ValidateAdminSession(1, jti, version) {
+		t.Error("session should be invalid after revoking all sessions")
+	}
+	newJTI, newVersion, _ := newTestSession(t)
+	if newVersion != version+1 || !ValidateAdminSession(1, newJTI, newVersion) {
+		t.Errorf("new session version = %d, want %d and valid", newVersion, version+1)
+	}
+}
+
+func rotateOK(t *testing.T, token string) string {
+	t.Helper()
+	_, _, _, next, err := RotateRefreshToken(token)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	return next
+}
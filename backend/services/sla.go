@@ -0,0 +1,244 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"invite-backend/database"
+)
+
+// 审核时效默认参数
+const (
+	defaultReviewSLAHours = 48
+	slaCheckInterval      = time.Hour
+	slaDigestInterval     = 24 * time.Hour // 超时摘要邮件的最小发送间隔
+	slaDigestLimit        = 50             // 摘要邮件中最多列出的申请数
+)
+
+// slaDigest 记录最近一次发送超时摘要的时间，避免每轮检查都发送邮件
+var slaDigest struct {
+	sync.Mutex
+	lastAt time.Time
+}
+
+// OverdueApplication 超过审核时效仍未处理的申请
+type OverdueApplication struct {
+	ID                 int       `json:"id"`
+	Email              string    `json:"email"`
+	Status             string    `json:"status"`
+	CreatedAt          time.Time `json:"createdAt"`
+	AssignedToUsername string    `json:"assignedToUsername"`
+}
+
+// DecisionTimeStats 从提交到审核决定的耗时分布（单位：秒）
+type DecisionTimeStats struct {
+	AdminID   int    `json:"adminId"`
+	Username  string `json:"username"`
+	Decisions int    `json:"decisions"`
+	Median    int64  `json:"medianSeconds"`
+	P90       int64  `json:"p90Seconds"`
+	P99       int64  `json:"p99Seconds"`
+}
+
+// ReviewSLA 从系统设置读取审核时效，返回 0 表示未启用
+func ReviewSLA() time.Duration {
+	settings, _ := GetSystemSettings()
+	hours, err := strconv.Atoi(settings["review_sla_hours"])
+	if err != nil || hours < 0 {
+		hours = defaultReviewSLAHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// IsAwaitingReview 判断申请是否仍在等待审核
+func IsAwaitingReview(status string) bool {
+	return status == "pending" || status == "pending_second_review"
+}
+
+// ListOverdueApplications 按等待时长倒序获取超时未处理的申请，同时返回超时总数
+func ListOverdueApplications(limit int) ([]OverdueApplication, int, error) {
+	sla := ReviewSLA()
+	items := make([]OverdueApplication, 0)
+	if sla == 0 {
+		return items, 0, nil
+	}
+	cutoff := time.Now().Add(-sla).Unix()
+
+	var total int
+	if err := database.DB.QueryRow(
+		"SELECT COUNT(*) FROM applications WHERE status IN ('pending', 'pending_second_review') AND created_at <= ?", cutoff,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT a.id, a.email, a.status, a.created_at, COALESCE(asg.username, '')
+		FROM applications a
+		LEFT JOIN admins asg ON a.assigned_to = asg.id
+		WHERE a.status IN ('pending', 'pending_second_review') AND a.created_at <= ?
+		ORDER BY a.created_at ASC, a.id ASC
+		LIMIT ?
+	`, cutoff, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a OverdueApplication
+		var createdAt interface{}
+		if err := rows.Scan(&a.ID, &a.Email, &a.Status, &createdAt, &a.AssignedToUsername); err != nil {
+			continue
+		}
+		a.CreatedAt = time.Unix(database.ToUnixTimestamp(createdAt), 0)
+		items = append(items, a)
+	}
+	return items, total, nil
+}
+
+// StartSLAWorker 启动后台任务，定期检查超时未处理的申请并向超级管理员发送摘要邮件
+func StartSLAWorker() {
+	go func() {
+		ticker := time.NewTicker(slaCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			SendOverdueDigest()
+		}
+	}()
+}
+
+// SendOverdueDigest 存在超时申请且距上次发送超过间隔时，向超级管理员发送超时摘要
+func SendOverdueDigest() {
+	items, total, err := ListOverdueApplications(slaDigestLimit)
+	if err != nil {
+		log.Printf("Failed to list overdue applications: %v", err)
+		return
+	}
+	if total == 0 {
+		return
+	}
+
+	slaDigest.Lock()
+	if time.Since(slaDigest.lastAt) < slaDigestInterval {
+		slaDigest.Unlock()
+		return
+	}
+	slaDigest.lastAt = time.Now()
+	slaDigest.Unlock()
+
+	emailService, err := GetEmailService()
+	if err != nil {
+		log.Printf("%d applications overdue, but SMTP not configured", total)
+		return
+	}
+
+	sla := ReviewSLA()
+	var b strings.Builder
+	fmt.Fprintf(&b, "以下申请已超过 %d 小时审核时效仍未处理（共 %d 条）：\n\n", int(sla.Hours()), total)
+	for _, a := range items {
+		assignee := a.AssignedToUsername
+		if assignee == "" {
+			assignee = "未分配"
+		}
+		fmt.Fprintf(&b, "#%d %s，已等待 %s，%s\n", a.ID, a.Email, formatWaitDuration(time.Since(a.CreatedAt)), assignee)
+	}
+	if total > len(items) {
+		fmt.Fprintf(&b, "\n另有 %d 条未列出，请登录管理后台查看。", total-len(items))
+	}
+
+	for _, to := range GetSuperAdminEmails() {
+		if err := emailService.SendAdminNotification(to, "⏰ 申请审核超时提醒", b.String()); err != nil {
+			log.Printf("Failed to send overdue digest to %s: %v", to, err)
+		}
+	}
+}
+
+// formatWaitDuration 将等待时长格式化为“X 天 Y 小时”
+func formatWaitDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	if hours < 24 {
+		return fmt.Sprintf("%d 小时", hours)
+	}
+	return fmt.Sprintf("%d 天 %d 小时", hours/24, hours%24)
+}
+
+// GetDecisionTimeStats 统计近 days 天内已审核申请从提交到首次决定的耗时，
+// 返回整体分布与每位审核员的分布，days 为 0 时统计全部；申诉改判不影响原审核员的耗时
+func GetDecisionTimeStats(days int) (DecisionTimeStats, []DecisionTimeStats, error) {
+	query := `
+		SELECT a.decided_by, COALESCE(ad.username, ''), a.decided_at - a.created_at
+		FROM applications a
+		LEFT JOIN admins ad ON a.decided_by = ad.id
+		WHERE a.decided_at IS NOT NULL AND a.decided_by IS NOT NULL AND a.decided_at >= a.created_at`
+	var args []interface{}
+	if days > 0 {
+		query += " AND a.decided_at >= ?"
+		args = append(args, time.Now().AddDate(0, 0, -days).Unix())
+	}
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return DecisionTimeStats{}, nil, err
+	}
+	defer rows.Close()
+
+	var all []int64
+	byAdmin := make(map[int][]int64)
+	usernames := make(map[int]string)
+	for rows.Next() {
+		var adminID int
+		var username string
+		var seconds int64
+		if err := rows.Scan(&adminID, &username, &seconds); err != nil {
+			continue
+		}
+		all = append(all, seconds)
+		byAdmin[adminID] = append(byAdmin[adminID], seconds)
+		usernames[adminID] = username
+	}
+
+	overall := decisionTimeStats(all)
+	reviewers := make([]DecisionTimeStats, 0, len(byAdmin))
+	for adminID, durations := range byAdmin {
+		s := decisionTimeStats(durations)
+		s.AdminID = adminID
+		s.Username = usernames[adminID]
+		reviewers = append(reviewers, s)
+	}
+	sort.Slice(reviewers, func(i, j int) bool {
+		if reviewers[i].Decisions != reviewers[j].Decisions {
+			return reviewers[i].Decisions > reviewers[j].Decisions
+		}
+		return reviewers[i].AdminID < reviewers[j].AdminID
+	})
+
+	return overall, reviewers, nil
+}
+
+func decisionTimeStats(durations []int64) DecisionTimeStats {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return DecisionTimeStats{
+		Decisions: len(durations),
+		Median:    percentile(durations, 50),
+		P90:       percentile(durations, 90),
+		P99:       percentile(durations, 99),
+	}
+}
+
+// percentile 按最近秩法计算已排序数据的百分位数
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,38 @@
+package services
+
+import "testing"
+
+func TestPercentile(t *testing.T) {
+	ten := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		name   string
+		sorted []int64
+		p      float64
+		want   int64
+	}{
+		{"empty", nil, 50, 0},
+		{"single", []int64{42}, 99, 42},
+		{"median of even count", ten, 50, 5},
+		{"median of odd count", []int64{1, 2, 3, 4, 5}, 50, 3},
+		{"p90", ten, 90, 9},
+		{"p99 rounds up", ten, 99, 10},
+		{"p100", ten, 100, 10},
+		{"p0 uses the first rank", ten, 0, 1},
+		{"nearest rank", []int64{15, 20, 35, 40, 50}, 30, 20},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.sorted, tt.p); got != tt.want {
+			t.Errorf("%s: percentile(%v, %v) = %d, want %d", tt.name, tt.sorted, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestDecisionTimeStats(t *testing.T) {
+	s := decisionTimeStats([]int64{300, 100, 200})
+	if s.Decisions != 3 || s.Median != 200 || s.P90 != 300 || s.P99 != 300 {
+		t.Errorf("stats = %+v", s)
+	}
+	if s := decisionTimeStats(nil); s != (DecisionTimeStats{}) {
+		t.Errorf("empty stats = %+v", s)
+	}
+}
@@ -48,6 +48,26 @@ func GetEmailService() (*EmailService, error) {
 	return NewEmailService(host, port, user, pass), nil
 }
 
+// GetSuperAdminEmails 获取所有配置了通知邮箱的超级管理员邮箱
+func GetSuperAdminEmails() []string {
+	rows, err := database.DB.Query("SELECT email FROM admins WHERE role = 'super' AND email IS NOT NULL AND email != ''")
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var emails []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			continue
+		}
+		emails = append(emails, email)
+	}
+
+	return emails
+}
+
 // UpdateSettings 更新系统设置
 func UpdateSettings(settings map[string]string) error {
 	tx, err := database.DB.Begin()
@@ -75,7 +95,7 @@ func UpdateSettings(settings map[string]string) error {
 func CheckApplicationStatus(fingerprint string) (hasPending, hasApproved bool, err error) {
 	query := `
 		SELECT status FROM applications 
-		WHERE device_id = ? AND status IN ('pending', 'approved')
+		WHERE device_id = ? AND status IN ('pending', 'pending_second_review', 'approved')
 		LIMIT 1
 	`
 	var status string
@@ -88,5 +108,5 @@ func CheckApplicationStatus(fingerprint string) (hasPending, hasApproved bool, e
 		return false, false, err
 	}
 
-	return status == "pending", status == "approved", nil
+	return status == "pending" || status == "pending_second_review", status == "approved", nil
 }
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"invite-backend/config"
+	"invite-backend/database"
+)
+
+// setupTestDB 在临时目录中初始化一个全新的数据库
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	config.AppConfig = &config.Config{AdminUsername: "admin", AdminPassword: "test-password", JWTSecret: "test"}
+	if err := database.InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.DB.Close() })
+}
+
+// setTestSetting 修改系统设置
+func setTestSetting(t *testing.T, key, value string) {
+	t.Helper()
+	if _, err := database.DB.Exec(
+		"INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value", key, value,
+	); err != nil {
+		t.Fatal(err)
+	}
+}
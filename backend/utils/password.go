@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id 参数（参考 RFC 9106 推荐的低内存配置）
+const (
+	argon2Time    uint32 = 3
+	argon2Memory  uint32 = 64 * 1024
+	argon2Threads uint8  = 2
+	argon2KeyLen  uint32 = 32
+	argon2SaltLen        = 16
+)
+
+const argon2Prefix = "$argon2id$"
+
+// HashPassword 使用 argon2id 生成带随机盐的密码哈希
+// 格式：$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func HashPassword(password string) string {
+	salt := make([]byte, argon2SaltLen)
+	rand.Read(salt)
+
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix, argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+// VerifyPassword 校验密码，兼容旧版无盐 SHA256 哈希
+// needsRehash 为 true 表示校验通过但哈希格式或参数已过时，应使用 HashPassword 重新生成
+func VerifyPassword(password, encoded string) (ok bool, needsRehash bool) {
+	if encoded == "" {
+		return false, false
+	}
+
+	if strings.HasPrefix(encoded, argon2Prefix) {
+		return verifyArgon2id(password, encoded)
+	}
+
+	// 旧版 SHA256 十六进制哈希
+	if isLegacySHA256(encoded) {
+		hash := sha256.Sum256([]byte(password))
+		legacy := fmt.Sprintf("%x", hash)
+		if subtle.ConstantTimeCompare([]byte(legacy), []byte(strings.ToLower(encoded))) == 1 {
+			return true, true
+		}
+	}
+
+	return false, false
+}
+
+func verifyArgon2id(password, encoded string) (bool, bool) {
+	// $argon2id$v=19$m=65536,t=3,p=2$salt$hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, false
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return false, false
+	}
+
+	needsRehash := memory != argon2Memory || time != argon2Time || threads != argon2Threads || uint32(len(key)) != argon2KeyLen
+	return true, needsRehash
+}
+
+func isLegacySHA256(encoded string) bool {
+	if len(encoded) != sha256.Size*2 {
+		return false
+	}
+	for _, r := range encoded {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
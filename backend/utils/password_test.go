@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+func TestHashPasswordRoundTrip(t *testing.T) {
+	encoded := HashPassword("correct horse")
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=65536,t=3,p=2$") {
+		t.Fatalf("unexpected encoding %q", encoded)
+	}
+
+	if ok, needsRehash := VerifyPassword("correct horse", encoded); !ok || needsRehash {
+		t.Errorf("VerifyPassword = %v, %v; want true, false", ok, needsRehash)
+	}
+	if ok, _ := VerifyPassword("correct horse ", encoded); ok {
+		t.Error("wrong password should not verify")
+	}
+	if HashPassword("correct horse") == encoded {
+		t.Error("hashes of the same password should use different salts")
+	}
+}
+
+func TestVerifyPasswordLegacySHA256(t *testing.T) {
+	legacy := fmt.Sprintf("%x", sha256.Sum256([]byte("admin123")))
+
+	tests := []struct {
+		name        string
+		password    string
+		encoded     string
+		ok          bool
+		needsRehash bool
+	}{
+		{"legacy match", "admin123", legacy, true, true},
+		{"legacy uppercase hex", "admin123", strings.ToUpper(legacy), true, true},
+		{"legacy mismatch", "admin1234", legacy, false, false},
+		{"not hex", "admin123", strings.Repeat("z", 64), false, false},
+		{"wrong length", "admin123", legacy[:63], false, false},
+		{"plaintext", "admin123", "admin123", false, false},
+		{"empty", "", "", false, false},
+	}
+	for _, tt := range tests {
+		ok, needsRehash := VerifyPassword(tt.password, tt.encoded)
+		if ok != tt.ok || needsRehash != tt.needsRehash {
+			t.Errorf("%s: VerifyPassword = %v, %v; want %v, %v", tt.name, ok, needsRehash, tt.ok, tt.needsRehash)
+		}
+	}
+}
+
+func TestVerifyPasswordOutdatedParams(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	encode := func(memory, time uint32, threads uint8, keyLen uint32) string {
+		key := argon2.IDKey([]byte("secret"), salt, time, memory, threads, keyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, time, threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"lower memory", encode(8*1024, argon2Time, argon2Threads, argon2KeyLen)},
+		{"fewer passes", encode(argon2Memory, 1, argon2Threads, argon2KeyLen)},
+		{"one thread", encode(argon2Memory, argon2Time, 1, argon2KeyLen)},
+		{"shorter key", encode(argon2Memory, argon2Time, argon2Threads, 16)},
+	}
+	for _, tt := range tests {
+		if ok, needsRehash := VerifyPassword("secret", tt.encoded); !ok || !needsRehash {
+			t.Errorf("%s: VerifyPassword = %v, %v; want true, true", tt.name, ok, needsRehash)
+		}
+		if ok, _ := VerifyPassword("other", tt.encoded); ok {
+			t.Errorf("%s: wrong password should not verify", tt.name)
+		}
+	}
+
+	if ok, needsRehash := VerifyPassword("secret", encode(argon2Memory, argon2Time, argon2Threads, argon2KeyLen)); !ok || needsRehash {
+		t.Errorf("current params: VerifyPassword = %v, %v; want true, false", ok, needsRehash)
+	}
+}
+
+func TestVerifyPasswordMalformed(t *testing.T) {
+	valid := HashPassword("secret")
+	parts := strings.Split(valid, "$")
+
+	tests := map[string]string{
+		"missing hash":    strings.Join(parts[:5], "$"),
+		"wrong version":   strings.Replace(valid, "v=19", "v=16", 1),
+		"bad params":      strings.Replace(valid, "m=65536,t=3,p=2", "m=x", 1),
+		"bad salt":        strings.Join([]string{parts[0], parts[1], parts[2], parts[3], "!!", parts[5]}, "$"),
+		"empty hash":      strings.Join([]string{parts[0], parts[1], parts[2], parts[3], parts[4], ""}, "$"),
+		"tampered hash":   strings.Join([]string{parts[0], parts[1], parts[2], parts[3], parts[4], base64.RawStdEncoding.EncodeToString(make([]byte, 32))}, "$"),
+		"other algorithm": strings.Replace(valid, "$argon2id$", "$argon2i$", 1),
+	}
+	for name, encoded := range tests {
+		if ok, needsRehash := VerifyPassword("secret", encoded); ok || needsRehash {
+			t.Errorf("%s: VerifyPassword = %v, %v; want false, false", name, ok, needsRehash)
+		}
+	}
+}
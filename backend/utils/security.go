@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -96,9 +95,3 @@ func (s *StarMoonSecurity) deriveDynamicKey(fingerprint string, nonce int) strin
 
 	return key
 }
-
-// HashPassword SHA256 密码哈希
-func HashPassword(password string) string {
-	hash := sha256.Sum256([]byte(password))
-	return fmt.Sprintf("%x", hash)
-}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试密钥
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 附录 B 的 8 位动态码取后 6 位
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		if got := totpCode([]byte("12345678901234567890"), uint64(v.unix/totpPeriod)); got != v.code {
+			t.Errorf("totpCode at %d = %s, want %s", v.unix, got, v.code)
+		}
+		counter, ok := VerifyTOTP(rfcSecret, v.code, time.Unix(v.unix, 0))
+		if !ok || counter != v.unix/totpPeriod {
+			t.Errorf("VerifyTOTP at %d = %d, %v", v.unix, counter, ok)
+		}
+	}
+}
+
+func TestVerifyTOTPSkew(t *testing.T) {
+	at := time.Unix(1111111109, 0) // 计数器 37037036
+	code := "081804"
+
+	for _, offset := range []time.Duration{-30 * time.Second, 0, 30 * time.Second} {
+		if counter, ok := VerifyTOTP(rfcSecret, code, at.Add(offset)); !ok || counter != 37037036 {
+			t.Errorf("offset %v: got %d, %v", offset, counter, ok)
+		}
+	}
+	for _, offset := range []time.Duration{-60 * time.Second, 60 * time.Second} {
+		if _, ok := VerifyTOTP(rfcSecret, code, at.Add(offset)); ok {
+			t.Errorf("offset %v: code outside the skew window should be rejected", offset)
+		}
+	}
+}
+
+func TestVerifyTOTPInput(t *testing.T) {
+	at := time.Unix(59, 0)
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		want   bool
+	}{
+		{"surrounding whitespace", rfcSecret, " 287082 ", true},
+		{"lowercase secret", strings.ToLower(rfcSecret), "287082", true},
+		{"padded secret", rfcSecret + "====", "287082", true},
+		{"wrong code", rfcSecret, "287083", false},
+		{"too short", rfcSecret, "28708", false},
+		{"too long", rfcSecret, "94287082", false},
+		{"empty", rfcSecret, "", false},
+		{"invalid secret", "not base32!", "287082", false},
+	}
+	for _, tt := range tests {
+		if _, ok := VerifyTOTP(tt.secret, tt.code, at); ok != tt.want {
+			t.Errorf("%s: VerifyTOTP = %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret := GenerateTOTPSecret()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes, err %v", secret, len(key), err)
+	}
+	if GenerateTOTPSecret() == secret {
+		t.Error("secrets should be random")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes := GenerateRecoveryCodes(10)
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes", len(codes))
+	}
+	pattern := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if !pattern.MatchString(code) {
+			t.Errorf("unexpected format %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+
+	// 输入时忽略大小写与空格
+	if HashRecoveryCode(" ABCDE-fghij ") != HashRecoveryCode("abcde-fghij") || HashRecoveryCode("abc de-fghij") != HashRecoveryCode("abcde-fghij") {
+		t.Error("recovery code hash should ignore case and spaces")
+	}
+	if HashRecoveryCode("abcde-fghij") == HashRecoveryCode("abcde-fghik") {
+		t.Error("different codes should hash differently")
+	}
+}
@@ -16,9 +16,45 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
+// 访问令牌过期后使用 HttpOnly 刷新令牌换取新令牌，并发请求共享同一次刷新
+let refreshing: Promise<string> | null = null;
+
+const refreshToken = () => {
+  if (!refreshing) {
+    refreshing = axios
+      .post('/api/admin/token/refresh', null, { withCredentials: true })
+      .then((res) => {
+        localStorage.setItem('admin_token', res.data.token);
+        return res.data.token as string;
+      })
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+};
+
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
+  async (error) => {
+    const config = error.config;
+    if (
+      error.response?.status === 401 &&
+      config &&
+      !config._retry &&
+      localStorage.getItem('admin_token') &&
+      !config.url?.startsWith('/admin/login') &&
+      config.url !== '/admin/logout'
+    ) {
+      config._retry = true;
+      try {
+        const token = await refreshToken();
+        config.headers.Authorization = `Bearer ${token}`;
+        return api(config);
+      } catch {
+        // 刷新失败，按未登录处理
+      }
+    }
     if (error.response?.status === 401) {
       localStorage.removeItem('admin_token');
       // Only redirect if we are in admin area
@@ -1,7 +1,8 @@
 import React, { useEffect, useState } from 'react';
 import { Navbar, NavbarBrand, NavbarContent, NavbarItem, Link, Button, Dropdown, DropdownTrigger, DropdownMenu, DropdownItem } from "@heroui/react";
 import { Link as RouterLink, useNavigate, useLocation } from 'react-router-dom';
-import { FaMoon, FaSun, FaUserCircle, FaSignOutAlt, FaShieldAlt, FaUsers, FaBullhorn, FaCog, FaUserShield, FaHistory } from 'react-icons/fa';
+import api from '../api/client';
+import { FaMoon, FaSun, FaUserCircle, FaSignOutAlt, FaShieldAlt, FaUsers, FaBullhorn, FaCog, FaUserShield, FaHistory, FaClipboardList, FaGavel, FaStopwatch } from 'react-icons/fa';
 
 export default function Layout({ children }: { children: React.ReactNode }) {
   const navigate = useNavigate();
@@ -11,19 +12,22 @@ export default function Layout({ children }: { children: React.ReactNode }) {
   const isAdminPage = location.pathname.startsWith('/admin');
   const isDashboard = location.pathname === '/admin/dashboard';
 
-  // Get user role
+  // Get user permissions
   const user = JSON.parse(localStorage.getItem('admin_user') || '{}');
-  const role = user.role || 'reviewer';
+  const permissions: string[] = user.permissions || [];
 
   const allAdminTabs = [
-    { id: 'applications', label: '申请管理', icon: <FaUsers size={16} />, roles: ['super', 'reviewer'] },
-    { id: 'announcements', label: '系统公告', icon: <FaBullhorn size={16} />, roles: ['super'] },
-    { id: 'audit-logs', label: '审核日志', icon: <FaHistory size={16} />, roles: ['super'] },
-    { id: 'settings', label: '系统设置', icon: <FaCog size={16} />, roles: ['super'] },
-    { id: 'admins', label: '人员管理', icon: <FaUserShield size={16} />, roles: ['super'] },
+    { id: 'applications', label: '申请管理', icon: <FaUsers size={16} />, permission: 'applications.review' },
+    { id: 'appeals', label: '申诉处理', icon: <FaGavel size={16} />, permission: 'appeals.review' },
+    { id: 'announcements', label: '系统公告', icon: <FaBullhorn size={16} />, permission: 'announcements.manage' },
+    { id: 'audit-logs', label: '审核日志', icon: <FaHistory size={16} />, permission: 'audit.read' },
+    { id: 'review-stats', label: '审核时效', icon: <FaStopwatch size={16} />, permission: 'audit.read' },
+    { id: 'review-templates', label: '审核模板', icon: <FaClipboardList size={16} />, permission: 'templates.manage' },
+    { id: 'settings', label: '系统设置', icon: <FaCog size={16} />, permission: 'settings.write' },
+    { id: 'admins', label: '人员管理', icon: <FaUserShield size={16} />, permission: 'admins.manage' },
   ];
 
-  const adminTabs = allAdminTabs.filter(tab => tab.roles.includes(role));
+  const adminTabs = allAdminTabs.filter(tab => permissions.includes(tab.permission));
 
   const currentTab = new URLSearchParams(location.search).get('tab') || 'applications';
 
@@ -47,7 +51,12 @@ export default function Layout({ children }: { children: React.ReactNode }) {
     }
   };
 
-  const handleLogout = () => {
+  const handleLogout = async () => {
+    try {
+      await api.post('/admin/logout');
+    } catch {
+      // 令牌已失效时忽略错误，直接清理本地状态
+    }
     localStorage.removeItem('admin_token');
     navigate('/admin/login');
   };
@@ -145,7 +154,7 @@ export default function Layout({ children }: { children: React.ReactNode }) {
                     startContent={<FaShieldAlt className="text-primary" />}
                     className="h-10"
                   >
-                    {role === 'super' ? '控制台' : '申请管理'}
+                    {user.role === 'super' ? '控制台' : '申请管理'}
                   </DropdownItem>
                   <DropdownItem 
                     key="logout" 
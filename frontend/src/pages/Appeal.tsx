@@ -0,0 +1,123 @@
+import { useEffect, useState } from 'react';
+import { useSearchParams } from 'react-router-dom';
+import { Card, CardBody, Button, Textarea, Chip, Spinner } from "@heroui/react";
+import { FaGavel, FaPaperPlane } from 'react-icons/fa';
+import api from '../api/client';
+import toast from 'react-hot-toast';
+
+interface AppealInfo {
+  status: string;
+  canAppeal: boolean;
+  appeal?: {
+    content: string;
+    status: 'pending' | 'overturned' | 'upheld';
+    decisionOpinion: string;
+    createdAt: string;
+  };
+}
+
+const appealStatusText: Record<string, { label: string; color: 'warning' | 'success' | 'danger' }> = {
+  pending: { label: '申诉处理中', color: 'warning' },
+  overturned: { label: '申诉已通过', color: 'success' },
+  upheld: { label: '维持原判', color: 'danger' },
+};
+
+export default function Appeal() {
+  const [searchParams] = useSearchParams();
+  const id = Number(searchParams.get('id'));
+  const token = searchParams.get('token') || '';
+  const [info, setInfo] = useState<AppealInfo | null>(null);
+  const [error, setError] = useState('');
+  const [content, setContent] = useState('');
+  const [submitting, setSubmitting] = useState(false);
+
+  const fetchInfo = async () => {
+    try {
+      const res = await api.get('/appeal', { params: { id, token } });
+      setInfo(res.data.data);
+    } catch (err: any) {
+      setError(err.response?.data?.message || '申诉链接无效');
+    }
+  };
+
+  useEffect(() => {
+    fetchInfo();
+  }, [id, token]);
+
+  const handleSubmit = async () => {
+    setSubmitting(true);
+    try {
+      const res = await api.post('/appeal', { id, token, content });
+      toast.success(res.data.message);
+      fetchInfo();
+    } catch (err: any) {
+      toast.error(err.response?.data?.message || '提交失败');
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  return (
+    <div className="min-h-[calc(100vh-64px)] flex items-center justify-center py-12 px-4">
+      <Card className="w-full max-w-xl shadow-sm border border-divider" radius="lg">
+        <CardBody className="p-8 gap-6">
+          <h1 className="text-2xl font-black flex items-center gap-3">
+            <FaGavel className="text-primary" />
+            申请申诉
+          </h1>
+
+          {error && <p className="text-danger font-medium">{error}</p>}
+          {!error && !info && <div className="flex justify-center p-6"><Spinner /></div>}
+
+          {info?.appeal && (
+            <div className="space-y-4">
+              <Chip color={appealStatusText[info.appeal.status].color} variant="flat" className="font-bold">
+                {appealStatusText[info.appeal.status].label}
+              </Chip>
+              <div className="p-4 bg-default-50 dark:bg-default-800/50 rounded-xl border border-divider">
+                <p className="text-xs font-bold text-default-400 mb-2">您的申诉</p>
+                <p className="text-sm whitespace-pre-wrap">{info.appeal.content}</p>
+              </div>
+              {info.appeal.decisionOpinion && (
+                <div className="p-4 bg-primary/5 rounded-xl border border-primary/10">
+                  <p className="text-xs font-bold text-primary mb-2">处理意见</p>
+                  <p className="text-sm whitespace-pre-wrap">{info.appeal.decisionOpinion}</p>
+                </div>
+              )}
+            </div>
+          )}
+
+          {info && !info.appeal && !info.canAppeal && (
+            <p className="text-default-500">该申请当前不是拒绝状态，无需申诉。</p>
+          )}
+
+          {info?.canAppeal && (
+            <div className="space-y-4">
+              <p className="text-sm text-default-500">每个申请仅可申诉一次，请详细说明您认为审核结果有误的原因。</p>
+              <Textarea
+                label="申诉内容"
+                placeholder="10-1000 字"
+                variant="bordered"
+                radius="lg"
+                minRows={5}
+                value={content}
+                onValueChange={setContent}
+              />
+              <Button
+                color="primary"
+                radius="lg"
+                className="font-bold w-full h-12"
+                startContent={<FaPaperPlane />}
+                isLoading={submitting}
+                isDisabled={content.trim().length < 10}
+                onPress={handleSubmit}
+              >
+                提交申诉
+              </Button>
+            </div>
+          )}
+        </CardBody>
+      </Card>
+    </div>
+  );
+}
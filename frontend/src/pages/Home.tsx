@@ -15,6 +15,7 @@ interface Announcement {
   created_at: number;
 }
 import { StarMoonSecurity } from '../utils/security';
+import { solveSecurityChallenge, PowTimeoutError } from '../utils/pow';
 import { getDeviceId } from '../utils/device';
 
 export default function Home() {
@@ -31,11 +32,13 @@ export default function Home() {
   const [captchaQuestion, setCaptchaQuestion] = useState('');
   const [captchaAnswer, setCaptchaAnswer] = useState('');
   const [captchaLoading, setCaptchaLoading] = useState(false);
+  const [powProgress, setPowProgress] = useState<number | null>(null);
 
   // Status state
   const [statusEmail, setStatusEmail] = useState('');
   const [statusLoading, setStatusLoading] = useState(false);
   const [status, setStatus] = useState<ApplicationStatus | null>(null);
+  const [manageLinkLoading, setManageLinkLoading] = useState(false);
   const [announcements, setAnnouncements] = useState<Announcement[]>([]);
 
   useEffect(() => {
@@ -74,15 +77,19 @@ export default function Home() {
     }
     setSending(true);
     try {
-      await api.post('/verification-code', { email, captchaAnswer });
+      const fingerprint = getDeviceId();
+      const pow = await solveSecurityChallenge(fingerprint, { onProgress: setPowProgress });
+      setPowProgress(null);
+      await api.post('/verification-code', { email, captchaAnswer, fingerprint, ...pow });
       toast.success("验证码已发送，请检查邮箱");
       setStep(2);
     } catch (error: any) {
-      toast.error(error.response?.data?.message || "发送失败");
+      toast.error(error instanceof PowTimeoutError ? error.message : error.response?.data?.message || "发送失败");
       fetchCaptcha();
       setCaptchaAnswer('');
     } finally {
       setSending(false);
+      setPowProgress(null);
     }
   };
 
@@ -102,9 +109,11 @@ export default function Home() {
       const nonce = Math.floor(Math.random() * 1000000);
       const fingerprint = getDeviceId();
       const payload = { email, code, reason };
+      const pow = await solveSecurityChallenge(fingerprint, { onProgress: setPowProgress });
+      setPowProgress(null);
       const encrypted = StarMoonSecurity.encryptData(payload, fingerprint, nonce);
-      
-      await api.post('/application/submit', { encrypted, fingerprint, nonce });
+
+      await api.post('/application/submit', { encrypted, fingerprint, nonce, ...pow });
       toast.success("申请提交成功！");
       setActiveTab("status");
       setStatusEmail(email);
@@ -114,9 +123,10 @@ export default function Home() {
       setCode("");
       setReason("");
     } catch (error: any) {
-      toast.error(error.response?.data?.message || "提交失败");
+      toast.error(error instanceof PowTimeoutError ? error.message : error.response?.data?.message || "提交失败");
     } finally {
       setSubmitting(false);
+      setPowProgress(null);
     }
   };
 
@@ -142,6 +152,19 @@ export default function Home() {
     }
   };
 
+  const handleRequestManageLink = async () => {
+    if (!status) return;
+    setManageLinkLoading(true);
+    try {
+      const res = await api.post('/application/manage-link', { email: status.email });
+      toast.success(res.data.message || "管理链接已发送至邮箱");
+    } catch (error: any) {
+      toast.error(error.response?.data?.message || "发送失败");
+    } finally {
+      setManageLinkLoading(false);
+    }
+  };
+
   const formatDate = (dateVal: any, showTime: boolean = false) => {
     if (!dateVal) return '';
     try {
@@ -306,7 +329,7 @@ export default function Home() {
                               isLoading={sending}
                               className="font-bold h-14 text-lg shadow-sm"
                             >
-                              获取验证码
+                              {powProgress !== null ? `安全校验中 ${powProgress}%` : '获取验证码'}
                             </Button>
                           </div>
                         ) : (
@@ -354,7 +377,7 @@ export default function Home() {
                                 isLoading={submitting}
                                 className="font-bold h-14 text-lg"
                               >
-                                提交申请
+                                {powProgress !== null ? `安全校验中 ${powProgress}%` : '提交申请'}
                               </Button>
                               <Button 
                                 variant="light" 
@@ -431,6 +454,32 @@ export default function Home() {
                           </div>
                         )}
 
+                        {status.status === 'rejected' && (status.reapplyExhausted || status.reapplyAt) && (
+                          <div className="p-5 bg-default-100 dark:bg-default-50 rounded-large border border-divider">
+                            <p className="text-sm text-default-500 font-medium">
+                              {status.reapplyExhausted
+                                ? '该邮箱被拒绝的次数已达上限，无法再次申请。'
+                                : `您可于 ${formatDate(status.reapplyAt, true)} 后重新申请。`}
+                            </p>
+                          </div>
+                        )}
+
+                        {status.status === 'pending' && (
+                          <div className="flex items-center justify-between gap-4 p-5 bg-default-100 dark:bg-default-50 rounded-large border border-divider">
+                            <p className="text-sm text-default-500 font-medium">审核前可以撤回申请或修改申请理由，操作链接将发送至申请邮箱。</p>
+                            <Button
+                              size="sm"
+                              variant="flat"
+                              color="primary"
+                              className="font-bold shrink-0"
+                              isLoading={manageLinkLoading}
+                              onPress={handleRequestManageLink}
+                            >
+                              撤回或修改
+                            </Button>
+                          </div>
+                        )}
+
                         {status.status === 'approved' && (
                           <div className="mt-8 p-8 bg-default-100 dark:bg-default-50 rounded-large border border-divider text-center shadow-sm">
                             <div className="flex flex-col items-center gap-2 mb-6">
@@ -500,7 +549,8 @@ function StatusChip({ status }: { status: string }) {
   const configs: any = {
     pending: { color: "warning", label: "审核中", icon: <FaClock className="text-xs" /> },
     approved: { color: "success", label: "已通过", icon: <FaCheckCircle className="text-xs" /> },
-    rejected: { color: "danger", label: "已拒绝", icon: <FaTimesCircle className="text-xs" /> }
+    rejected: { color: "danger", label: "已拒绝", icon: <FaTimesCircle className="text-xs" /> },
+    withdrawn: { color: "default", label: "已撤回", icon: <FaInfoCircle className="text-xs" /> }
   };
   const config = configs[status] || { color: "primary", label: status, icon: <FaInfoCircle className="text-xs" /> };
   
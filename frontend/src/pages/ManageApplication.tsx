@@ -0,0 +1,120 @@
+import { useEffect, useState } from 'react';
+import { useSearchParams } from 'react-router-dom';
+import { Card, CardBody, Button, Textarea, Spinner } from "@heroui/react";
+import { FaEdit, FaUndo, FaSave } from 'react-icons/fa';
+import api from '../api/client';
+import toast from 'react-hot-toast';
+
+interface ManagedApplication {
+  email: string;
+  status: string;
+  reason: string;
+  createdAt: string;
+  expiresAt: string;
+  canManage: boolean;
+}
+
+export default function ManageApplication() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token') || '';
+  const [info, setInfo] = useState<ManagedApplication | null>(null);
+  const [error, setError] = useState('');
+  const [reason, setReason] = useState('');
+  const [done, setDone] = useState('');
+  const [submitting, setSubmitting] = useState<'edit' | 'withdraw' | null>(null);
+
+  useEffect(() => {
+    const fetchInfo = async () => {
+      try {
+        const res = await api.get('/application/manage', { params: { token } });
+        setInfo(res.data.data);
+        setReason(res.data.data.reason);
+      } catch (err: any) {
+        setError(err.response?.data?.message || '管理链接无效');
+      }
+    };
+    fetchInfo();
+  }, [token]);
+
+  // 管理链接只能使用一次，操作成功后不再展示表单
+  const handleAction = async (action: 'edit' | 'withdraw') => {
+    if (action === 'withdraw' && !window.confirm('确定要撤回申请吗？撤回后可以使用该邮箱重新申请。')) return;
+    setSubmitting(action);
+    try {
+      const res = action === 'edit'
+        ? await api.post('/application/edit', { token, reason })
+        : await api.post('/application/withdraw', { token });
+      toast.success(res.data.message);
+      setDone(res.data.message);
+    } catch (err: any) {
+      toast.error(err.response?.data?.message || '操作失败');
+    } finally {
+      setSubmitting(null);
+    }
+  };
+
+  return (
+    <div className="min-h-[calc(100vh-64px)] flex items-center justify-center py-12 px-4">
+      <Card className="w-full max-w-xl shadow-sm border border-divider" radius="lg">
+        <CardBody className="p-8 gap-6">
+          <h1 className="text-2xl font-black flex items-center gap-3">
+            <FaEdit className="text-primary" />
+            管理申请
+          </h1>
+
+          {error && <p className="text-danger font-medium">{error}</p>}
+          {!error && !info && <div className="flex justify-center p-6"><Spinner /></div>}
+
+          {done && <p className="text-success font-medium">{done}</p>}
+
+          {info && !done && !info.canManage && (
+            <p className="text-default-500">申请已进入审核流程，无法撤回或修改。</p>
+          )}
+
+          {info && !done && info.canManage && (
+            <div className="space-y-4">
+              <p className="text-sm text-default-500">
+                {info.email} 的申请正在等待审核。此链接只能使用一次，修改或撤回后如需再次操作请重新获取链接。
+              </p>
+              <Textarea
+                label="申请理由"
+                placeholder="至少 50 字"
+                variant="bordered"
+                radius="lg"
+                minRows={6}
+                value={reason}
+                onValueChange={setReason}
+                description={`${reason.trim().length} 字`}
+              />
+              <div className="flex gap-3">
+                <Button
+                  color="primary"
+                  radius="lg"
+                  className="font-bold flex-grow h-12"
+                  startContent={<FaSave />}
+                  isLoading={submitting === 'edit'}
+                  isDisabled={submitting !== null || reason.trim().length < 50 || reason.trim() === info.reason}
+                  onPress={() => handleAction('edit')}
+                >
+                  保存修改
+                </Button>
+                <Button
+                  color="danger"
+                  variant="flat"
+                  radius="lg"
+                  className="font-bold h-12"
+                  startContent={<FaUndo />}
+                  isLoading={submitting === 'withdraw'}
+                  isDisabled={submitting !== null}
+                  onPress={() => handleAction('withdraw')}
+                >
+                  撤回申请
+                </Button>
+              </div>
+            </div>
+          )}
+        </CardBody>
+      </Card>
+    </div>
+  );
+}
@@ -27,14 +27,22 @@ import toast from 'react-hot-toast';
 interface Admin {
   id: number;
   username: string;
-  role: 'super' | 'reviewer';
+  role: string;
   linuxdoId?: string;
   createdAt: string;
   updatedAt: string;
 }
 
+interface Role {
+  name: string;
+  description: string;
+  permissions: string[];
+  builtin: boolean;
+}
+
 export default function Admins() {
   const [admins, setAdmins] = useState<Admin[]>([]);
+  const [roles, setRoles] = useState<Role[]>([]);
   const [loading, setLoading] = useState(true);
   const { isOpen, onOpen, onOpenChange } = useDisclosure();
   const [modalMode, setModalMode] = useState<'add' | 'edit'>('add');
@@ -43,7 +51,7 @@ export default function Admins() {
   // Form states
   const [username, setUsername] = useState('');
   const [password, setPassword] = useState('');
-  const [role, setRole] = useState<'super' | 'reviewer'>('reviewer');
+  const [role, setRole] = useState('reviewer');
 
   const fetchAdmins = async () => {
     setLoading(true);
@@ -57,8 +65,20 @@ export default function Admins() {
     }
   };
 
+  const fetchRoles = async () => {
+    try {
+      const res = await api.get('/admin/roles');
+      setRoles(res.data.data || []);
+    } catch (error) {
+      toast.error("加载角色列表失败");
+    }
+  };
+
+  const roleLabel = (name: string) => roles.find(r => r.name === name)?.description || name;
+
   useEffect(() => {
     fetchAdmins();
+    fetchRoles();
   }, []);
 
   const resetForm = () => {
@@ -169,7 +189,7 @@ export default function Admins() {
                   size="sm"
                   className="font-bold"
                 >
-                  {admin.role === 'super' ? "超级管理员" : "审核员"}
+                  {roleLabel(admin.role)}
                 </Chip>
               </TableCell>
               <TableCell>
@@ -244,12 +264,16 @@ export default function Admins() {
                   label="角色"
                   placeholder="选择角色"
                   selectedKeys={[role]}
-                  onSelectionChange={(keys) => setRole(Array.from(keys)[0] as any)}
+                  onSelectionChange={(keys) => setRole(Array.from(keys)[0] as string)}
                   variant="bordered"
                   radius="lg"
+                  items={roles}
                 >
-                  <SelectItem key="super" textValue="超级管理员">超级管理员</SelectItem>
-                  <SelectItem key="reviewer" textValue="审核员">审核员</SelectItem>
+                  {(r) => (
+                    <SelectItem key={r.name} textValue={r.description || r.name}>
+                      {r.description || r.name}
+                    </SelectItem>
+                  )}
                 </Select>
               </ModalBody>
               <ModalFooter>
@@ -0,0 +1,140 @@
+import { useState, useEffect } from 'react';
+import { 
+  Button, Card, CardBody, Spinner, Chip, Textarea, Select, SelectItem
+} from "@heroui/react";
+import { FaGavel, FaCheck, FaTimes } from 'react-icons/fa';
+import api from '../../api/client';
+import toast from 'react-hot-toast';
+
+interface Appeal {
+  id: number;
+  applicationId: number;
+  email: string;
+  content: string;
+  status: 'pending' | 'overturned' | 'upheld';
+  reviewerUsername: string;
+  decisionOpinion: string;
+  createdAt: string;
+  reason: string;
+  reviewOpinion: string;
+  rejectedBy: string;
+}
+
+export default function Appeals() {
+  const [appeals, setAppeals] = useState<Appeal[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [statusFilter, setStatusFilter] = useState('pending');
+  const [opinions, setOpinions] = useState<Record<number, string>>({});
+  const [submittingId, setSubmittingId] = useState<number | null>(null);
+
+  const fetchAppeals = async () => {
+    setLoading(true);
+    try {
+      const res = await api.get('/admin/appeals', { params: { status: statusFilter } });
+      setAppeals(res.data.data || []);
+    } catch (error: any) {
+      toast.error("无法加载申诉列表");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    fetchAppeals();
+  }, [statusFilter]);
+
+  const handleDecide = async (appeal: Appeal, decision: 'overturn' | 'uphold') => {
+    setSubmittingId(appeal.id);
+    try {
+      await api.post(`/admin/appeals/${appeal.id}/decide`, { decision, opinion: opinions[appeal.id] || '' });
+      toast.success(decision === 'overturn' ? "已改判通过" : "已维持原判");
+      fetchAppeals();
+    } catch (error: any) {
+      toast.error(error.response?.data?.message || "处理失败");
+    } finally {
+      setSubmittingId(null);
+    }
+  };
+
+  return (
+    <div className="flex flex-col gap-6 w-full max-w-5xl mx-auto pb-12">
+      <div className="flex flex-col md:flex-row justify-between items-start md:items-center gap-6 bg-content1 p-8 rounded-large shadow-sm border border-divider">
+        <div className="flex flex-col">
+          <h1 className="text-2xl font-bold tracking-tight flex items-center gap-3">
+            <FaGavel className="text-primary" />
+            申诉处理
+          </h1>
+          <p className="text-sm text-default-500">复核申请人对拒绝结果提出的申诉</p>
+        </div>
+        <Select
+          aria-label="申诉状态"
+          className="w-40"
+          selectedKeys={[statusFilter]}
+          onChange={(e) => setStatusFilter(e.target.value || 'pending')}
+          variant="bordered"
+        >
+          <SelectItem key="pending">待处理</SelectItem>
+          <SelectItem key="overturned">已改判</SelectItem>
+          <SelectItem key="upheld">维持原判</SelectItem>
+          <SelectItem key="all">全部</SelectItem>
+        </Select>
+      </div>
+
+      {loading ? (
+        <div className="flex justify-center p-10"><Spinner size="lg" /></div>
+      ) : appeals.length === 0 ? (
+        <p className="text-center text-default-400 py-10">暂无申诉</p>
+      ) : (
+        appeals.map((appeal) => (
+          <Card key={appeal.id} className="shadow-sm border border-divider">
+            <CardBody className="p-6 gap-4">
+              <div className="flex justify-between items-center">
+                <p className="font-bold">#{appeal.applicationId} {appeal.email}</p>
+                <Chip size="sm" variant="flat" color={appeal.status === 'pending' ? 'warning' : appeal.status === 'overturned' ? 'success' : 'danger'}>
+                  {appeal.status === 'pending' ? '待处理' : appeal.status === 'overturned' ? '已改判' : '维持原判'}
+                </Chip>
+              </div>
+              <div className="grid grid-cols-1 md:grid-cols-2 gap-4 text-sm">
+                <div className="p-3 bg-default-50 dark:bg-default-800/50 rounded-xl border border-divider">
+                  <p className="text-xs font-bold text-default-400 mb-1">申请理由</p>
+                  <p className="whitespace-pre-wrap">{appeal.reason}</p>
+                </div>
+                <div className="p-3 bg-danger/5 rounded-xl border border-danger/10">
+                  <p className="text-xs font-bold text-danger mb-1">拒绝意见{appeal.rejectedBy ? `（${appeal.rejectedBy}）` : ''}</p>
+                  <p className="whitespace-pre-wrap">{appeal.reviewOpinion || '无'}</p>
+                </div>
+              </div>
+              <div className="p-3 bg-primary/5 rounded-xl border border-primary/10 text-sm">
+                <p className="text-xs font-bold text-primary mb-1">申诉内容</p>
+                <p className="whitespace-pre-wrap">{appeal.content}</p>
+              </div>
+              {appeal.status === 'pending' ? (
+                <>
+                  <Textarea
+                    placeholder="处理意见（将通过邮件发送给申请人）"
+                    variant="bordered"
+                    minRows={2}
+                    value={opinions[appeal.id] || ''}
+                    onValueChange={(val) => setOpinions({ ...opinions, [appeal.id]: val })}
+                  />
+                  <div className="flex justify-end gap-2">
+                    <Button color="danger" variant="flat" startContent={<FaTimes />} isLoading={submittingId === appeal.id} onPress={() => handleDecide(appeal, 'uphold')}>
+                      维持原判
+                    </Button>
+                    <Button color="success" variant="flat" startContent={<FaCheck />} isLoading={submittingId === appeal.id} onPress={() => handleDecide(appeal, 'overturn')}>
+                      改判通过
+                    </Button>
+                  </div>
+                </>
+              ) : (
+                <p className="text-xs text-default-500">
+                  {appeal.reviewerUsername} 处理{appeal.decisionOpinion ? `：${appeal.decisionOpinion}` : ''}
+                </p>
+              )}
+            </CardBody>
+          </Card>
+        ))
+      )}
+    </div>
+  );
+}
@@ -3,23 +3,62 @@ import {
   Table, TableHeader, TableColumn, TableBody, TableRow, TableCell, 
   Chip, Button, Modal, ModalContent, ModalHeader, ModalBody, ModalFooter, 
   useDisclosure, Textarea, Input, Spinner, Select, SelectItem, Pagination,
-  Tooltip
+  Tooltip, Selection
 } from "@heroui/react";
 import { FaCheck, FaTimes, FaInfoCircle, FaSync, FaSearch, FaCopy, FaEnvelope, FaCalendarAlt, FaGlobe, FaFingerprint, FaTrash } from 'react-icons/fa';
 import api from '../../api/client';
 import toast from 'react-hot-toast';
+import { formatDuration } from '../../utils/duration';
 
 interface Application {
   id: number;
   email: string;
   reason: string;
-  status: 'pending' | 'approved' | 'rejected';
+  status: 'pending' | 'pending_second_review' | 'approved' | 'rejected' | 'withdrawn';
   deviceId: string;
   ip: string;
   createdAt: string;
   adminNote?: string;
   reviewOpinion?: string;
   adminUsername?: string;
+  lockedBy?: number | null;
+  lockedByUsername?: string;
+  lockedUntil?: string | null;
+  assignedTo?: number | null;
+  assignedToUsername?: string;
+  ageSeconds: number;
+  overdue: boolean;
+  riskScore: number;
+  riskReasons: { rule: string; score: number; reason: string }[];
+  riskFlagged: boolean;
+  similar: { id: number; email: string; status: string; similarity: number }[];
+}
+
+interface RelatedApplication extends Application {
+  matchedBy: string[];
+}
+
+interface ApplicationDetail {
+  application: Application;
+  related: RelatedApplication[];
+  decisions: { id: number; admin_username: string; action: string; application_id: number; target_email: string; created_at: string }[];
+  verifications: { id: number; ip: string; createdAt: string }[];
+  edits: { id: number; oldReason: string; newReason: string; ip: string; createdAt: string }[];
+}
+
+interface ReviewTemplate {
+  id: number;
+  title: string;
+  kind: 'approval' | 'rejection';
+}
+
+interface Comment {
+  id: number;
+  adminId: number;
+  adminUsername: string;
+  content: string;
+  mentions: string[];
+  createdAt: string;
 }
 
 export default function Applications() {
@@ -33,25 +72,41 @@ export default function Applications() {
   const [inviteCode, setInviteCode] = useState('');
   const [adminNote, setAdminNote] = useState('');
   const [reviewOpinion, setReviewOpinion] = useState('');
+  const [templates, setTemplates] = useState<ReviewTemplate[]>([]);
+  const [templateId, setTemplateId] = useState('');
   const [submitting, setSubmitting] = useState(false);
   const [statusFilter, setStatusFilter] = useState('all');
+  const [myQueue, setMyQueue] = useState(false);
+  const [overdueOnly, setOverdueOnly] = useState(false);
+  const [flaggedOnly, setFlaggedOnly] = useState(false);
   const [searchQuery, setSearchQuery] = useState('');
+  const [comments, setComments] = useState<Comment[]>([]);
+  const [detail, setDetail] = useState<ApplicationDetail | null>(null);
+  const [selectedKeys, setSelectedKeys] = useState<Selection>(new Set([]));
+  const [bulkOpinion, setBulkOpinion] = useState('');
+  const [bulkSubmitting, setBulkSubmitting] = useState(false);
+  const [newComment, setNewComment] = useState('');
+  const [commenting, setCommenting] = useState(false);
   
   const {isOpen, onOpen, onClose} = useDisclosure();
   const deleteModal = useDisclosure();
   const [appToDelete, setAppToDelete] = useState<Application | null>(null);
 
   const user = JSON.parse(localStorage.getItem('admin_user') || '{}');
-  const role = user.role || 'reviewer';
+  const permissions: string[] = user.permissions || [];
 
   const fetchApps = async () => {
     setLoading(true);
+    setSelectedKeys(new Set([]));
     try {
       const params: any = {
         page,
         pageSize,
       };
       if (statusFilter !== 'all') params.status = statusFilter;
+      if (myQueue) params.queue = 'mine';
+      if (overdueOnly) params.overdue = 'true';
+      if (flaggedOnly) params.flagged = 'true';
       if (searchQuery) params.search = searchQuery;
       
       const res = await api.get('/admin/applications', { params });
@@ -73,24 +128,73 @@ export default function Applications() {
       fetchApps();
     }, 300);
     return () => clearTimeout(timer);
-  }, [statusFilter, searchQuery, page, pageSize]);
+  }, [statusFilter, myQueue, overdueOnly, flaggedOnly, searchQuery, page, pageSize]);
 
-  const handleOpenDetail = (app: Application) => {
+  const handleOpenDetail = async (app: Application) => {
+    // 打开待处理申请的详情即认领，避免多人同时审核同一申请
+    if (app.status === 'pending' || app.status === 'pending_second_review') {
+      try {
+        await api.post(`/admin/applications/${app.id}/claim`);
+      } catch (error: any) {
+        if (error.response?.status === 409) {
+          toast.error(error.response.data?.message || "该申请已被其他管理员认领");
+        }
+      }
+    }
     setSelectedApp(app);
     setReviewStatus('approved');
     setInviteCode('');
     setAdminNote(app.adminNote || '');
     setReviewOpinion(app.reviewOpinion || '');
+    setTemplateId('');
+    setComments([]);
+    setNewComment('');
+    setDetail(null);
     onOpen();
+    fetchDetail(app.id);
   };
 
-  const submitReview = async () => {
-    if (!selectedApp) return;
-    if (reviewStatus === 'approved' && !inviteCode) {
-      toast.error("请输入邀请码");
-      return;
+  useEffect(() => {
+    api.get('/admin/review-templates')
+      .then((res) => setTemplates(res.data.data || []))
+      .catch(() => setTemplates([]));
+  }, []);
+
+  const fetchDetail = async (appId: number) => {
+    try {
+      const res = await api.get(`/admin/applications/${appId}`);
+      setDetail(res.data.data);
+      setComments(res.data.data.comments || []);
+    } catch (error) {
+      console.error("Failed to fetch application detail", error);
+    }
+  };
+
+  const fetchComments = async (appId: number) => {
+    try {
+      const res = await api.get(`/admin/applications/${appId}/comments`);
+      setComments(res.data.data || []);
+    } catch (error) {
+      console.error("Failed to fetch comments", error);
+    }
+  };
+
+  const submitComment = async () => {
+    if (!selectedApp || !newComment.trim()) return;
+    setCommenting(true);
+    try {
+      await api.post(`/admin/applications/${selectedApp.id}/comments`, { content: newComment });
+      setNewComment('');
+      fetchComments(selectedApp.id);
+    } catch (error: any) {
+      toast.error(error.response?.data?.message || "评论失败");
+    } finally {
+      setCommenting(false);
     }
+  };
 
+  const submitReview = async () => {
+    if (!selectedApp) return;
     setSubmitting(true);
     try {
       await api.post('/admin/review', {
@@ -99,7 +203,8 @@ export default function Applications() {
         data: {
           code: inviteCode,
           note: adminNote,
-          opinion: reviewOpinion
+          opinion: reviewOpinion,
+          templateId: templateId ? Number(templateId) : 0
         }
       });
       toast.success("审核提交成功");
@@ -112,6 +217,29 @@ export default function Applications() {
     }
   };
 
+  const selectedIds = selectedKeys === 'all' ? apps.map((a) => a.id) : Array.from(selectedKeys).map(Number);
+
+  const submitBulk = async (action: 'approve' | 'reject' | 'delete') => {
+    if (selectedIds.length === 0) return;
+    setBulkSubmitting(true);
+    try {
+      const res = await api.post('/admin/review/bulk', { appIds: selectedIds, action, opinion: bulkOpinion });
+      const { succeeded, failed, results } = res.data;
+      if (failed > 0) {
+        const first = results.find((r: any) => !r.success);
+        toast.error(`成功 ${succeeded} 条，失败 ${failed} 条（#${first.appId}: ${first.message}）`);
+      } else {
+        toast.success(`已处理 ${succeeded} 条申请`);
+      }
+      setBulkOpinion('');
+      fetchApps();
+    } catch (error: any) {
+      toast.error(error.response?.data?.message || "批量操作失败");
+    } finally {
+      setBulkSubmitting(false);
+    }
+  };
+
   const handleDelete = async () => {
     if (!appToDelete) return;
     
@@ -177,25 +305,64 @@ export default function Applications() {
         );
       case "reason":
         return (
-          <div className="max-w-[300px] truncate text-default-600" title={app.reason}>
-            {app.reason}
+          <div className="flex flex-col gap-1 items-start">
+            <div className="max-w-[300px] truncate text-default-600" title={app.reason}>
+              {app.reason}
+            </div>
+            {app.similar.length > 0 && (
+              <div className="flex flex-wrap gap-1">
+                {app.similar.map((s) => (
+                  <Tooltip key={s.id} content={`${s.email}`}>
+                    <Chip size="sm" variant="flat" color={s.similarity >= 0.8 ? "danger" : "warning"}>
+                      与 #{s.id} 相似 {Math.round(s.similarity * 100)}%
+                    </Chip>
+                  </Tooltip>
+                ))}
+              </div>
+            )}
           </div>
         );
       case "status":
         const statusColors: Record<string, "warning" | "success" | "danger" | "default"> = {
           pending: "warning",
+          pending_second_review: "warning",
           approved: "success",
-          rejected: "danger"
+          rejected: "danger",
+          withdrawn: "default"
         };
         return (
-          <Chip className="capitalize font-bold" color={statusColors[app.status]} size="sm" variant="flat">
-            {app.status === 'pending' ? '待审核' : app.status === 'approved' ? '已批准' : '已拒绝'}
-          </Chip>
+          <div className="flex flex-col gap-1 items-start">
+            <Chip className="capitalize font-bold" color={statusColors[app.status]} size="sm" variant="flat">
+              {app.status === 'pending' ? '待审核' : app.status === 'pending_second_review' ? '待复核' : app.status === 'approved' ? '已批准' : app.status === 'withdrawn' ? '已撤回' : '已拒绝'}
+            </Chip>
+            {app.lockedByUsername && (
+              <span className="text-tiny text-default-400">{app.lockedByUsername} 审核中</span>
+            )}
+            {!app.lockedByUsername && app.status === 'pending' && app.assignedToUsername && (
+              <span className="text-tiny text-default-400">已分配给 {app.assignedToUsername}</span>
+            )}
+            {app.riskScore > 0 && (
+              <Tooltip content={
+                <div className="text-tiny space-y-1 py-1">
+                  {app.riskReasons.map((r) => <p key={r.rule}>+{r.score} {r.reason}</p>)}
+                </div>
+              }>
+                <Chip size="sm" variant="flat" color={app.riskFlagged ? "danger" : "default"}>
+                  风险分 {app.riskScore}
+                </Chip>
+              </Tooltip>
+            )}
+          </div>
         );
       case "createdAt":
         return (
-          <div className="text-default-500 text-sm">
+          <div className="flex flex-col gap-1 items-start text-default-500 text-sm">
             {formatDate(app.createdAt)}
+            {(app.status === 'pending' || app.status === 'pending_second_review') && (
+              <span className={`text-tiny ${app.overdue ? 'text-danger font-bold' : 'text-default-400'}`}>
+                已等待 {formatDuration(app.ageSeconds)}{app.overdue ? '（超时）' : ''}
+              </span>
+            )}
           </div>
         );
       case "actions":
@@ -211,7 +378,7 @@ export default function Applications() {
             >
               详情
             </Button>
-            {role === 'super' && (
+            {permissions.includes('applications.delete') && (
               <Tooltip content="删除申请" color="danger">
                 <Button 
                   size="sm" 
@@ -275,9 +442,35 @@ export default function Applications() {
           >
             <SelectItem key="all" textValue="全部状态">全部状态</SelectItem>
             <SelectItem key="pending" textValue="待审核">待审核</SelectItem>
+            <SelectItem key="pending_second_review" textValue="待复核">待复核</SelectItem>
             <SelectItem key="approved" textValue="已批准">已批准</SelectItem>
             <SelectItem key="rejected" textValue="已拒绝">已拒绝</SelectItem>
+            <SelectItem key="withdrawn" textValue="已撤回">已撤回</SelectItem>
           </Select>
+          <Button
+            variant={myQueue ? "solid" : "flat"}
+            color="primary"
+            onPress={() => { setMyQueue(!myQueue); setPage(1); }}
+            className="h-12 rounded-large font-bold"
+          >
+            我的队列
+          </Button>
+          <Button
+            variant={overdueOnly ? "solid" : "flat"}
+            color="danger"
+            onPress={() => { setOverdueOnly(!overdueOnly); setPage(1); }}
+            className="h-12 rounded-large font-bold"
+          >
+            超时
+          </Button>
+          <Button
+            variant={flaggedOnly ? "solid" : "flat"}
+            color="danger"
+            onPress={() => { setFlaggedOnly(!flaggedOnly); setPage(1); }}
+            className="h-12 rounded-large font-bold"
+          >
+            高风险
+          </Button>
           <Button 
             isIconOnly 
             variant="flat" 
@@ -290,10 +483,44 @@ export default function Applications() {
         </div>
       </div>
 
+      {selectedIds.length > 0 && (
+        <div className="flex flex-col md:flex-row items-start md:items-center gap-3 bg-content1 p-4 rounded-large shadow-sm border border-primary/30">
+          <span className="text-sm font-bold text-default-600 whitespace-nowrap">已选择 {selectedIds.length} 条</span>
+          <Input
+            aria-label="批量审核意见"
+            placeholder="统一的审核意见（将发送给申请人）"
+            value={bulkOpinion}
+            onValueChange={setBulkOpinion}
+            variant="bordered"
+            size="sm"
+          />
+          <div className="flex gap-2">
+            <Button size="sm" color="success" variant="flat" startContent={<FaCheck />} isLoading={bulkSubmitting} onPress={() => submitBulk('approve')}>
+              批量批准
+            </Button>
+            <Button size="sm" color="danger" variant="flat" startContent={<FaTimes />} isLoading={bulkSubmitting} onPress={() => submitBulk('reject')}>
+              批量拒绝
+            </Button>
+            {permissions.includes('applications.delete') && (
+              <Button size="sm" color="danger" startContent={<FaTrash />} isLoading={bulkSubmitting} onPress={() => submitBulk('delete')}>
+                批量删除
+              </Button>
+            )}
+          </div>
+        </div>
+      )}
+
       <div className="bg-content1 rounded-large shadow-sm border border-divider overflow-hidden">
         <Table 
           aria-label="申请列表" 
           removeWrapper
+          selectionMode="multiple"
+          selectedKeys={selectedKeys}
+          onSelectionChange={setSelectedKeys}
+          onRowAction={(key) => {
+            const app = apps.find((a) => a.id === Number(key));
+            if (app) handleOpenDetail(app);
+          }}
           className="min-w-full"
           classNames={{
             th: "bg-default-100 text-default-500 font-bold h-12 first:pl-6 last:pr-6",
@@ -314,7 +541,7 @@ export default function Applications() {
             loadingState={loading ? "loading" : "idle"}
           >
             {(app) => (
-              <TableRow key={app.id} className="hover:bg-default-50/50 dark:hover:bg-default-800/30 transition-colors cursor-pointer" onClick={() => handleOpenDetail(app)}>
+              <TableRow key={app.id} className="hover:bg-default-50/50 dark:hover:bg-default-800/30 transition-colors cursor-pointer">
                 {(columnKey) => <TableCell>{renderCell(app, columnKey)}</TableCell>}
               </TableRow>
             )}
@@ -408,6 +635,36 @@ export default function Applications() {
               </div>
             </div>
 
+            {/* 相似申请 */}
+            {detail && detail.application.similar.length > 0 && (
+              <div className="space-y-2">
+                <p className="text-xs font-bold text-default-400 uppercase">理由相似的历史申请</p>
+                <div className="flex flex-wrap gap-2">
+                  {detail.application.similar.map((s) => (
+                    <Chip key={s.id} variant="flat" color={s.similarity >= 0.8 ? "danger" : "warning"}>
+                      #{s.id} {s.email} • {Math.round(s.similarity * 100)}%
+                    </Chip>
+                  ))}
+                </div>
+              </div>
+            )}
+
+            {/* 风险评分 */}
+            {selectedApp && selectedApp.riskReasons.length > 0 && (
+              <div className="space-y-2">
+                <p className="text-xs font-bold text-default-400 uppercase">
+                  风险评分 {selectedApp.riskScore}{selectedApp.riskFlagged ? '（需人工复核）' : ''}
+                </p>
+                <div className={`p-4 rounded-xl border space-y-1 ${selectedApp.riskFlagged ? 'bg-danger/5 border-danger/20' : 'bg-default-50 dark:bg-default-800/50 border-divider'}`}>
+                  {selectedApp.riskReasons.map((r) => (
+                    <p key={r.rule} className="text-sm text-default-700">
+                      <span className="font-mono font-bold mr-2">+{r.score}</span>{r.reason}
+                    </p>
+                  ))}
+                </div>
+              </div>
+            )}
+
             {/* 审核区域 */}
             <div className="space-y-4 pt-4 border-t border-divider">
               <div className="flex items-center justify-between">
@@ -420,22 +677,22 @@ export default function Applications() {
                   )}
                   {selectedApp?.status !== 'pending' && (
                     <Chip 
-                      color={selectedApp?.status === 'approved' ? 'success' : 'danger'} 
+                      color={selectedApp?.status === 'approved' ? 'success' : selectedApp?.status === 'pending_second_review' ? 'warning' : selectedApp?.status === 'withdrawn' ? 'default' : 'danger'} 
                       variant="flat"
                       className="font-bold"
                     >
-                      {selectedApp?.status === 'approved' ? '已批准' : '已拒绝'}
+                      {selectedApp?.status === 'approved' ? '已批准' : selectedApp?.status === 'pending_second_review' ? '待复核' : selectedApp?.status === 'withdrawn' ? '已撤回' : '已拒绝'}
                     </Chip>
                   )}
                 </div>
               </div>
 
-              {selectedApp?.status === 'pending' ? (
+              {selectedApp?.status === 'pending' || selectedApp?.status === 'pending_second_review' ? (
                 <div className="space-y-6 animate-in fade-in slide-in-from-top-4 duration-300">
                   <div className="flex gap-4">
                     <Button
                       className={`flex-grow h-14 font-bold ${reviewStatus === 'approved' ? 'bg-primary text-white shadow-lg' : 'bg-default-100'}`}
-                      onPress={() => setReviewStatus('approved')}
+                      onPress={() => { setReviewStatus('approved'); setTemplateId(''); }}
                       startContent={<FaCheck />}
                       radius="lg"
                     >
@@ -443,7 +700,7 @@ export default function Applications() {
                     </Button>
                     <Button
                       className={`flex-grow h-14 font-bold ${reviewStatus === 'rejected' ? 'bg-danger text-white shadow-lg' : 'bg-default-100'}`}
-                      onPress={() => setReviewStatus('rejected')}
+                      onPress={() => { setReviewStatus('rejected'); setTemplateId(''); }}
                       startContent={<FaTimes />}
                       radius="lg"
                     >
@@ -454,7 +711,7 @@ export default function Applications() {
                   {reviewStatus === 'approved' && (
                     <Input
                       label="邀请码"
-                      placeholder="输入要发放的邀请码"
+                      placeholder="留空则自动从库存分配，填写则使用指定邀请码"
                       value={inviteCode}
                       onValueChange={setInviteCode}
                       variant="bordered"
@@ -468,6 +725,22 @@ export default function Applications() {
                     />
                   )}
 
+                  {templates.some((t) => t.kind === (reviewStatus === 'approved' ? 'approval' : 'rejection')) && (
+                    <Select
+                      label="审核模板"
+                      placeholder="不使用模板"
+                      description="选择模板后由系统生成审核意见，下方填写的内容将作为补充说明附在模板之后。"
+                      selectedKeys={templateId ? [templateId] : []}
+                      onChange={(e) => setTemplateId(e.target.value)}
+                      variant="bordered"
+                      radius="lg"
+                    >
+                      {templates
+                        .filter((t) => t.kind === (reviewStatus === 'approved' ? 'approval' : 'rejection'))
+                        .map((t) => <SelectItem key={String(t.id)}>{t.title}</SelectItem>)}
+                    </Select>
+                  )}
+
                   <Textarea
                     label="审核意见"
                     placeholder="将发送给申请人的说明（如：已通过、申请理由不足等）"
@@ -500,6 +773,8 @@ export default function Applications() {
                     }}
                   />
                 </div>
+              ) : selectedApp?.status === 'withdrawn' ? (
+                <p className="text-sm text-default-400 italic">申请人已撤回该申请，无需审核</p>
               ) : (
                 <div className="grid grid-cols-1 md:grid-cols-2 gap-4">
                   <div className="p-4 bg-primary/5 rounded-xl border border-primary/10">
@@ -517,6 +792,76 @@ export default function Applications() {
                 </div>
               )}
             </div>
+
+            {detail && detail.edits.length > 0 && (
+              <div className="space-y-3">
+                <p className="text-xs font-bold text-default-400 uppercase">申请人修改记录（{detail.edits.length} 次）</p>
+                {detail.edits.map((e) => (
+                  <div key={e.id} className="p-3 bg-default-50 dark:bg-default-800/50 rounded-xl border border-divider text-sm space-y-2">
+                    <p className="text-xs text-default-400">{formatDate(e.createdAt)}{e.ip ? ` • ${e.ip}` : ''}</p>
+                    <p className="text-default-400 line-through whitespace-pre-wrap">{e.oldReason}</p>
+                    <p className="text-default-700 whitespace-pre-wrap">{e.newReason}</p>
+                  </div>
+                ))}
+              </div>
+            )}
+
+            {detail && (
+              <div className="space-y-3">
+                <p className="text-xs font-bold text-default-400 uppercase">
+                  关联记录（验证码发送 {detail.verifications.length} 次）
+                </p>
+                {detail.related.length === 0 ? (
+                  <p className="text-sm text-default-400 italic">没有共用邮箱、设备或 IP 的其他申请</p>
+                ) : (
+                  detail.related.map((r) => (
+                    <div key={r.id} className="flex justify-between items-center p-3 bg-warning/5 rounded-xl border border-warning/20 text-sm">
+                      <span className="font-semibold text-default-700">#{r.id} {r.email}</span>
+                      <span className="text-xs text-default-500">
+                        相同{r.matchedBy.map((m) => ({ email: '邮箱', device: '设备', ip: 'IP' } as Record<string, string>)[m]).join('/')}
+                        {' • '}{r.status}{r.adminUsername ? ` • ${r.adminUsername}` : ''}
+                      </span>
+                    </div>
+                  ))
+                )}
+                {detail.decisions.length > 0 && (
+                  <div className="text-xs text-default-500 space-y-1">
+                    {detail.decisions.map((d) => (
+                      <p key={d.id}>{formatDate(d.created_at)} {d.admin_username} {d.action} #{d.application_id} ({d.target_email})</p>
+                    ))}
+                  </div>
+                )}
+              </div>
+            )}
+
+            <div className="space-y-3">
+              <p className="text-xs font-bold text-default-400 uppercase">内部评论（申请人不可见，使用 @用户名 提醒其他管理员）</p>
+              {comments.length === 0 ? (
+                <p className="text-sm text-default-400 italic">暂无评论</p>
+              ) : (
+                comments.map((cm) => (
+                  <div key={cm.id} className="p-3 bg-default-50 dark:bg-default-800/50 rounded-xl border border-divider">
+                    <div className="flex justify-between text-xs text-default-400 mb-1">
+                      <span className="font-bold">{cm.adminUsername}</span>
+                      <span>{formatDate(cm.createdAt)}</span>
+                    </div>
+                    <p className="text-sm text-default-600 whitespace-pre-wrap">{cm.content}</p>
+                  </div>
+                ))
+              )}
+              <div className="flex gap-2 items-end">
+                <Textarea
+                  placeholder="添加内部评论..."
+                  value={newComment}
+                  onValueChange={setNewComment}
+                  variant="bordered"
+                  minRows={1}
+                />
+                <Button color="primary" variant="flat" onPress={submitComment} isLoading={commenting} isDisabled={!newComment.trim()}>
+                  发送
+                </Button>
+              </div>
+            </div>
           </ModalBody>
           <ModalFooter>
             <Button 
@@ -3,29 +3,35 @@ import Settings from './Settings';
 import Announcements from './Announcements';
 import Admins from './Admins';
 import AuditLogs from './AuditLogs';
+import ReviewTemplates from './ReviewTemplates';
+import Appeals from './Appeals';
+import ReviewStats from './ReviewStats';
 import { useLocation } from 'react-router-dom';
 
 export default function Dashboard() {
   const location = useLocation();
 
-  // Get user role from localStorage
+  // Get user permissions from localStorage
   const user = JSON.parse(localStorage.getItem('admin_user') || '{}');
-  const role = user.role || 'reviewer';
+  const permissions: string[] = user.permissions || [];
 
   // Get active tab from URL query params
   const searchParams = new URLSearchParams(location.search);
-  const activeTab = (searchParams.get('tab') as 'applications' | 'settings' | 'announcements' | 'admins' | 'audit-logs') || 'applications';
+  const activeTab = (searchParams.get('tab') as 'applications' | 'settings' | 'announcements' | 'admins' | 'audit-logs' | 'review-templates' | 'appeals' | 'review-stats') || 'applications';
 
   return (
     <div className="flex flex-col w-full min-h-[calc(100vh-64px)] bg-default-50/50">
       {/* Main Content Area */}
       <div className="flex-grow container mx-auto px-6 py-8">
         <div className="animate-in fade-in slide-in-from-bottom-4 duration-500">
-          {activeTab === 'applications' && <Applications />}
-          {activeTab === 'announcements' && role === 'super' && <Announcements />}
-          {activeTab === 'settings' && role === 'super' && <Settings />}
-          {activeTab === 'admins' && role === 'super' && <Admins />}
-          {activeTab === 'audit-logs' && role === 'super' && <AuditLogs />}
+          {activeTab === 'applications' && permissions.includes('applications.review') && <Applications />}
+          {activeTab === 'announcements' && permissions.includes('announcements.manage') && <Announcements />}
+          {activeTab === 'settings' && permissions.includes('settings.write') && <Settings />}
+          {activeTab === 'admins' && permissions.includes('admins.manage') && <Admins />}
+          {activeTab === 'audit-logs' && permissions.includes('audit.read') && <AuditLogs />}
+          {activeTab === 'review-templates' && permissions.includes('templates.manage') && <ReviewTemplates />}
+          {activeTab === 'appeals' && permissions.includes('appeals.review') && <Appeals />}
+          {activeTab === 'review-stats' && permissions.includes('audit.read') && <ReviewStats />}
         </div>
       </div>
     </div>
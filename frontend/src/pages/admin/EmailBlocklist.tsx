@@ -0,0 +1,136 @@
+import { useState, useEffect } from 'react';
+import {
+  Card, CardBody, CardHeader, Divider, Button, Chip, Input
+} from "@heroui/react";
+import { FaBan, FaPlus, FaSearch } from 'react-icons/fa';
+import api from '../../api/client';
+import toast from 'react-hot-toast';
+
+interface BlockedDomain {
+  domain: string;
+  source: 'bundled' | 'custom';
+  hits: number;
+  createdAt: string;
+}
+
+interface EmailRejectionStats {
+  total: number;
+  blocklist: number;
+  noMx: number;
+}
+
+export default function EmailBlocklist() {
+  const user = JSON.parse(localStorage.getItem('admin_user') || '{}');
+  const canManage = (user.permissions || []).includes('blocklist.manage');
+
+  const [items, setItems] = useState<BlockedDomain[]>([]);
+  const [rejections, setRejections] = useState<EmailRejectionStats | null>(null);
+  const [search, setSearch] = useState('');
+  const [newDomain, setNewDomain] = useState('');
+  const [adding, setAdding] = useState(false);
+
+  const fetchBlocklist = async () => {
+    try {
+      const res = await api.get('/admin/email-blocklist', { params: { search } });
+      setItems(res.data.data.items);
+      setRejections(res.data.data.rejections);
+    } catch (error: any) {
+      toast.error("无法加载邮箱域名黑名单");
+    }
+  };
+
+  useEffect(() => {
+    fetchBlocklist();
+  }, [search]);
+
+  const handleAdd = async () => {
+    if (!newDomain.trim()) return;
+    setAdding(true);
+    try {
+      const res = await api.post('/admin/email-blocklist', { domain: newDomain });
+      toast.success(res.data.message);
+      setNewDomain('');
+      fetchBlocklist();
+    } catch (error: any) {
+      toast.error(error.response?.data?.message || "添加失败");
+    } finally {
+      setAdding(false);
+    }
+  };
+
+  const handleRemove = async (domain: string) => {
+    try {
+      const res = await api.delete(`/admin/email-blocklist/${encodeURIComponent(domain)}`);
+      toast.success(res.data.message);
+      fetchBlocklist();
+    } catch (error: any) {
+      toast.error(error.response?.data?.message || "移除失败");
+    }
+  };
+
+  return (
+    <Card className="shadow-sm border border-divider md:col-span-2">
+      <CardHeader className="flex justify-between px-6 py-4">
+        <div className="flex items-center gap-3">
+          <FaBan className="text-danger" size={20} />
+          <p className="font-bold text-lg">临时邮箱黑名单</p>
+          <Chip size="sm" variant="flat">{items.length} 条</Chip>
+        </div>
+        {rejections && (
+          <p className="text-tiny text-default-500">
+            已拒绝 {rejections.total} 次（黑名单 {rejections.blocklist}，无 MX 记录 {rejections.noMx}）
+          </p>
+        )}
+      </CardHeader>
+      <Divider />
+      <CardBody className="gap-4 px-6 py-6">
+        <div className="flex flex-col md:flex-row gap-3">
+          <Input
+            placeholder="搜索域名"
+            value={search}
+            onValueChange={setSearch}
+            startContent={<FaSearch className="text-default-400" />}
+            variant="bordered"
+            radius="lg"
+            className="md:max-w-xs"
+          />
+          {canManage && (
+            <div className="flex gap-3 flex-1">
+              <Input
+                placeholder="example.com 或 *.example.com（匹配全部子域名）"
+                value={newDomain}
+                onValueChange={setNewDomain}
+                onKeyDown={(e) => e.key === 'Enter' && handleAdd()}
+                variant="bordered"
+                radius="lg"
+              />
+              <Button color="primary" onPress={handleAdd} isLoading={adding} startContent={<FaPlus />} radius="lg" className="font-bold">
+                添加
+              </Button>
+            </div>
+          )}
+        </div>
+
+        <div className="flex flex-wrap gap-2 max-h-64 overflow-y-auto">
+          {items.length === 0 && <p className="text-sm text-default-400">没有匹配的域名</p>}
+          {items.map((d) => (
+            <Chip
+              key={d.domain}
+              variant="flat"
+              color={d.source === 'custom' ? 'primary' : 'default'}
+              onClose={canManage ? () => handleRemove(d.domain) : undefined}
+            >
+              <span className="font-mono">{d.domain}</span>
+              {d.hits > 0 && <span className="text-danger font-bold ml-1">×{d.hits}</span>}
+            </Chip>
+          ))}
+        </div>
+        {!canManage && (
+          <p className="text-tiny text-default-400">
+            您没有添加或移除域名的权限
+          </p>
+        )}
+      </CardBody>
+    </Card>
+  );
+}
@@ -0,0 +1,153 @@
+import { useState, useEffect } from 'react';
+import {
+  Card, CardBody, CardHeader, Divider, Button, Chip, Select, SelectItem, Tooltip
+} from "@heroui/react";
+import { FaTachometerAlt, FaSync } from 'react-icons/fa';
+import api from '../../api/client';
+import toast from 'react-hot-toast';
+
+interface PowDifficultyPoint {
+  time: string;
+  challenges: number;
+  avgDifficulty: number;
+  maxDifficulty: number;
+}
+
+interface PowStatus {
+  base: number;
+  override: number | null;
+  current: number;
+  volume: number;
+  surgeVolume: number;
+  max: number;
+  curve: PowDifficultyPoint[];
+}
+
+export default function PowDifficulty() {
+  const [status, setStatus] = useState<PowStatus | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [pinned, setPinned] = useState('auto');
+  const [saving, setSaving] = useState(false);
+
+  const fetchStatus = async () => {
+    setLoading(true);
+    try {
+      const res = await api.get('/admin/pow');
+      setStatus(res.data.data);
+      setPinned(res.data.data.override === null ? 'auto' : String(res.data.data.override));
+    } catch (error: any) {
+      toast.error("无法加载 PoW 难度");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    fetchStatus();
+  }, []);
+
+  const handleSave = async () => {
+    setSaving(true);
+    try {
+      const res = await api.put('/admin/pow/override', {
+        difficulty: pinned === 'auto' ? null : Number(pinned)
+      });
+      toast.success(res.data.message);
+      fetchStatus();
+    } catch (error: any) {
+      toast.error(error.response?.data?.message || "更新失败");
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const levels = status ? Array.from({ length: status.max + 1 }, (_, i) => String(i)) : [];
+
+  return (
+    <Card className="shadow-sm border border-divider md:col-span-2">
+      <CardHeader className="flex justify-between px-6 py-4">
+        <div className="flex items-center gap-3">
+          <FaTachometerAlt className="text-warning" size={20} />
+          <p className="font-bold text-lg">PoW 难度</p>
+          {status && (
+            <Chip size="sm" variant="flat" color={status.override !== null ? 'warning' : 'success'}>
+              {status.override !== null ? `已固定为 ${status.override}` : '自动调整'}
+            </Chip>
+          )}
+        </div>
+        <button
+          onClick={fetchStatus}
+          className="p-2 hover:bg-default-100 rounded-full transition-colors"
+          title="刷新"
+        >
+          <FaSync className={loading ? "animate-spin" : ""} />
+        </button>
+      </CardHeader>
+      <Divider />
+      {status && (
+        <CardBody className="gap-6 px-6 py-6">
+          <div className="grid grid-cols-2 md:grid-cols-4 gap-4">
+            <div>
+              <p className="text-xs text-default-400 font-bold">基础难度</p>
+              <p className="text-2xl font-black">{status.base}</p>
+            </div>
+            <div>
+              <p className="text-xs text-default-400 font-bold">当前难度</p>
+              <p className={`text-2xl font-black ${status.current > status.base ? 'text-warning' : ''}`}>{status.current}</p>
+            </div>
+            <div>
+              <p className="text-xs text-default-400 font-bold">近 10 分钟提交量</p>
+              <p className="text-2xl font-black">{status.volume}</p>
+            </div>
+            <div>
+              <p className="text-xs text-default-400 font-bold">激增阈值</p>
+              <p className="text-2xl font-black">{status.surgeVolume}</p>
+            </div>
+          </div>
+
+          <div>
+            <p className="text-xs text-default-400 font-bold mb-2">近 24 小时签发难度（每 10 分钟）</p>
+            {status.curve.length === 0 ? (
+              <p className="text-sm text-default-400">暂无签发记录</p>
+            ) : (
+              <div className="flex items-end gap-[2px] h-32 border-b border-divider">
+                {status.curve.map((p) => (
+                  <Tooltip
+                    key={p.time}
+                    content={`${new Date(p.time).toLocaleString()}：平均 ${p.avgDifficulty.toFixed(1)}，最高 ${p.maxDifficulty}，共 ${p.challenges} 次`}
+                  >
+                    <div className="flex-1 h-full flex items-end">
+                      <div
+                        className={`w-full rounded-t ${p.maxDifficulty > status.base ? 'bg-warning' : 'bg-primary'}`}
+                        style={{ height: `${Math.max(p.avgDifficulty / status.max, 0.02) * 100}%` }}
+                      />
+                    </div>
+                  </Tooltip>
+                ))}
+              </div>
+            )}
+          </div>
+
+          <div className="flex items-end gap-3">
+            <Select
+              label="固定难度"
+              description="固定后不再根据提交量与可疑行为自动调整"
+              className="max-w-xs"
+              variant="bordered"
+              selectedKeys={[pinned]}
+              onSelectionChange={(keys) => setPinned(Array.from(keys)[0] as string)}
+            >
+              {[
+                <SelectItem key="auto">自动调整</SelectItem>,
+                ...levels.map((level) => <SelectItem key={level}>{level}</SelectItem>)
+              ]}
+            </Select>
+            <Button color="primary" onPress={handleSave} isLoading={saving} radius="lg" className="font-bold mb-6">
+              应用
+            </Button>
+          </div>
+        </CardBody>
+      )}
+    </Card>
+  );
+}
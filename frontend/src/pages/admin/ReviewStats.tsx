@@ -0,0 +1,161 @@
+import { useState, useEffect } from 'react';
+import {
+  Table, TableHeader, TableColumn, TableBody, TableRow, TableCell,
+  Chip, Spinner, Card, CardHeader, CardBody, Select, SelectItem
+} from "@heroui/react";
+import { FaStopwatch, FaSync } from 'react-icons/fa';
+import api from '../../api/client';
+import toast from 'react-hot-toast';
+import { formatDuration } from '../../utils/duration';
+
+interface DecisionTimeStats {
+  adminId: number;
+  username: string;
+  decisions: number;
+  medianSeconds: number;
+  p90Seconds: number;
+  p99Seconds: number;
+}
+
+interface OverdueApplication {
+  id: number;
+  email: string;
+  status: string;
+  createdAt: string;
+  assignedToUsername: string;
+}
+
+interface ReviewStatsData {
+  slaHours: number;
+  days: number;
+  overdueTotal: number;
+  overdue: OverdueApplication[];
+  overall: DecisionTimeStats;
+  reviewers: DecisionTimeStats[];
+}
+
+export default function ReviewStats() {
+  const [data, setData] = useState<ReviewStatsData | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [days, setDays] = useState('30');
+
+  const fetchStats = async () => {
+    setLoading(true);
+    try {
+      const res = await api.get('/admin/review-stats', { params: { days } });
+      setData(res.data.data);
+    } catch (error: any) {
+      toast.error("无法加载审核时效统计");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    fetchStats();
+  }, [days]);
+
+  return (
+    <div className="space-y-6">
+      <Card className="shadow-sm border border-divider">
+        <CardHeader className="flex justify-between px-6 py-4">
+          <div className="flex items-center gap-2">
+            <FaStopwatch className="text-primary" size={20} />
+            <h1 className="text-xl font-bold">审核时效</h1>
+            {data && data.slaHours > 0 && (
+              <Chip size="sm" variant="flat">时效 {data.slaHours} 小时</Chip>
+            )}
+          </div>
+          <div className="flex items-center gap-3">
+            <Select
+              aria-label="统计范围"
+              size="sm"
+              className="w-32"
+              selectedKeys={[days]}
+              onSelectionChange={(keys) => setDays(Array.from(keys)[0] as string)}
+            >
+              <SelectItem key="7">近 7 天</SelectItem>
+              <SelectItem key="30">近 30 天</SelectItem>
+              <SelectItem key="90">近 90 天</SelectItem>
+              <SelectItem key="0">全部</SelectItem>
+            </Select>
+            <button
+              onClick={fetchStats}
+              className="p-2 hover:bg-default-100 rounded-full transition-colors"
+              title="刷新"
+            >
+              <FaSync className={loading ? "animate-spin" : ""} />
+            </button>
+          </div>
+        </CardHeader>
+        {data && (
+          <CardBody className="grid grid-cols-2 md:grid-cols-4 gap-4 px-6 pb-6">
+            <div>
+              <p className="text-xs text-default-400 font-bold">超时未处理</p>
+              <p className={`text-2xl font-black ${data.overdueTotal > 0 ? 'text-danger' : ''}`}>{data.overdueTotal}</p>
+            </div>
+            <div>
+              <p className="text-xs text-default-400 font-bold">中位耗时</p>
+              <p className="text-2xl font-black">{data.overall.decisions ? formatDuration(data.overall.medianSeconds) : '-'}</p>
+            </div>
+            <div>
+              <p className="text-xs text-default-400 font-bold">P90 耗时</p>
+              <p className="text-2xl font-black">{data.overall.decisions ? formatDuration(data.overall.p90Seconds) : '-'}</p>
+            </div>
+            <div>
+              <p className="text-xs text-default-400 font-bold">P99 耗时</p>
+              <p className="text-2xl font-black">{data.overall.decisions ? formatDuration(data.overall.p99Seconds) : '-'}</p>
+            </div>
+          </CardBody>
+        )}
+      </Card>
+
+      <Table aria-label="审核员耗时统计" classNames={{ wrapper: "shadow-sm border border-divider" }}>
+        <TableHeader>
+          <TableColumn>审核员</TableColumn>
+          <TableColumn>处理数</TableColumn>
+          <TableColumn>中位耗时</TableColumn>
+          <TableColumn>P90</TableColumn>
+          <TableColumn>P99</TableColumn>
+        </TableHeader>
+        <TableBody
+          items={data?.reviewers || []}
+          isLoading={loading}
+          loadingContent={<Spinner label="加载中..." />}
+          emptyContent="暂无已处理的申请"
+        >
+          {(r) => (
+            <TableRow key={r.adminId}>
+              <TableCell className="font-bold">{r.username || `#${r.adminId}`}</TableCell>
+              <TableCell>{r.decisions}</TableCell>
+              <TableCell>{formatDuration(r.medianSeconds)}</TableCell>
+              <TableCell>{formatDuration(r.p90Seconds)}</TableCell>
+              <TableCell>{formatDuration(r.p99Seconds)}</TableCell>
+            </TableRow>
+          )}
+        </TableBody>
+      </Table>
+
+      {data && data.overdue.length > 0 && (
+        <Table aria-label="超时未处理的申请" classNames={{ wrapper: "shadow-sm border border-divider" }}>
+          <TableHeader>
+            <TableColumn>申请</TableColumn>
+            <TableColumn>已等待</TableColumn>
+            <TableColumn>分配给</TableColumn>
+          </TableHeader>
+          <TableBody items={data.overdue}>
+            {(a) => (
+              <TableRow key={a.id}>
+                <TableCell>#{a.id} {a.email}</TableCell>
+                <TableCell className="text-danger font-bold">
+                  {formatDuration((Date.now() - new Date(a.createdAt).getTime()) / 1000)}
+                </TableCell>
+                <TableCell>{a.assignedToUsername || '未分配'}</TableCell>
+              </TableRow>
+            )}
+          </TableBody>
+        </Table>
+      )}
+    </div>
+  );
+}
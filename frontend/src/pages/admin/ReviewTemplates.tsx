@@ -0,0 +1,233 @@
+import { useState, useEffect } from 'react';
+import { 
+  Button, Card, CardBody, Spinner, Chip, Input, Select, SelectItem,
+  Table, TableHeader, TableColumn, TableBody, TableRow, TableCell,
+  Textarea, Modal, ModalContent, ModalHeader, ModalBody, ModalFooter, useDisclosure
+} from "@heroui/react";
+import { FaClipboardList, FaPlus, FaTrash, FaEdit } from 'react-icons/fa';
+import api from '../../api/client';
+import toast from 'react-hot-toast';
+
+interface ReviewTemplate {
+  id: number;
+  title: string;
+  body: string;
+  kind: 'approval' | 'rejection';
+}
+
+interface TemplateStat {
+  id: number;
+  totalUses: number;
+  recentUses: number;
+}
+
+export default function ReviewTemplates() {
+  const [templates, setTemplates] = useState<ReviewTemplate[]>([]);
+  const [stats, setStats] = useState<Record<number, TemplateStat>>({});
+  const [loading, setLoading] = useState(true);
+  const [editing, setEditing] = useState<ReviewTemplate | null>(null);
+  const [title, setTitle] = useState('');
+  const [body, setBody] = useState('');
+  const [kind, setKind] = useState<'approval' | 'rejection'>('rejection');
+  const [isSubmitting, setIsSubmitting] = useState(false);
+  const {isOpen, onOpen, onClose} = useDisclosure();
+
+  const fetchTemplates = async () => {
+    setLoading(true);
+    try {
+      const [listRes, statsRes] = await Promise.all([
+        api.get('/admin/review-templates'),
+        api.get('/admin/review-templates/stats'),
+      ]);
+      setTemplates(listRes.data.data || []);
+      const byId: Record<number, TemplateStat> = {};
+      (statsRes.data.data || []).forEach((s: TemplateStat) => { byId[s.id] = s; });
+      setStats(byId);
+    } catch (error: any) {
+      toast.error("无法加载审核模板");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    fetchTemplates();
+  }, []);
+
+  const openEditor = (template: ReviewTemplate | null) => {
+    setEditing(template);
+    setTitle(template?.title || '');
+    setBody(template?.body || '');
+    setKind(template?.kind || 'rejection');
+    onOpen();
+  };
+
+  const handleSave = async () => {
+    if (!title.trim() || !body.trim()) {
+      toast.error("请填写模板标题与内容");
+      return;
+    }
+    setIsSubmitting(true);
+    try {
+      if (editing) {
+        await api.put(`/admin/review-templates/${editing.id}`, { title, body, kind });
+      } else {
+        await api.post('/admin/review-templates', { title, body, kind });
+      }
+      toast.success("模板已保存");
+      onClose();
+      fetchTemplates();
+    } catch (error: any) {
+      toast.error(error.response?.data?.message || "保存失败");
+    } finally {
+      setIsSubmitting(false);
+    }
+  };
+
+  const handleDelete = async (id: number) => {
+    if (!confirm("确定删除该模板吗？")) return;
+    try {
+      await api.delete(`/admin/review-templates/${id}`);
+      toast.success("已删除");
+      fetchTemplates();
+    } catch (error: any) {
+      toast.error("删除失败");
+    }
+  };
+
+  if (loading) return <div className="flex justify-center p-10"><Spinner size="lg" /></div>;
+
+  return (
+    <div className="flex flex-col gap-6 w-full max-w-5xl mx-auto pb-12">
+      <div className="flex flex-col md:flex-row justify-between items-start md:items-center gap-6 bg-content1 p-8 rounded-large shadow-sm border border-divider">
+        <div className="flex flex-col">
+          <h1 className="text-2xl font-bold tracking-tight flex items-center gap-3">
+            <FaClipboardList className="text-primary" />
+            审核模板
+          </h1>
+          <p className="text-sm text-default-500">{'常用审核意见，支持 {{email}} 与 {{site_name}} 占位符'}</p>
+        </div>
+        <Button 
+          color="primary" 
+          radius="lg"
+          className="font-bold h-12 px-8 shadow-lg shadow-primary/20"
+          startContent={<FaPlus />} 
+          onPress={() => openEditor(null)}
+        >
+          新建模板
+        </Button>
+      </div>
+
+      <Card className="shadow-sm border border-divider">
+        <CardBody className="p-0">
+          <Table 
+            aria-label="审核模板列表"
+            classNames={{
+              wrapper: "shadow-none bg-transparent",
+              th: "bg-default-100 text-default-600 font-bold py-4",
+              td: "py-4"
+            }}
+          >
+            <TableHeader>
+              <TableColumn>模板</TableColumn>
+              <TableColumn width={100}>类型</TableColumn>
+              <TableColumn width={140}>使用次数（近 30 天）</TableColumn>
+              <TableColumn width={120} align="center">操作</TableColumn>
+            </TableHeader>
+            <TableBody emptyContent="暂无模板">
+              {templates.map((item) => (
+                <TableRow key={item.id}>
+                  <TableCell>
+                    <p className="font-bold text-sm">{item.title}</p>
+                    <div className="whitespace-pre-wrap text-xs text-default-500 line-clamp-2">{item.body}</div>
+                  </TableCell>
+                  <TableCell>
+                    <Chip size="sm" variant="flat" color={item.kind === 'approval' ? 'success' : 'danger'}>
+                      {item.kind === 'approval' ? '批准' : '拒绝'}
+                    </Chip>
+                  </TableCell>
+                  <TableCell>
+                    <span className="text-sm font-medium">
+                      {stats[item.id]?.totalUses || 0}（{stats[item.id]?.recentUses || 0}）
+                    </span>
+                  </TableCell>
+                  <TableCell>
+                    <div className="flex justify-center gap-1">
+                      <Button isIconOnly variant="light" size="sm" onPress={() => openEditor(item)}>
+                        <FaEdit size={14} />
+                      </Button>
+                      <Button isIconOnly color="danger" variant="light" size="sm" onPress={() => handleDelete(item.id)}>
+                        <FaTrash size={14} />
+                      </Button>
+                    </div>
+                  </TableCell>
+                </TableRow>
+              ))}
+            </TableBody>
+          </Table>
+        </CardBody>
+      </Card>
+
+      <Modal 
+        isOpen={isOpen} 
+        onClose={onClose} 
+        backdrop="blur"
+        radius="lg"
+        classNames={{
+          header: "border-b border-divider/50 px-8 py-6",
+          body: "px-8 py-6",
+          footer: "border-t border-divider/50 px-8 py-4"
+        }}
+      >
+        <ModalContent>
+          <ModalHeader>
+            <h3 className="text-xl font-black">{editing ? '编辑模板' : '新建模板'}</h3>
+          </ModalHeader>
+          <ModalBody>
+            <Input
+              label="标题"
+              variant="bordered"
+              radius="lg"
+              value={title}
+              onValueChange={setTitle}
+            />
+            <Select
+              label="类型"
+              variant="bordered"
+              radius="lg"
+              selectedKeys={[kind]}
+              onChange={(e) => setKind((e.target.value || 'rejection') as 'approval' | 'rejection')}
+            >
+              <SelectItem key="approval">批准</SelectItem>
+              <SelectItem key="rejection">拒绝</SelectItem>
+            </Select>
+            <Textarea
+              label="内容"
+              placeholder="您好 {{email}}，感谢您对 {{site_name}} 的关注..."
+              variant="bordered"
+              radius="lg"
+              minRows={4}
+              value={body}
+              onValueChange={setBody}
+              classNames={{
+                input: "text-sm"
+              }}
+            />
+          </ModalBody>
+          <ModalFooter>
+            <Button variant="light" color="primary" onPress={onClose} radius="lg" className="font-bold">取消</Button>
+            <Button 
+              color="primary" 
+              onPress={handleSave} 
+              isLoading={isSubmitting}
+              radius="lg"
+              className="font-bold shadow-lg"
+            >
+              保存
+            </Button>
+          </ModalFooter>
+        </ModalContent>
+      </Modal>
+    </div>
+  );
+}
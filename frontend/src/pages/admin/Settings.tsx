@@ -5,6 +5,8 @@ import {
 import { FaSave, FaCog, FaEnvelope, FaShieldAlt, FaKey, FaLinux } from 'react-icons/fa';
 import api from '../../api/client';
 import toast from 'react-hot-toast';
+import PowDifficulty from './PowDifficulty';
+import EmailBlocklist from './EmailBlocklist';
 
 export default function Settings() {
   const [settings, setSettings] = useState<Record<string, string>>({});
@@ -160,6 +162,28 @@ export default function Settings() {
                 onValueChange={(val) => handleChange('risk_control_enabled', val ? 'true' : 'false')}
               />
             </div>
+            <div className="flex justify-between items-center p-4 bg-default-50 rounded-large border border-divider">
+              <div>
+                <p className="text-sm font-bold">临时邮箱拦截</p>
+                <p className="text-tiny text-default-500">未配置白名单时拒绝一次性邮箱域名</p>
+              </div>
+              <Switch 
+                color="primary"
+                isSelected={settings.email_blocklist_enabled === 'true'} 
+                onValueChange={(val) => handleChange('email_blocklist_enabled', val ? 'true' : 'false')}
+              />
+            </div>
+            <div className="flex justify-between items-center p-4 bg-default-50 rounded-large border border-divider">
+              <div>
+                <p className="text-sm font-bold">MX 记录检查</p>
+                <p className="text-tiny text-default-500">拒绝没有邮件服务器（MX 记录）的邮箱域名</p>
+              </div>
+              <Switch 
+                color="primary"
+                isSelected={settings.email_mx_check_enabled === 'true'} 
+                onValueChange={(val) => handleChange('email_mx_check_enabled', val ? 'true' : 'false')}
+              />
+            </div>
             <div className="flex justify-between items-center p-4 bg-default-50 rounded-large border border-divider">
               <div>
                 <p className="text-sm font-bold">注册审核</p>
@@ -171,6 +195,17 @@ export default function Settings() {
                 onValueChange={(val) => handleChange('require_audit', val ? 'true' : 'false')}
               />
             </div>
+            <div className="flex justify-between items-center p-4 bg-default-50 rounded-large border border-divider">
+              <div>
+                <p className="text-sm font-bold">双人审核</p>
+                <p className="text-tiny text-default-500">批准后需另一位管理员复核才发放邀请码</p>
+              </div>
+              <Switch 
+                color="primary"
+                isSelected={settings.require_dual_approval === 'true'} 
+                onValueChange={(val) => handleChange('require_dual_approval', val ? 'true' : 'false')}
+              />
+            </div>
             <Input
               label="网站名称"
               placeholder="例如: Invite System"
@@ -184,6 +219,20 @@ export default function Settings() {
                 inputWrapper: "border-2"
               }}
             />
+            <Input
+              label="站点地址"
+              placeholder="例如: https://invite.example.com"
+              description="用于生成邮件中的申诉等链接，留空则邮件中不附带链接"
+              value={settings.site_url || ''}
+              onValueChange={(val) => handleChange('site_url', val)}
+              variant="bordered"
+              radius="lg"
+              size="lg"
+              classNames={{
+                label: "font-bold text-default-500",
+                inputWrapper: "border-2"
+              }}
+            />
             <Textarea
               label="首页公告 (副标题)"
               placeholder="显示在首页大标题下方的介绍文字"
@@ -233,6 +282,34 @@ export default function Settings() {
                 inputWrapper: "border-2"
               }}
             />
+            <Input
+              label="被拒绝后重新申请冷却天数"
+              type="number"
+              description="最近一次被拒绝后需等待的天数；与下一项均为 0 时被拒绝的邮箱不能重新申请"
+              value={settings.reapply_cooldown_days ?? '0'}
+              onValueChange={(val) => handleChange('reapply_cooldown_days', val)}
+              variant="bordered"
+              radius="lg"
+              size="lg"
+              classNames={{
+                label: "font-bold text-default-500",
+                inputWrapper: "border-2"
+              }}
+            />
+            <Input
+              label="单邮箱被拒绝次数上限"
+              type="number"
+              description="累计被拒绝达到该次数后不可再申请，0 表示不限次数；与上一项均为 0 时不开启重新申请"
+              value={settings.max_rejections_per_email ?? '0'}
+              onValueChange={(val) => handleChange('max_rejections_per_email', val)}
+              variant="bordered"
+              radius="lg"
+              size="lg"
+              classNames={{
+                label: "font-bold text-default-500",
+                inputWrapper: "border-2"
+              }}
+            />
             <Input
               label="单 IP 提交上限"
               type="number"
@@ -246,6 +323,62 @@ export default function Settings() {
                 inputWrapper: "border-2"
               }}
             />
+            <Input
+              label="风险分标记阈值"
+              type="number"
+              description="提交时风险分达到该值的申请标记为需人工复核，0 表示不标记"
+              value={settings.risk_flag_score ?? '40'}
+              onValueChange={(val) => handleChange('risk_flag_score', val)}
+              variant="bordered"
+              radius="lg"
+              size="lg"
+              classNames={{
+                label: "font-bold text-default-500",
+                inputWrapper: "border-2"
+              }}
+            />
+            <Input
+              label="风险分拒绝阈值"
+              type="number"
+              description="开启风控时，风险分达到该值的提交直接拒绝，0 表示不自动拒绝"
+              value={settings.risk_reject_score ?? '100'}
+              onValueChange={(val) => handleChange('risk_reject_score', val)}
+              variant="bordered"
+              radius="lg"
+              size="lg"
+              classNames={{
+                label: "font-bold text-default-500",
+                inputWrapper: "border-2"
+              }}
+            />
+            <Input
+              label="理由相似度标记阈值（%）"
+              type="number"
+              description="申请理由与其他申请人的历史申请相似度达到该值时标记人工复核，0 表示不标记"
+              value={settings.reason_similarity_threshold ?? '80'}
+              onValueChange={(val) => handleChange('reason_similarity_threshold', val)}
+              variant="bordered"
+              radius="lg"
+              size="lg"
+              classNames={{
+                label: "font-bold text-default-500",
+                inputWrapper: "border-2"
+              }}
+            />
+            <Input
+              label="PoW 流量激增阈值"
+              type="number"
+              description="10 分钟内提交量达到该值时自动提高 PoW 难度"
+              value={settings.pow_surge_volume ?? '30'}
+              onValueChange={(val) => handleChange('pow_surge_volume', val)}
+              variant="bordered"
+              radius="lg"
+              size="lg"
+              classNames={{
+                label: "font-bold text-default-500",
+                inputWrapper: "border-2"
+              }}
+            />
             <Textarea
               label="邮箱白名单"
               placeholder="允许的后缀, 如: gmail.com, qq.com"
@@ -401,6 +534,10 @@ export default function Settings() {
             />
           </CardBody>
         </Card>
+
+        <PowDifficulty />
+
+        <EmailBlocklist />
       </div>
 
       <Modal 
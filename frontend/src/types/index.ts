@@ -24,11 +24,13 @@ export interface ApplicationSubmit {
 
 export interface ApplicationStatus {
   email: string;
-  status: string; // pending, approved, rejected
+  status: string; // pending, approved, rejected, withdrawn
   reason?: string;
   adminNote?: string;
   createdAt: string;
   inviteCode?: string;
+  reapplyAt?: string | null;
+  reapplyExhausted?: boolean;
 }
 
 export interface AdminLoginResponse {
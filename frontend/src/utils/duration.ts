@@ -0,0 +1,7 @@
+// formatDuration 将秒数格式化为便于阅读的时长
+export function formatDuration(seconds: number) {
+  if (seconds < 3600) return `${Math.max(1, Math.round(seconds / 60))} 分钟`;
+  const hours = seconds / 3600;
+  if (hours < 48) return `${hours.toFixed(1)} 小时`;
+  return `${(hours / 24).toFixed(1)} 天`;
+}
@@ -0,0 +1,53 @@
+import api from '../api/client';
+
+export interface PowSolution {
+  powSalt: string;
+  powNonce: number;
+}
+
+export interface PowSolveOptions {
+  // 求解进度（0-99），按难度的期望尝试次数估算
+  onProgress?: (percent: number) => void;
+  // 求解超时时间，需短于挑战 5 分钟的有效期
+  timeoutMs?: number;
+}
+
+// PowTimeoutError 求解超时，提示用户稍后重试
+export class PowTimeoutError extends Error {
+  constructor() {
+    super('安全校验耗时过长，请稍后重试');
+    this.name = 'PowTimeoutError';
+  }
+}
+
+const DEFAULT_TIMEOUT_MS = 2 * 60 * 1000;
+const PROGRESS_INTERVAL = 2000;
+
+async function sha256Hex(input: string): Promise<string> {
+  const digest = await crypto.subtle.digest('SHA-256', new TextEncoder().encode(input));
+  return Array.from(new Uint8Array(digest)).map((b) => b.toString(16).padStart(2, '0')).join('');
+}
+
+// solveSecurityChallenge 获取绑定设备指纹的 PoW 挑战并求解：
+// 找到 nonce 使 sha256(salt:fingerprint:nonce) 以 difficulty 个 0 开头，每个解答只能使用一次
+export async function solveSecurityChallenge(fingerprint: string, options: PowSolveOptions = {}): Promise<PowSolution> {
+  const res = await api.get('/security-challenge', { params: { fingerprint } });
+  const { salt, difficulty } = res.data as { salt: string; difficulty: number };
+  const prefix = '0'.repeat(difficulty);
+  const expected = Math.pow(16, difficulty);
+  const deadline = Date.now() + (options.timeoutMs ?? DEFAULT_TIMEOUT_MS);
+
+  for (let nonce = 0; ; nonce++) {
+    const hash = await sha256Hex(`${salt}:${fingerprint}:${nonce}`);
+    if (hash.startsWith(prefix)) {
+      options.onProgress?.(100);
+      return { powSalt: salt, powNonce: nonce };
+    }
+    if (nonce % PROGRESS_INTERVAL === 0) {
+      if (Date.now() > deadline) {
+        throw new PowTimeoutError();
+      }
+      options.onProgress?.(Math.min(99, Math.floor((nonce / expected) * 100)));
+    }
+  }
+}